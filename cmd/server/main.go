@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/huxxnainali/finance-app/internal/auth"
 	"github.com/huxxnainali/finance-app/internal/config"
+	"github.com/huxxnainali/finance-app/internal/connectors"
 	"github.com/huxxnainali/finance-app/internal/db"
 	"github.com/huxxnainali/finance-app/internal/handlers"
+	"github.com/huxxnainali/finance-app/internal/jobs"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/notifiers"
 	"github.com/huxxnainali/finance-app/internal/services"
 )
 
@@ -29,14 +35,92 @@ func main() {
 
 	// Initialize services
 	userService := services.NewUserService(database)
-	budgetService := services.NewBudgetService(database)
-	fundService := services.NewFundService(database)
+	notificationSenders := map[models.NotificationChannel]notifiers.Sender{
+		models.NotificationChannelWebhook: notifiers.NewWebhook(10 * time.Second),
+	}
+	if cfg.SMTPHost != "" {
+		notificationSenders[models.NotificationChannelEmail] = notifiers.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	notificationService := services.NewNotificationService(database, userService, notificationSenders)
+	var alertNotifier services.AlertNotifier = notificationService
+	if cfg.AlertNotifier == "log" {
+		alertNotifier = services.LogAlertNotifier{}
+	}
+	alertService := services.NewAlertService(database, alertNotifier)
+	budgetService := services.NewBudgetService(database, alertService)
+	contactService := services.NewContactService(database)
+	fundService := services.NewFundService(database, contactService, budgetService, notificationService)
+	ruleService := services.NewRuleService(database, budgetService)
+	accountService := services.NewAccountService(database, budgetService)
+	importService := services.NewImportService(database, budgetService, ruleService, accountService)
+	duplicateService := services.NewDuplicateService(database, budgetService)
+	exportService := services.NewExportService(budgetService)
+	statementService := services.NewStatementService(budgetService, fundService)
+	ledgerService := services.NewLedgerService(database, budgetService, fundService)
+	reconciliationService := services.NewReconciliationService(database, accountService, budgetService)
+	bankConnectors := connectors.Registry{
+		connectors.MockProvider: connectors.NewMock(cfg.MockBankDir),
+	}
+	bankSyncService := services.NewBankSyncService(database, bankConnectors, budgetService, ruleService, accountService)
+	billService := services.NewBillService(database, budgetService, accountService, notificationService)
+
+	// Link funds from before contacts existed to contacts
+	linked, err := contactService.LinkFunds(context.Background())
+	if err != nil {
+		log.Printf("Linking funds to contacts failed: %v", err)
+	} else if linked > 0 {
+		log.Printf("Linked %d funds to contacts", linked)
+	}
+
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	jobs.Every(jobsCtx, "empty budget cleanup", cfg.BudgetCleanupInterval, func(ctx context.Context) error {
+		deleted, err := budgetService.DeleteEmptyBudgets(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d empty budgets", deleted)
+		}
+		return nil
+	})
+
+	jobs.EveryOrWake(jobsCtx, "notification delivery", cfg.NotificationInterval, notificationService.Wake(), notificationService.DeliverPending)
+	jobs.Every(jobsCtx, "bank sync", cfg.BankSyncInterval, bankSyncService.SyncAll)
+	jobs.Every(jobsCtx, "bill autopay", cfg.BillJobInterval, billService.PayAutopayBills)
+	jobs.Every(jobsCtx, "bill reminders", cfg.BillJobInterval, billService.SendBillReminders)
+	jobs.Every(jobsCtx, "fund reminders", cfg.FundJobInterval, fundService.SendDueReminders)
+	jobs.Every(jobsCtx, "orphaned fund transaction cleanup", cfg.FundJobInterval, func(ctx context.Context) error {
+		purged, err := fundService.PurgeOrphanedTransactions(ctx)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("Deleted %d transactions of deleted funds", purged)
+		}
+		return nil
+	})
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userService, cfg)
+	authHandler := handlers.NewAuthHandler(userService, notificationService, cfg)
 	budgetHandler := handlers.NewBudgetHandler(budgetService)
-	expenseHandler := handlers.NewExpenseHandler(budgetService)
+	expenseHandler := handlers.NewExpenseHandler(budgetService, ruleService, accountService)
 	fundHandler := handlers.NewFundHandler(fundService)
+	contactHandler := handlers.NewContactHandler(contactService, fundService)
+	importHandler := handlers.NewImportHandler(importService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	duplicateHandler := handlers.NewDuplicateHandler(duplicateService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	bankHandler := handlers.NewBankHandler(bankSyncService)
+	billHandler := handlers.NewBillHandler(billService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	alertHandler := handlers.NewAlertHandler(alertService)
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
@@ -45,9 +129,10 @@ func main() {
 
 	// Middleware
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Content-Type,Authorization",
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Content-Type,Authorization",
+		ExposeHeaders: "X-Total-Count",
 	}))
 	app.Use(logger.New())
 
@@ -69,6 +154,8 @@ func main() {
 	budgetGroup.Use(auth.AuthMiddleware(cfg))
 	budgetGroup.Get("/current", budgetHandler.GetCurrentBudget)
 	budgetGroup.Get("/", budgetHandler.GetBudgetByMonth)
+	budgetGroup.Get("/summary", budgetHandler.GetBudgetSummary)
+	budgetGroup.Get("/statement", statementHandler.GetMonthlyStatement)
 	budgetGroup.Post("/base-income", budgetHandler.SetBaseIncome)
 	budgetGroup.Put("/base-income", budgetHandler.SetBaseIncome)
 
@@ -83,13 +170,137 @@ func main() {
 	fundGroup := app.Group("/funds")
 	fundGroup.Use(auth.AuthMiddleware(cfg))
 	fundGroup.Get("/", fundHandler.GetAllFunds)
+	fundGroup.Get("/overdue", fundHandler.GetOverdueFunds)
 	fundGroup.Get("/:fundId", fundHandler.GetFundByID)
 	fundGroup.Post("/", fundHandler.CreateFund)
 	fundGroup.Put("/:fundId", fundHandler.UpdateFund)
 	fundGroup.Delete("/:fundId", fundHandler.DeleteFund)
+	fundGroup.Get("/:fundId/schedule", fundHandler.GetRepaymentSchedule)
+	fundGroup.Put("/:fundId/plan", fundHandler.SetRepaymentPlan)
+	fundGroup.Delete("/:fundId/plan", fundHandler.DeleteRepaymentPlan)
+	fundGroup.Put("/:fundId/status", fundHandler.SetFundStatus)
 	fundGroup.Post("/:fundId/transactions", fundHandler.AddTransaction)
 	fundGroup.Put("/:fundId/transactions/:transactionId", fundHandler.UpdateTransaction)
 	fundGroup.Delete("/:fundId/transactions/:transactionId", fundHandler.DeleteTransaction)
+	fundGroup.Post("/:fundId/write-offs", fundHandler.WriteOffFund)
+	fundGroup.Post("/:fundId/write-offs/:transactionId/reverse", fundHandler.ReverseWriteOff)
+
+	// Contact routes
+	contactGroup := app.Group("/contacts")
+	contactGroup.Use(auth.AuthMiddleware(cfg))
+	contactGroup.Get("/", contactHandler.GetContacts)
+	contactGroup.Get("/balances", contactHandler.GetBalances)
+	contactGroup.Get("/:contactId", contactHandler.GetContactByID)
+	contactGroup.Get("/:contactId/balance", contactHandler.GetBalance)
+	contactGroup.Post("/", contactHandler.CreateContact)
+	contactGroup.Put("/:contactId", contactHandler.UpdateContact)
+	contactGroup.Delete("/:contactId", contactHandler.DeleteContact)
+	contactGroup.Get("/:contactId/settlements", contactHandler.GetSettlements)
+	contactGroup.Post("/:contactId/settlements", contactHandler.SettleContact)
+
+	// Account routes
+	accountGroup := app.Group("/accounts")
+	accountGroup.Use(auth.AuthMiddleware(cfg))
+	accountGroup.Get("/", accountHandler.GetAccounts)
+	accountGroup.Get("/:accountId", accountHandler.GetAccountByID)
+	accountGroup.Post("/", accountHandler.CreateAccount)
+	accountGroup.Put("/:accountId", accountHandler.UpdateAccount)
+	accountGroup.Delete("/:accountId", accountHandler.DeleteAccount)
+	accountGroup.Get("/:accountId/ledger", accountHandler.GetAccountLedger)
+	accountGroup.Get("/:accountId/reconciliations", reconciliationHandler.GetReconciliations)
+	accountGroup.Post("/:accountId/reconciliations", reconciliationHandler.StartReconciliation)
+
+	// Transfer routes
+	transferGroup := app.Group("/transfers")
+	transferGroup.Use(auth.AuthMiddleware(cfg))
+	transferGroup.Get("/", accountHandler.GetTransfers)
+	transferGroup.Post("/", accountHandler.CreateTransfer)
+	transferGroup.Delete("/:transferId", accountHandler.DeleteTransfer)
+
+	// Reconciliation routes
+	reconciliationGroup := app.Group("/reconciliations")
+	reconciliationGroup.Use(auth.AuthMiddleware(cfg))
+	reconciliationGroup.Get("/:reconciliationId", reconciliationHandler.GetReconciliation)
+	reconciliationGroup.Post("/:reconciliationId/clear", reconciliationHandler.ClearEntries)
+	reconciliationGroup.Post("/:reconciliationId/complete", reconciliationHandler.CompleteReconciliation)
+	reconciliationGroup.Delete("/:reconciliationId", reconciliationHandler.DeleteReconciliation)
+
+	// Bank connection routes
+	bankGroup := app.Group("/bank-connections")
+	bankGroup.Use(auth.AuthMiddleware(cfg))
+	bankGroup.Get("/", bankHandler.GetConnections)
+	bankGroup.Get("/:connectionId", bankHandler.GetConnectionByID)
+	bankGroup.Post("/", bankHandler.LinkConnections)
+	bankGroup.Put("/:connectionId", bankHandler.UpdateConnection)
+	bankGroup.Delete("/:connectionId", bankHandler.DeleteConnection)
+	bankGroup.Post("/:connectionId/sync", bankHandler.SyncConnection)
+
+	// Bill routes
+	billGroup := app.Group("/bills")
+	billGroup.Use(auth.AuthMiddleware(cfg))
+	billGroup.Get("/", billHandler.GetBills)
+	billGroup.Get("/upcoming", billHandler.GetUpcomingBills)
+	billGroup.Get("/:billId", billHandler.GetBillByID)
+	billGroup.Post("/", billHandler.CreateBill)
+	billGroup.Put("/:billId", billHandler.UpdateBill)
+	billGroup.Delete("/:billId", billHandler.DeleteBill)
+	billGroup.Post("/:billId/pay", billHandler.PayBill)
+
+	// Notification routes
+	notificationGroup := app.Group("/notifications")
+	notificationGroup.Use(auth.AuthMiddleware(cfg))
+	notificationGroup.Get("/", notificationHandler.GetInbox)
+	notificationGroup.Post("/read-all", notificationHandler.MarkAllRead)
+	notificationGroup.Post("/:notificationId/read", notificationHandler.MarkRead)
+	notificationGroup.Get("/preferences", notificationHandler.GetPreferences)
+	notificationGroup.Put("/preferences", notificationHandler.UpdatePreferences)
+
+	// Budget alert routes
+	alertGroup := app.Group("/alerts")
+	alertGroup.Use(auth.AuthMiddleware(cfg))
+	alertGroup.Get("/", alertHandler.GetAlerts)
+	alertGroup.Get("/triggers", alertHandler.GetTriggers)
+	alertGroup.Get("/:alertId", alertHandler.GetAlertByID)
+	alertGroup.Post("/", alertHandler.CreateAlert)
+	alertGroup.Put("/:alertId", alertHandler.UpdateAlert)
+	alertGroup.Delete("/:alertId", alertHandler.DeleteAlert)
+
+	// Import routes
+	importGroup := app.Group("/imports")
+	importGroup.Use(auth.AuthMiddleware(cfg))
+	importGroup.Get("/", importHandler.GetImports)
+	importGroup.Get("/:importId", importHandler.GetImportByID)
+	importGroup.Post("/", importHandler.CreateImport)
+	importGroup.Post("/:importId/preview", importHandler.PreviewImport)
+	importGroup.Post("/:importId/commit", importHandler.CommitImport)
+	importGroup.Post("/:importId/rollback", importHandler.RollbackImport)
+
+	// Categorization rule routes
+	ruleGroup := app.Group("/rules")
+	ruleGroup.Use(auth.AuthMiddleware(cfg))
+	ruleGroup.Get("/", ruleHandler.GetRules)
+	ruleGroup.Post("/", ruleHandler.CreateRule)
+	ruleGroup.Post("/dry-run", ruleHandler.DryRunRule)
+	ruleGroup.Get("/:ruleId", ruleHandler.GetRuleByID)
+	ruleGroup.Put("/:ruleId", ruleHandler.UpdateRule)
+	ruleGroup.Delete("/:ruleId", ruleHandler.DeleteRule)
+	ruleGroup.Post("/:ruleId/dry-run", ruleHandler.DryRunSavedRule)
+	ruleGroup.Post("/:ruleId/apply", ruleHandler.ApplyRuleToHistory)
+
+	// Duplicate expense routes
+	duplicateGroup := app.Group("/duplicates")
+	duplicateGroup.Use(auth.AuthMiddleware(cfg))
+	duplicateGroup.Get("/", duplicateHandler.GetDuplicates)
+	duplicateGroup.Post("/merge", duplicateHandler.MergeDuplicates)
+	duplicateGroup.Post("/dismiss", duplicateHandler.DismissDuplicate)
+
+	// Export routes
+	exportGroup := app.Group("/exports")
+	exportGroup.Use(auth.AuthMiddleware(cfg))
+	exportGroup.Get("/", exportHandler.ExportBudgets)
+	exportGroup.Get("/journal", ledgerHandler.ExportJournal)
+	exportGroup.Get("/journal/accounts", ledgerHandler.GetAccounts)
+	exportGroup.Put("/journal/accounts", ledgerHandler.UpdateAccounts)
 
 	// 404 handler
 	app.Use(func(c *fiber.Ctx) error {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +14,20 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiryHours int
 	Port           string
+
+	BudgetCleanupInterval time.Duration
+	BankSyncInterval      time.Duration
+	BillJobInterval       time.Duration
+	FundJobInterval       time.Duration
+	MockBankDir           string
+
+	NotificationInterval time.Duration
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	AlertNotifier        string
 }
 
 func LoadConfig() *Config {
@@ -20,6 +35,11 @@ func LoadConfig() *Config {
 	_ = godotenv.Load()
 
 	jwtExpiryHours, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
+	budgetCleanupHours, _ := strconv.Atoi(getEnv("BUDGET_CLEANUP_INTERVAL_HOURS", "24"))
+	bankSyncMinutes, _ := strconv.Atoi(getEnv("BANK_SYNC_INTERVAL_MINUTES", "60"))
+	billJobMinutes, _ := strconv.Atoi(getEnv("BILL_JOB_INTERVAL_MINUTES", "60"))
+	fundJobMinutes, _ := strconv.Atoi(getEnv("FUND_JOB_INTERVAL_MINUTES", "60"))
+	notificationSeconds, _ := strconv.Atoi(getEnv("NOTIFICATION_INTERVAL_SECONDS", "30"))
 
 	return &Config{
 		MongoDBURI:     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
@@ -27,6 +47,20 @@ func LoadConfig() *Config {
 		JWTSecret:      getEnv("JWT_SECRET", "your-super-secret-key"),
 		JWTExpiryHours: jwtExpiryHours,
 		Port:           getEnv("PORT", "3000"),
+
+		BudgetCleanupInterval: time.Duration(budgetCleanupHours) * time.Hour,
+		BankSyncInterval:      time.Duration(bankSyncMinutes) * time.Minute,
+		BillJobInterval:       time.Duration(billJobMinutes) * time.Minute,
+		FundJobInterval:       time.Duration(fundJobMinutes) * time.Minute,
+		MockBankDir:           getEnv("MOCK_BANK_DIR", "mockbank"),
+
+		NotificationInterval: time.Duration(notificationSeconds) * time.Second,
+		SMTPHost:             getEnv("SMTP_HOST", ""),
+		SMTPPort:             getEnv("SMTP_PORT", "587"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", "Finance Tracker <no-reply@localhost>"),
+		AlertNotifier:        getEnv("ALERT_NOTIFIER", "notifications"),
 	}
 }
 
@@ -0,0 +1,99 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrCredentialsExpired is returned by Fetch when the access token has to be refreshed
+var ErrCredentialsExpired = errors.New("connector credentials expired")
+
+// ErrReauthRequired is returned when the user has to go through the provider's link flow again
+var ErrReauthRequired = errors.New("connector requires the user to link the account again")
+
+// Credentials are the tokens a provider issues for a linked login
+type Credentials struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// Expired reports whether the access token has expired, with a small margin so
+// tokens aren't used right before they run out
+func (c Credentials) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Add(time.Minute).Before(c.ExpiresAt)
+}
+
+// Account is a bank account made available by a link
+type Account struct {
+	ID   string
+	Name string
+	Type string
+}
+
+// Link is the result of exchanging a link token for credentials
+type Link struct {
+	Credentials Credentials
+	Accounts    []Account
+}
+
+// Transaction is a single transaction reported by a provider.
+// Amount is signed: negative amounts are money going out of the account.
+// Pending transactions may change or disappear; once posted, a transaction
+// carries the ID of the pending transaction it replaces in PendingID.
+type Transaction struct {
+	ID          string
+	PendingID   string
+	Date        time.Time
+	Description string
+	Payee       string
+	Amount      float64
+	Pending     bool
+}
+
+// Page is one batch of transactions. Removed lists the IDs of pending transactions
+// the provider dropped without posting them, e.g. a cancelled card hold, and applies
+// after the page's transactions. Cursor is handed back on the next fetch to continue
+// where this page stopped.
+type Page struct {
+	Transactions []Transaction
+	Removed      []string
+	Cursor       string
+	HasMore      bool
+}
+
+// Connector is a bank aggregation provider
+type Connector interface {
+	// Link exchanges a token from the provider's link flow for credentials and accounts
+	Link(ctx context.Context, linkToken string) (*Link, error)
+	// Fetch returns the account's transactions added and pending ones removed since
+	// cursor; an empty cursor starts from the beginning
+	Fetch(ctx context.Context, credentials Credentials, accountID, cursor string) (*Page, error)
+	// Refresh exchanges the refresh token for new credentials
+	Refresh(ctx context.Context, credentials Credentials) (*Credentials, error)
+}
+
+// Registry holds the available connectors by provider name
+type Registry map[string]Connector
+
+// Get returns the connector for a provider
+func (r Registry) Get(provider string) (Connector, error) {
+	connector, ok := r[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown bank provider %q", provider)
+	}
+	return connector, nil
+}
+
+// Providers lists the registered provider names in alphabetical order
+func (r Registry) Providers() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,205 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MockProvider is the provider name of the file-backed mock connector
+const MockProvider = "mock"
+
+const (
+	mockTokenTTL = time.Hour
+	mockPageSize = 100
+)
+
+// mockLinkToken limits link tokens to plain file names
+var mockLinkToken = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// mockFile is the layout of a mock institution, stored as <dir>/<linkToken>.json.
+// The file is read on every call, so transactions can be appended (e.g. the posted
+// version of a pending one) while the server is running. An appended entry with
+// removed set drops the pending transaction with its ID instead. Setting reauthRequired
+// simulates a revoked login: fetches fail as expired and refreshes are refused.
+type mockFile struct {
+	ReauthRequired bool          `json:"reauthRequired"`
+	Accounts       []mockAccount `json:"accounts"`
+}
+
+type mockAccount struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Transactions []mockTransaction `json:"transactions"`
+}
+
+type mockTransaction struct {
+	ID          string  `json:"id"`
+	PendingID   string  `json:"pendingId"`
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	Payee       string  `json:"payee"`
+	Amount      float64 `json:"amount"`
+	Pending     bool    `json:"pending"`
+	Removed     bool    `json:"removed"`
+}
+
+// Mock is a connector backed by JSON files in a local directory, for development
+// and testing without a real aggregator. The link token names the file, and the
+// cursor is the number of transactions already returned for the account.
+type Mock struct {
+	dir string
+}
+
+// NewMock creates a mock connector reading institutions from dir
+func NewMock(dir string) *Mock {
+	return &Mock{dir: dir}
+}
+
+// Link checks that the institution file exists and issues short-lived credentials
+func (m *Mock) Link(ctx context.Context, linkToken string) (*Link, error) {
+	file, err := m.load(linkToken)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{Credentials: m.credentials(linkToken)}
+	for _, account := range file.Accounts {
+		link.Accounts = append(link.Accounts, Account{
+			ID:   account.ID,
+			Name: account.Name,
+			Type: account.Type,
+		})
+	}
+	if len(link.Accounts) == 0 {
+		return nil, fmt.Errorf("mock institution %q has no accounts", linkToken)
+	}
+
+	return link, nil
+}
+
+// Fetch returns up to a page of the account's transactions after the cursor
+func (m *Mock) Fetch(ctx context.Context, credentials Credentials, accountID, cursor string) (*Page, error) {
+	if credentials.Expired(time.Now()) {
+		return nil, ErrCredentialsExpired
+	}
+
+	linkToken, ok := strings.CutPrefix(credentials.AccessToken, "mock-access:")
+	if !ok {
+		return nil, fmt.Errorf("invalid mock access token")
+	}
+	file, err := m.load(linkToken)
+	if err != nil {
+		return nil, err
+	}
+	if file.ReauthRequired {
+		return nil, ErrCredentialsExpired
+	}
+
+	var account *mockAccount
+	for i := range file.Accounts {
+		if file.Accounts[i].ID == accountID {
+			account = &file.Accounts[i]
+			break
+		}
+	}
+	if account == nil {
+		return nil, fmt.Errorf("mock account %q not found", accountID)
+	}
+
+	offset := 0
+	if cursor != "" {
+		offset, err = strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+	}
+	if offset > len(account.Transactions) {
+		offset = len(account.Transactions)
+	}
+
+	end := offset + mockPageSize
+	if end > len(account.Transactions) {
+		end = len(account.Transactions)
+	}
+
+	page := &Page{
+		Cursor:  strconv.Itoa(end),
+		HasMore: end < len(account.Transactions),
+	}
+	for _, tx := range account.Transactions[offset:end] {
+		if tx.Removed {
+			page.Removed = append(page.Removed, tx.ID)
+			continue
+		}
+		date, err := time.Parse("2006-01-02", tx.Date)
+		if err != nil {
+			return nil, fmt.Errorf("mock transaction %q has invalid date %q", tx.ID, tx.Date)
+		}
+		page.Transactions = append(page.Transactions, Transaction{
+			ID:          tx.ID,
+			PendingID:   tx.PendingID,
+			Date:        date,
+			Description: tx.Description,
+			Payee:       tx.Payee,
+			Amount:      tx.Amount,
+			Pending:     tx.Pending,
+		})
+	}
+
+	return page, nil
+}
+
+// Refresh issues new credentials unless the institution file asks for a new link
+func (m *Mock) Refresh(ctx context.Context, credentials Credentials) (*Credentials, error) {
+	linkToken, ok := strings.CutPrefix(credentials.RefreshToken, "mock-refresh:")
+	if !ok {
+		return nil, ErrReauthRequired
+	}
+	file, err := m.load(linkToken)
+	if err != nil {
+		return nil, err
+	}
+	if file.ReauthRequired {
+		return nil, ErrReauthRequired
+	}
+
+	refreshed := m.credentials(linkToken)
+	return &refreshed, nil
+}
+
+func (m *Mock) credentials(linkToken string) Credentials {
+	return Credentials{
+		AccessToken:  "mock-access:" + linkToken,
+		RefreshToken: "mock-refresh:" + linkToken,
+		ExpiresAt:    time.Now().Add(mockTokenTTL),
+	}
+}
+
+func (m *Mock) load(linkToken string) (*mockFile, error) {
+	if !mockLinkToken.MatchString(linkToken) {
+		return nil, fmt.Errorf("invalid mock link token")
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.dir, linkToken+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("mock institution %q not found", linkToken)
+		}
+		return nil, err
+	}
+
+	file := &mockFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("invalid mock institution %q: %v", linkToken, err)
+	}
+
+	return file, nil
+}
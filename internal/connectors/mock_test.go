@@ -0,0 +1,230 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeInstitution stores a mock institution file and returns its link token
+func writeInstitution(t *testing.T, dir, linkToken string, file mockFile) string {
+	t.Helper()
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, linkToken+".json"), data, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return linkToken
+}
+
+func TestMockLink(t *testing.T) {
+	dir := t.TempDir()
+	writeInstitution(t, dir, "demo-bank", mockFile{Accounts: []mockAccount{
+		{ID: "chk", Name: "Checking", Type: "depository"},
+		{ID: "cc", Name: "Card", Type: "credit"},
+	}})
+	writeInstitution(t, dir, "empty", mockFile{})
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600)
+
+	tests := []struct {
+		linkToken string
+		accounts  int
+		wantErr   bool
+	}{
+		{linkToken: "demo-bank", accounts: 2},
+		{linkToken: "empty", wantErr: true},
+		{linkToken: "broken", wantErr: true},
+		{linkToken: "missing", wantErr: true},
+		{linkToken: "../demo-bank", wantErr: true},
+		{linkToken: "", wantErr: true},
+	}
+
+	mock := NewMock(dir)
+	for _, tt := range tests {
+		link, err := mock.Link(context.Background(), tt.linkToken)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Link(%q) succeeded, want an error", tt.linkToken)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Link(%q): %v", tt.linkToken, err)
+			continue
+		}
+		if len(link.Accounts) != tt.accounts {
+			t.Errorf("Link(%q) has %d accounts, want %d", tt.linkToken, len(link.Accounts), tt.accounts)
+		}
+		if link.Credentials.Expired(time.Now()) {
+			t.Errorf("Link(%q) issued expired credentials", tt.linkToken)
+		}
+	}
+}
+
+func TestMockFetchPages(t *testing.T) {
+	dir := t.TempDir()
+	transactions := make([]mockTransaction, 0, 150)
+	for i := 0; i < 150; i++ {
+		transactions = append(transactions, mockTransaction{
+			ID:     fmt.Sprintf("tx%d", i),
+			Date:   "2026-01-05",
+			Amount: -1,
+		})
+	}
+	linkToken := writeInstitution(t, dir, "demo-bank", mockFile{Accounts: []mockAccount{
+		{ID: "chk", Transactions: transactions},
+	}})
+
+	mock := NewMock(dir)
+	link, err := mock.Link(context.Background(), linkToken)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+
+	tests := []struct {
+		cursor     string
+		count      int
+		nextCursor string
+		hasMore    bool
+		wantErr    bool
+	}{
+		{cursor: "", count: 100, nextCursor: "100", hasMore: true},
+		{cursor: "100", count: 50, nextCursor: "150"},
+		{cursor: "150", count: 0, nextCursor: "150"},
+		{cursor: "999", count: 0, nextCursor: "150"},
+		{cursor: "-1", wantErr: true},
+		{cursor: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		page, err := mock.Fetch(context.Background(), link.Credentials, "chk", tt.cursor)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Fetch(cursor %q) succeeded, want an error", tt.cursor)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Fetch(cursor %q): %v", tt.cursor, err)
+			continue
+		}
+		if len(page.Transactions) != tt.count || page.Cursor != tt.nextCursor || page.HasMore != tt.hasMore {
+			t.Errorf("Fetch(cursor %q) = %d transactions, cursor %q, hasMore %v; want %d, %q, %v",
+				tt.cursor, len(page.Transactions), page.Cursor, page.HasMore, tt.count, tt.nextCursor, tt.hasMore)
+		}
+	}
+
+	if _, err := mock.Fetch(context.Background(), link.Credentials, "savings", ""); err == nil {
+		t.Error("Fetch of an unknown account succeeded")
+	}
+}
+
+func TestMockFetchRemoved(t *testing.T) {
+	dir := t.TempDir()
+	linkToken := writeInstitution(t, dir, "demo-bank", mockFile{Accounts: []mockAccount{
+		{ID: "chk", Transactions: []mockTransaction{
+			{ID: "tx1", Date: "2026-01-05", Amount: -20, Pending: true},
+			{ID: "tx2", Date: "2026-01-06", Amount: -5},
+			{ID: "tx1", Removed: true},
+		}},
+	}})
+
+	mock := NewMock(dir)
+	link, err := mock.Link(context.Background(), linkToken)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+
+	page, err := mock.Fetch(context.Background(), link.Credentials, "chk", "")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(page.Transactions) != 2 || len(page.Removed) != 1 || page.Removed[0] != "tx1" || page.Cursor != "3" {
+		t.Errorf("Fetch = %d transactions, removed %v, cursor %q; want 2, [tx1], %q", len(page.Transactions), page.Removed, page.Cursor, "3")
+	}
+
+	// A removal appended later comes with the next fetch
+	page, err = mock.Fetch(context.Background(), link.Credentials, "chk", "2")
+	if err != nil {
+		t.Fatalf("Fetch from cursor 2: %v", err)
+	}
+	if len(page.Transactions) != 0 || len(page.Removed) != 1 {
+		t.Errorf("Fetch from cursor 2 = %d transactions, removed %v; want 0, [tx1]", len(page.Transactions), page.Removed)
+	}
+}
+
+func TestMockExpiredCredentials(t *testing.T) {
+	dir := t.TempDir()
+	account := mockAccount{ID: "chk", Transactions: []mockTransaction{{ID: "tx1", Date: "2026-01-05", Amount: -1}}}
+	active := writeInstitution(t, dir, "active", mockFile{Accounts: []mockAccount{account}})
+	revoked := writeInstitution(t, dir, "revoked", mockFile{ReauthRequired: true, Accounts: []mockAccount{account}})
+
+	mock := NewMock(dir)
+	ctx := context.Background()
+
+	expired := mock.credentials(active)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := mock.Fetch(ctx, expired, "chk", ""); !errors.Is(err, ErrCredentialsExpired) {
+		t.Errorf("Fetch with expired credentials = %v, want ErrCredentialsExpired", err)
+	}
+	refreshed, err := mock.Refresh(ctx, expired)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, err := mock.Fetch(ctx, *refreshed, "chk", ""); err != nil {
+		t.Errorf("Fetch with refreshed credentials: %v", err)
+	}
+
+	credentials := mock.credentials(revoked)
+	if _, err := mock.Fetch(ctx, credentials, "chk", ""); !errors.Is(err, ErrCredentialsExpired) {
+		t.Errorf("Fetch of a revoked login = %v, want ErrCredentialsExpired", err)
+	}
+	if _, err := mock.Refresh(ctx, credentials); !errors.Is(err, ErrReauthRequired) {
+		t.Errorf("Refresh of a revoked login = %v, want ErrReauthRequired", err)
+	}
+	if _, err := mock.Refresh(ctx, Credentials{RefreshToken: "other"}); !errors.Is(err, ErrReauthRequired) {
+		t.Errorf("Refresh of a foreign token = %v, want ErrReauthRequired", err)
+	}
+}
+
+func TestCredentialsExpired(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"never expires", time.Time{}, false},
+		{"valid for an hour", now.Add(time.Hour), false},
+		{"within the margin", now.Add(30 * time.Second), true},
+		{"right at the margin", now.Add(time.Minute), true},
+		{"expired", now.Add(-time.Second), true},
+	}
+
+	for _, tt := range tests {
+		if got := (Credentials{ExpiresAt: tt.expiresAt}).Expired(now); got != tt.want {
+			t.Errorf("%s: Expired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := Registry{MockProvider: NewMock(t.TempDir()), "acme": NewMock(t.TempDir())}
+
+	if providers := registry.Providers(); len(providers) != 2 || providers[0] != "acme" || providers[1] != MockProvider {
+		t.Errorf("Providers = %v, want [acme %s]", providers, MockProvider)
+	}
+	if _, err := registry.Get(MockProvider); err != nil {
+		t.Errorf("Get(%q): %v", MockProvider, err)
+	}
+	if _, err := registry.Get("plaid"); err == nil {
+		t.Error("Get of an unregistered provider succeeded")
+	}
+}
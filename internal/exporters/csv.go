@@ -0,0 +1,67 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+var csvHeader = []string{
+	"year", "month", "type", "date", "title", "amount",
+	"category", "tags", "merchant", "paymentMethod", "externalId",
+}
+
+type csvExporter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+// NewCSV exports one row per base income, income and expense
+func NewCSV(w io.Writer) Exporter {
+	return &csvExporter{writer: csv.NewWriter(w)}
+}
+
+func (e *csvExporter) WriteBudget(budget models.BudgetResponse) error {
+	if !e.wroteHeader {
+		if err := e.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	year, month := strconv.Itoa(budget.Year), strconv.Itoa(budget.Month)
+	for _, entry := range budgetEntries(budget) {
+		err := e.writer.Write([]string{
+			year,
+			month,
+			entry.Type,
+			entry.Date,
+			entry.Title,
+			strconv.FormatFloat(entry.Amount, 'f', 2, 64),
+			entry.Category,
+			entry.Tags,
+			entry.Merchant,
+			entry.PaymentMethod,
+			entry.ExternalID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Flush every month so the output streams instead of piling up in the buffer
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Close() error {
+	if !e.wroteHeader {
+		if err := e.writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
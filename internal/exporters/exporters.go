@@ -0,0 +1,95 @@
+package exporters
+
+import (
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// Exporter writes budgets to an output format one month at a time so that
+// long ranges never have to be held in memory.
+type Exporter interface {
+	// WriteBudget appends one month to the output
+	WriteBudget(budget models.BudgetResponse) error
+	// Close writes anything still buffered and finishes the output
+	Close() error
+}
+
+// Entry types used in flat exports
+const (
+	EntryBaseIncome = "BASE_INCOME"
+	EntryIncome     = "INCOME"
+	EntryExpense    = "EXPENSE"
+)
+
+// entry is one line of a month flattened for tabular formats
+type entry struct {
+	Type          string
+	Date          string
+	Title         string
+	Amount        float64
+	Category      string
+	Tags          string
+	Merchant      string
+	PaymentMethod string
+	ExternalID    string
+}
+
+// budgetEntries flattens a month into its base income, incomes and expenses
+func budgetEntries(budget models.BudgetResponse) []entry {
+	entries := make([]entry, 0, 1+len(budget.Incomes)+len(budget.Expenses))
+
+	if budget.BaseIncome != nil {
+		entries = append(entries, entry{
+			Type:   EntryBaseIncome,
+			Title:  "Base income",
+			Amount: *budget.BaseIncome,
+		})
+	}
+	for _, income := range budget.Incomes {
+		entries = append(entries, entry{
+			Type:       EntryIncome,
+			Date:       formatDate(income.Date),
+			Title:      income.Title,
+			Amount:     income.Amount,
+			ExternalID: income.ExternalID,
+		})
+	}
+	for _, expense := range budget.Expenses {
+		entries = append(entries, entry{
+			Type:          EntryExpense,
+			Date:          formatDate(expense.Date),
+			Title:         expense.Title,
+			Amount:        expense.Amount,
+			Category:      expense.Category,
+			Tags:          strings.Join(expense.Tags, ";"),
+			Merchant:      expense.Merchant,
+			PaymentMethod: expense.PaymentMethod,
+			ExternalID:    expense.ExternalID,
+		})
+	}
+
+	return entries
+}
+
+// budgetTotals returns the income and expenses of a month
+func budgetTotals(budget models.BudgetResponse) (income, expenses float64) {
+	if budget.BaseIncome != nil {
+		income = *budget.BaseIncome
+	}
+	for _, i := range budget.Incomes {
+		income += i.Amount
+	}
+	for _, e := range budget.Expenses {
+		expenses += e.Amount
+	}
+	return income, expenses
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
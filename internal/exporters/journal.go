@@ -0,0 +1,253 @@
+package exporters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// JournalPosting is one leg of a journal transaction. The posting without an
+// amount balances the transaction, as ledger, hledger and beancount all allow.
+type JournalPosting struct {
+	Account string
+	Amount  *float64
+}
+
+// JournalTransaction is a dated, balanced transaction in a plain-text journal
+type JournalTransaction struct {
+	Date      time.Time
+	Payee     string
+	Narration string
+	Tags      []string
+	ID        string
+	Postings  []JournalPosting
+}
+
+// JournalWriter streams transactions as a ledger, hledger or beancount journal.
+// Account declarations are written last because beancount and ledger don't
+// require them before use, which keeps the output streamable.
+type JournalWriter struct {
+	w        *bufio.Writer
+	format   models.JournalFormat
+	currency string
+	opened   map[string]time.Time
+}
+
+// NewJournal starts a journal with a header comment
+func NewJournal(w io.Writer, format models.JournalFormat, currency, title string) *JournalWriter {
+	j := &JournalWriter{
+		w:        bufio.NewWriter(w),
+		format:   format,
+		currency: currency,
+		opened:   map[string]time.Time{},
+	}
+
+	if format == models.JournalFormatBeancount {
+		fmt.Fprintf(j.w, "; %s\n\noption \"title\" %s\noption \"operating_currency\" %s\n\n",
+			title, beancountString(title), beancountString(currency))
+	} else {
+		fmt.Fprintf(j.w, "; %s\n\ncommodity %s\n\n", title, currency)
+	}
+
+	return j
+}
+
+// Write appends a transaction
+func (j *JournalWriter) Write(tx JournalTransaction) error {
+	for _, posting := range tx.Postings {
+		if first, ok := j.opened[posting.Account]; !ok || tx.Date.Before(first) {
+			j.opened[posting.Account] = tx.Date
+		}
+	}
+
+	switch j.format {
+	case models.JournalFormatBeancount:
+		j.writeBeancount(tx)
+	case models.JournalFormatHledger:
+		j.writeHledger(tx)
+	default:
+		j.writeLedger(tx)
+	}
+
+	// Flush per transaction so large journals stream
+	return j.w.Flush()
+}
+
+func (j *JournalWriter) writeLedger(tx JournalTransaction) {
+	payee := tx.Payee
+	if payee == "" {
+		payee = tx.Narration
+	}
+	fmt.Fprintf(j.w, "%s * %s\n", tx.Date.Format("2006/01/02"), journalText(payee))
+	if tx.Payee != "" && tx.Narration != "" && tx.Narration != tx.Payee {
+		fmt.Fprintf(j.w, "    ; %s\n", journalText(tx.Narration))
+	}
+	if tx.ID != "" {
+		fmt.Fprintf(j.w, "    ; id: %s\n", tx.ID)
+	}
+	if tags := journalTags(tx.Tags); len(tags) > 0 {
+		fmt.Fprintf(j.w, "    ; :%s:\n", strings.Join(tags, ":"))
+	}
+	j.writePostings(tx.Postings)
+}
+
+func (j *JournalWriter) writeHledger(tx JournalTransaction) {
+	description := journalText(tx.Narration)
+	if tx.Payee != "" {
+		description = journalText(tx.Payee) + " | " + description
+	}
+
+	var comment []string
+	if tx.ID != "" {
+		comment = append(comment, "id:"+tx.ID)
+	}
+	for _, tag := range journalTags(tx.Tags) {
+		comment = append(comment, tag+":")
+	}
+	if len(comment) > 0 {
+		description += "  ; " + strings.Join(comment, ", ")
+	}
+
+	fmt.Fprintf(j.w, "%s * %s\n", tx.Date.Format("2006-01-02"), description)
+	j.writePostings(tx.Postings)
+}
+
+func (j *JournalWriter) writeBeancount(tx JournalTransaction) {
+	fmt.Fprintf(j.w, "%s * %s %s", tx.Date.Format("2006-01-02"), beancountString(tx.Payee), beancountString(tx.Narration))
+	for _, tag := range journalTags(tx.Tags) {
+		fmt.Fprintf(j.w, " #%s", tag)
+	}
+	j.w.WriteString("\n")
+	if tx.ID != "" {
+		fmt.Fprintf(j.w, "  id: %s\n", beancountString(tx.ID))
+	}
+	j.writePostings(tx.Postings)
+}
+
+func (j *JournalWriter) writePostings(postings []JournalPosting) {
+	indent := "    "
+	if j.format == models.JournalFormatBeancount {
+		indent = "  "
+	}
+
+	for _, posting := range postings {
+		if posting.Amount == nil {
+			fmt.Fprintf(j.w, "%s%s\n", indent, posting.Account)
+			continue
+		}
+		amount := strconv.FormatFloat(*posting.Amount, 'f', 2, 64)
+		fmt.Fprintf(j.w, "%s%-40s  %12s %s\n", indent, posting.Account, amount, j.currency)
+	}
+	j.w.WriteString("\n")
+}
+
+// Close declares every account used in the journal
+func (j *JournalWriter) Close() error {
+	accounts := make([]string, 0, len(j.opened))
+	for account := range j.opened {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	if len(accounts) > 0 {
+		j.w.WriteString("; Accounts\n")
+	}
+	for _, account := range accounts {
+		if j.format == models.JournalFormatBeancount {
+			fmt.Fprintf(j.w, "%s open %s %s\n", j.opened[account].Format("2006-01-02"), account, j.currency)
+		} else {
+			fmt.Fprintf(j.w, "account %s\n", account)
+		}
+	}
+
+	return j.w.Flush()
+}
+
+// AccountComponent turns a free-text name such as a category or a person
+// into an account name component valid in all three formats ("eating out" → "Eating-Out")
+func AccountComponent(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	component := strings.Join(words, "-")
+	if component == "" {
+		return "Unknown"
+	}
+	return component
+}
+
+// ValidAccount reports whether an account name is valid in all three formats:
+// a standard root followed by components starting with a capital letter or digit
+func ValidAccount(account string) bool {
+	components := strings.Split(account, ":")
+	if len(components) < 2 {
+		return false
+	}
+
+	switch components[0] {
+	case "Assets", "Liabilities", "Equity", "Income", "Expenses":
+	default:
+		return false
+	}
+
+	for _, component := range components[1:] {
+		runes := []rune(component)
+		if len(runes) == 0 || !(unicode.IsUpper(runes[0]) || unicode.IsDigit(runes[0])) {
+			return false
+		}
+		for _, r := range runes {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// journalText keeps free text on one line and away from ledger's comment and amount syntax
+func journalText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.ReplaceAll(text, ";", ",")
+	return strings.ReplaceAll(text, "|", "/")
+}
+
+// journalTags keeps the characters tags may contain in all three formats
+func journalTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+				return r
+			}
+			if unicode.IsSpace(r) {
+				return '-'
+			}
+			return -1
+		}, tag)
+		if tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
+
+func beancountString(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+}
@@ -0,0 +1,41 @@
+package exporters
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+type jsonExporter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+// NewJSON exports a JSON array of budgets in the same shape as GET /budget
+func NewJSON(w io.Writer) Exporter {
+	return &jsonExporter{w: w, encoder: json.NewEncoder(w)}
+}
+
+func (e *jsonExporter) WriteBudget(budget models.BudgetResponse) error {
+	separator := ","
+	if e.count == 0 {
+		separator = "["
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	e.count++
+
+	return e.encoder.Encode(budget)
+}
+
+func (e *jsonExporter) Close() error {
+	closing := "]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(e.w, closing)
+	return err
+}
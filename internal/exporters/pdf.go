@@ -0,0 +1,152 @@
+package exporters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdfDocument is a minimal PDF 1.4 writer for text and rules on A4 pages.
+// It only uses the standard Helvetica fonts, which every PDF reader ships,
+// so no font files need to be embedded.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+}
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+)
+
+// pdf font resource names
+const (
+	pdfFontRegular = "F1"
+	pdfFontBold    = "F2"
+)
+
+// helveticaWidths are the Helvetica glyph widths for ASCII 32-126 in 1/1000 em
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth estimates the width of text in points. Bold text is treated as
+// regular, which is close enough for aligning amounts and truncating titles.
+func textWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			width += helveticaWidths[r-32]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// truncateText shortens text with an ellipsis so it fits in maxWidth points
+func truncateText(text string, size, maxWidth float64) string {
+	if textWidth(text, size) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// newPage starts a new page and returns its index
+func (d *pdfDocument) newPage() int {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	return len(d.pages) - 1
+}
+
+// text draws text with its baseline starting at x, y (origin bottom left)
+func (d *pdfDocument) text(page int, font string, size, x, y float64, text string) {
+	fmt.Fprintf(d.pages[page], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(text))
+}
+
+// textRight draws text so that it ends at x
+func (d *pdfDocument) textRight(page int, font string, size, x, y float64, text string) {
+	d.text(page, font, size, x-textWidth(text, size), y, text)
+}
+
+// line draws a horizontal rule
+func (d *pdfDocument) line(page int, x1, x2, y, width float64) {
+	fmt.Fprintf(d.pages[page], "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y, x2, y)
+}
+
+// WriteTo writes the document
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then adds
+	// a page object and a content stream
+	pageRefs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageRefs[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pdfFontRegular, pdfFontBold, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// winAnsi maps the non Latin-1 characters of the WinAnsi encoding
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfEscape encodes text as a WinAnsi PDF string, replacing characters
+// the standard fonts can't show
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case winAnsi[r] != 0:
+			b.WriteByte(winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
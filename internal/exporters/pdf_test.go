@@ -0,0 +1,144 @@
+package exporters
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+		signed string
+	}{
+		{0, "0.00", "0.00"},
+		{5, "5.00", "+5.00"},
+		{12.345, "12.35", "+12.35"},
+		{999.999, "1,000.00", "+1,000.00"},
+		{1234567.8, "1,234,567.80", "+1,234,567.80"},
+		{-42.5, "-42.50", "-42.50"},
+		{-1000, "-1,000.00", "-1,000.00"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAmount(tt.amount); got != tt.want {
+			t.Errorf("formatAmount(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+		if got := signedAmount(tt.amount); got != tt.signed {
+			t.Errorf("signedAmount(%v) = %q, want %q", tt.amount, got, tt.signed)
+		}
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Coffee", "Coffee"},
+		{`Rent (May) \ June`, `Rent \(May\) \\ June`},
+		{"Café", "Caf\xe9"},
+		{"€5 – “quoted”", "\x805 \x96 \x93quoted\x94"},
+		{"日本", "??"},
+	}
+
+	for _, tt := range tests {
+		if got := pdfEscape(tt.text); got != tt.want {
+			t.Errorf("pdfEscape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text     string
+		maxWidth float64
+		want     string
+	}{
+		{"Coffee", 100, "Coffee"},
+		{"A very long expense title that won't fit", 60, "A very long..."},
+		{"Anything", 1, "..."},
+	}
+
+	for _, tt := range tests {
+		got := truncateText(tt.text, 10, tt.maxWidth)
+		if got != tt.want {
+			t.Errorf("truncateText(%q, %v) = %q, want %q", tt.text, tt.maxWidth, got, tt.want)
+		}
+		if got != "..." && textWidth(got, 10) > tt.maxWidth {
+			t.Errorf("truncateText(%q, %v) = %q is %v wide", tt.text, tt.maxWidth, got, textWidth(got, 10))
+		}
+	}
+}
+
+func TestWriteStatementPDF(t *testing.T) {
+	base := 3000.0
+	var expenses []models.Expense
+	for i := 0; i < 80; i++ {
+		expenses = append(expenses, models.Expense{Title: fmt.Sprintf("Expense %d", i+1), Amount: 10})
+	}
+
+	var out bytes.Buffer
+	err := WriteStatementPDF(&out, models.MonthlyStatement{
+		Budget:      models.BudgetResponse{Year: 2026, Month: 1, BaseIncome: &base, Expenses: expenses},
+		Previous:    models.BudgetResponse{Year: 2025, Month: 12},
+		Categories:  []models.StatementCategory{{Category: "Food", Total: 800, PreviousTotal: 50, Expenses: expenses}},
+		OpenFunds:   []models.StatementFund{{PersonName: "Ali", Type: models.FundTypeGiven, PrincipalAmount: 500, Outstanding: 300}},
+		GeneratedAt: time.Date(2026, 2, 1, 9, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("WriteStatementPDF: %v", err)
+	}
+	pdf := out.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("output isn't framed as a PDF file")
+	}
+
+	// 80 expenses don't fit on one page
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(pdf)
+	if pages == nil {
+		t.Fatal("no page tree")
+	}
+	count, _ := strconv.Atoi(string(pages[1]))
+	if count < 2 {
+		t.Errorf("statement has %d pages, want the expenses to flow onto more", count)
+	}
+	for _, text := range []string{"(Monthly statement)", "(January 2026)", "(Expense 80)", "(Total expenses)", "(Ali)",
+		fmt.Sprintf("(Page %d of %d)", count, count), "(Generated 2026-02-01 09:30)"} {
+		if !bytes.Contains(pdf, []byte(text)) {
+			t.Errorf("statement doesn't show %s", text)
+		}
+	}
+
+	// Every cross-reference entry must point at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if startxref == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the xref table", xref)
+	}
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	objects := 4 + count*2
+	if lines[1] != fmt.Sprintf("0 %d", objects+1) {
+		t.Errorf("xref header = %q, want %d entries", lines[1], objects+1)
+	}
+	for i := 1; i <= objects; i++ {
+		offset, err := strconv.Atoi(strings.Fields(lines[2+i])[0])
+		if err != nil {
+			t.Fatalf("xref entry %d = %q", i, lines[2+i])
+		}
+		if want := fmt.Sprintf("%d 0 obj\n", i); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want object %d", i, pdf[offset:offset+10], i)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package exporters
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+const (
+	statementMargin     = 50.0
+	statementLineHeight = 15.0
+	statementFontSize   = 10.0
+)
+
+// statementLayout flows lines down the pages of a statement
+type statementLayout struct {
+	doc  *pdfDocument
+	page int
+	y    float64
+}
+
+func newStatementLayout() *statementLayout {
+	l := &statementLayout{doc: &pdfDocument{}}
+	l.addPage()
+	return l
+}
+
+func (l *statementLayout) addPage() {
+	l.page = l.doc.newPage()
+	l.y = pdfPageHeight - statementMargin
+}
+
+// reserve moves to a new page unless height points are left on this one
+func (l *statementLayout) reserve(height float64) {
+	if l.y-height < statementMargin+statementLineHeight {
+		l.addPage()
+	}
+}
+
+// row draws text columns on one line. Columns with a right edge are right aligned.
+func (l *statementLayout) row(font string, columns ...statementColumn) {
+	l.reserve(statementLineHeight)
+	for _, column := range columns {
+		text := column.text
+		if column.width > 0 {
+			text = truncateText(text, statementFontSize, column.width)
+		}
+		if column.right {
+			l.doc.textRight(l.page, font, statementFontSize, column.x, l.y, text)
+		} else {
+			l.doc.text(l.page, font, statementFontSize, column.x, l.y, text)
+		}
+	}
+	l.y -= statementLineHeight
+}
+
+// heading starts a section, keeping it together with at least its first lines
+func (l *statementLayout) heading(text string) {
+	l.reserve(statementLineHeight * 4)
+	l.y -= statementLineHeight / 2
+	l.doc.text(l.page, pdfFontBold, 13, statementMargin, l.y, text)
+	l.y -= 6
+	l.doc.line(l.page, statementMargin, pdfPageWidth-statementMargin, l.y, 0.8)
+	l.y -= statementLineHeight
+}
+
+func (l *statementLayout) rule() {
+	l.doc.line(l.page, statementMargin, pdfPageWidth-statementMargin, l.y+statementLineHeight-4, 0.3)
+}
+
+type statementColumn struct {
+	x     float64
+	text  string
+	right bool
+	width float64
+}
+
+func left(x float64, text string) statementColumn {
+	return statementColumn{x: x, text: text}
+}
+
+func clipped(x, width float64, text string) statementColumn {
+	return statementColumn{x: x, text: text, width: width}
+}
+
+func right(x float64, text string) statementColumn {
+	return statementColumn{x: x, text: text, right: true}
+}
+
+// Column positions shared by the statement sections
+const (
+	colLabel   = statementMargin
+	colDate    = statementMargin + 15
+	colTitle   = statementMargin + 85
+	colAmount1 = 345.0
+	colAmount2 = 445.0
+	colAmount3 = pdfPageWidth - statementMargin
+)
+
+// WriteStatementPDF renders a monthly statement as a PDF
+func WriteStatementPDF(w io.Writer, statement models.MonthlyStatement) error {
+	l := newStatementLayout()
+	budget, previous := statement.Budget, statement.Previous
+
+	// Title
+	l.doc.text(l.page, pdfFontBold, 18, statementMargin, l.y, "Monthly statement")
+	l.y -= 22
+	l.doc.text(l.page, pdfFontRegular, 12, statementMargin, l.y, monthName(budget.Year, budget.Month))
+	l.y -= statementLineHeight * 1.5
+
+	// Summary with comparison to the previous month
+	income, expenses := budgetTotals(budget)
+	prevIncome, prevExpenses := budgetTotals(previous)
+
+	l.heading("Summary")
+	l.row(pdfFontBold,
+		right(colAmount1, monthName(budget.Year, budget.Month)),
+		right(colAmount2, monthName(previous.Year, previous.Month)),
+		right(colAmount3, "Change"),
+	)
+	summaryRow := func(label string, current, prev *float64) {
+		columns := []statementColumn{left(colLabel, label), right(colAmount1, optionalAmount(current)), right(colAmount2, optionalAmount(prev))}
+		if current != nil && prev != nil {
+			columns = append(columns, right(colAmount3, signedAmount(*current-*prev)))
+		}
+		l.row(pdfFontRegular, columns...)
+	}
+	summaryRow("Income", incomeOrNil(budget, income), incomeOrNil(previous, prevIncome))
+	summaryRow("Expenses", &expenses, &prevExpenses)
+	l.rule()
+	summaryRow("Remaining", budget.Remaining, previous.Remaining)
+	l.y -= statementLineHeight / 2
+
+	// Income
+	l.heading("Income")
+	if budget.BaseIncome == nil && len(budget.Incomes) == 0 {
+		l.row(pdfFontRegular, left(colLabel, "No income recorded for this month."))
+	}
+	if budget.BaseIncome != nil {
+		l.row(pdfFontRegular, left(colLabel, "Base income"), right(colAmount3, formatAmount(*budget.BaseIncome)))
+	}
+	for _, i := range budget.Incomes {
+		l.row(pdfFontRegular,
+			left(colDate, formatDate(i.Date)),
+			clipped(colTitle, colAmount2-colTitle, i.Title),
+			right(colAmount3, formatAmount(i.Amount)),
+		)
+	}
+	if budget.BaseIncome != nil || len(budget.Incomes) > 0 {
+		l.rule()
+		l.row(pdfFontBold, left(colLabel, "Total income"), right(colAmount3, formatAmount(income)))
+	}
+	l.y -= statementLineHeight / 2
+
+	// Expenses grouped by category
+	l.heading("Expenses by category")
+	if len(budget.Expenses) == 0 {
+		l.row(pdfFontRegular, left(colLabel, "No expenses recorded for this month."))
+	}
+	for _, category := range statement.Categories {
+		if category.Total == 0 && len(category.Expenses) == 0 {
+			continue
+		}
+		l.reserve(statementLineHeight * 2)
+		l.row(pdfFontBold,
+			left(colLabel, category.Category),
+			right(colAmount2, "prev. "+formatAmount(category.PreviousTotal)),
+			right(colAmount3, formatAmount(category.Total)),
+		)
+		for _, expense := range category.Expenses {
+			l.row(pdfFontRegular,
+				left(colDate, formatDate(expense.Date)),
+				clipped(colTitle, colAmount2-colTitle, expense.Title),
+				right(colAmount3, formatAmount(expense.Amount)),
+			)
+		}
+		l.y -= statementLineHeight / 3
+	}
+	// Categories only spent on last month still count towards the comparison
+	for _, category := range statement.Categories {
+		if category.Total == 0 && category.PreviousTotal > 0 {
+			l.row(pdfFontRegular,
+				left(colLabel, category.Category+" (none this month)"),
+				right(colAmount2, "prev. "+formatAmount(category.PreviousTotal)),
+				right(colAmount3, formatAmount(0)),
+			)
+		}
+	}
+	if len(budget.Expenses) > 0 {
+		l.rule()
+		l.row(pdfFontBold, left(colLabel, "Total expenses"), right(colAmount3, formatAmount(expenses)))
+	}
+	l.y -= statementLineHeight / 2
+
+	// Open funds
+	l.heading("Open funds")
+	if len(statement.OpenFunds) == 0 {
+		l.row(pdfFontRegular, left(colLabel, "No open funds."))
+	} else {
+		l.row(pdfFontBold,
+			left(colLabel, "Person"),
+			left(colTitle+60, "Type"),
+			right(colAmount1+40, "Principal"),
+			right(colAmount2+30, "Paid"),
+			right(colAmount3, "Outstanding"),
+		)
+		totals := map[models.FundType]float64{}
+		for _, fund := range statement.OpenFunds {
+			l.row(pdfFontRegular,
+				clipped(colLabel, colTitle+50-colLabel, fund.PersonName),
+				left(colTitle+60, string(fund.Type)),
+				right(colAmount1+40, formatAmount(fund.PrincipalAmount)),
+				right(colAmount2+30, formatAmount(fund.TotalPaid)),
+				right(colAmount3, formatAmount(fund.Outstanding)),
+			)
+			totals[fund.Type] += fund.Outstanding
+		}
+		l.rule()
+		l.row(pdfFontBold, left(colLabel, "Owed to you (given)"), right(colAmount3, formatAmount(totals[models.FundTypeGiven])))
+		l.row(pdfFontBold, left(colLabel, "You owe (borrowed)"), right(colAmount3, formatAmount(totals[models.FundTypeBorrowed])))
+	}
+
+	// Footer on every page
+	generated := "Generated " + statement.GeneratedAt.Format("2006-01-02 15:04")
+	for page := range l.doc.pages {
+		l.doc.text(page, pdfFontRegular, 8, statementMargin, statementMargin/2, generated)
+		l.doc.textRight(page, pdfFontRegular, 8, pdfPageWidth-statementMargin, statementMargin/2,
+			fmt.Sprintf("Page %d of %d", page+1, len(l.doc.pages)))
+	}
+
+	_, err := l.doc.WriteTo(w)
+	return err
+}
+
+// incomeOrNil returns the income of a month, or nil if it has none recorded
+func incomeOrNil(budget models.BudgetResponse, income float64) *float64 {
+	if budget.BaseIncome == nil && len(budget.Incomes) == 0 {
+		return nil
+	}
+	return &income
+}
+
+func monthName(year, month int) string {
+	return time.Month(month).String() + " " + fmt.Sprint(year)
+}
+
+func optionalAmount(amount *float64) string {
+	if amount == nil {
+		return "-"
+	}
+	return formatAmount(*amount)
+}
+
+func signedAmount(amount float64) string {
+	if amount > 0 {
+		return "+" + formatAmount(amount)
+	}
+	return formatAmount(amount)
+}
+
+// formatAmount formats an amount with two decimals and thousands separators
+func formatAmount(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := fmt.Sprint(cents / 100)
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+
+	return fmt.Sprintf("%s%s.%02d", sign, b.String(), cents%100)
+}
@@ -0,0 +1,266 @@
+package exporters
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// The workbook is written as a bare-bones Office Open XML package. Each month
+// sheet goes into the zip as soon as it is built; the summary sheet and the
+// workbook parts that list the sheets are written on Close.
+
+const xlsxContentTypesHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the default cell style, a bold style for headers and a two-decimal number style
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
+
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleBold    = 1
+	xlsxStyleAmount  = 2
+)
+
+// xlsxCell is a string or number cell
+type xlsxCell struct {
+	text   string
+	number *float64
+	style  int
+}
+
+func textCell(text string) xlsxCell {
+	return xlsxCell{text: text}
+}
+
+func headerCell(text string) xlsxCell {
+	return xlsxCell{text: text, style: xlsxStyleBold}
+}
+
+func amountCell(amount float64) xlsxCell {
+	return xlsxCell{number: &amount, style: xlsxStyleAmount}
+}
+
+// xlsxSheet is a sheet that has been written to the package
+type xlsxSheet struct {
+	name string
+	file int
+}
+
+// summaryRow is one month of the summary sheet
+type summaryRow struct {
+	month     string
+	income    float64
+	hasIncome bool
+	expenses  float64
+}
+
+type xlsxExporter struct {
+	zip     *zip.Writer
+	sheets  []xlsxSheet
+	summary []summaryRow
+}
+
+// NewXLSX exports one sheet per month plus a summary sheet
+func NewXLSX(w io.Writer) Exporter {
+	return &xlsxExporter{zip: zip.NewWriter(w)}
+}
+
+var monthSheetHeader = []xlsxCell{
+	headerCell("Type"), headerCell("Date"), headerCell("Title"), headerCell("Category"),
+	headerCell("Tags"), headerCell("Merchant"), headerCell("Payment method"), headerCell("Amount"),
+}
+
+func (e *xlsxExporter) WriteBudget(budget models.BudgetResponse) error {
+	rows := [][]xlsxCell{monthSheetHeader}
+	for _, entry := range budgetEntries(budget) {
+		rows = append(rows, []xlsxCell{
+			textCell(entry.Type),
+			textCell(entry.Date),
+			textCell(entry.Title),
+			textCell(entry.Category),
+			textCell(entry.Tags),
+			textCell(entry.Merchant),
+			textCell(entry.PaymentMethod),
+			amountCell(entry.Amount),
+		})
+	}
+
+	income, expenses := budgetTotals(budget)
+	rows = append(rows, nil, []xlsxCell{headerCell("Total expenses"), {}, {}, {}, {}, {}, {}, amountCell(expenses)})
+	if budget.Remaining != nil {
+		rows = append(rows, []xlsxCell{headerCell("Remaining"), {}, {}, {}, {}, {}, {}, amountCell(*budget.Remaining)})
+	}
+
+	name := fmt.Sprintf("%04d-%02d", budget.Year, budget.Month)
+	if err := e.writeSheet(name, rows); err != nil {
+		return err
+	}
+
+	e.summary = append(e.summary, summaryRow{
+		month:     name,
+		income:    income,
+		hasIncome: budget.Remaining != nil,
+		expenses:  expenses,
+	})
+	return nil
+}
+
+func (e *xlsxExporter) Close() error {
+	rows := [][]xlsxCell{{headerCell("Month"), headerCell("Income"), headerCell("Expenses"), headerCell("Remaining")}}
+	var totalIncome, totalExpenses float64
+	for _, month := range e.summary {
+		row := []xlsxCell{textCell(month.month), {}, amountCell(month.expenses), {}}
+		if month.hasIncome {
+			row[1] = amountCell(month.income)
+			row[3] = amountCell(month.income - month.expenses)
+		}
+		rows = append(rows, row)
+		totalIncome += month.income
+		totalExpenses += month.expenses
+	}
+	rows = append(rows, []xlsxCell{
+		headerCell("Total"), amountCell(totalIncome), amountCell(totalExpenses), amountCell(totalIncome - totalExpenses),
+	})
+
+	if err := e.writeSheet("Summary", rows); err != nil {
+		return err
+	}
+
+	// List the summary sheet first
+	summary := e.sheets[len(e.sheets)-1]
+	sheets := append([]xlsxSheet{summary}, e.sheets[:len(e.sheets)-1]...)
+
+	if err := e.writePackageParts(sheets); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// writeSheet writes a worksheet part to the package
+func (e *xlsxExporter) writeSheet(name string, rows [][]xlsxCell) error {
+	file := len(e.sheets) + 1
+	part, err := e.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", file))
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(part)
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			if cell.number == nil && cell.text == "" {
+				continue
+			}
+			ref := columnName(c) + strconv.Itoa(r+1)
+			if cell.number != nil {
+				fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.style, strconv.FormatFloat(*cell.number, 'f', -1, 64))
+				continue
+			}
+			fmt.Fprintf(w, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, cell.style)
+			xml.EscapeText(w, []byte(cell.text))
+			w.WriteString(`</t></is></c>`)
+		}
+		w.WriteString(`</row>`)
+	}
+	w.WriteString(`</sheetData></worksheet>`)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	e.sheets = append(e.sheets, xlsxSheet{name: name, file: file})
+	return nil
+}
+
+// writePackageParts writes the parts that reference the sheets: content types,
+// relationships, the workbook and its styles
+func (e *xlsxExporter) writePackageParts(sheets []xlsxSheet) error {
+	var contentTypes, workbook, workbookRels strings.Builder
+
+	contentTypes.WriteString(xlsxContentTypesHeader)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes,
+			`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`,
+			sheet.file)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, sheet.name, i+1, sheet.file)
+		fmt.Fprintf(&workbookRels,
+			`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`,
+			sheet.file, sheet.file)
+	}
+	fmt.Fprintf(&workbookRels,
+		`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`,
+		len(sheets)+1)
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		part, err := e.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, p.content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columnName converts a zero-based column index into a spreadsheet column name (0 → A, 26 → AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,178 @@
+package exporters
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// xlsxTestSheet decodes the cells of a worksheet part
+type xlsxTestSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// cells maps cell references to their value
+func (s xlsxTestSheet) cells() map[string]string {
+	cells := map[string]string{}
+	for _, row := range s.Rows {
+		for _, cell := range row.Cells {
+			cells[cell.Ref] = cell.Value + cell.Inline
+		}
+	}
+	return cells
+}
+
+func readZipPart(t *testing.T, archive *zip.Reader, name string) []byte {
+	t.Helper()
+	file, err := archive.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return data
+}
+
+func TestXLSXExport(t *testing.T) {
+	base, remaining := 3000.0, 2950.0
+	date := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	budgets := []models.BudgetResponse{
+		{
+			Year:       2026,
+			Month:      1,
+			BaseIncome: &base,
+			Incomes:    []models.Income{{Title: "Refund", Amount: 20, Date: &date}},
+			Expenses: []models.Expense{
+				{Title: "Tom & Jerry <Diner>", Amount: 70, Category: "Food", Tags: []string{"eating out", "friends"}, Date: &date},
+			},
+			Remaining: &remaining,
+		},
+		{
+			Year:     2026,
+			Month:    2,
+			Expenses: []models.Expense{{Title: "Bus", Amount: 2.5}},
+		},
+	}
+
+	var out bytes.Buffer
+	exporter := NewXLSX(&out)
+	for _, budget := range budgets {
+		if err := exporter.WriteBudget(budget); err != nil {
+			t.Fatalf("WriteBudget: %v", err)
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("the export isn't a zip file: %v", err)
+	}
+
+	// Every part must be well-formed XML
+	for _, file := range archive.File {
+		decoder := xml.NewDecoder(bytes.NewReader(readZipPart(t, archive, file.Name)))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s isn't valid XML: %v", file.Name, err)
+			}
+		}
+	}
+
+	// The summary is the first sheet although it's written last
+	workbook := string(readZipPart(t, archive, "xl/workbook.xml"))
+	summaryAt := strings.Index(workbook, `<sheet name="Summary" sheetId="1" r:id="rId3"/>`)
+	januaryAt := strings.Index(workbook, `<sheet name="2026-01" sheetId="2" r:id="rId1"/>`)
+	if summaryAt < 0 || januaryAt < summaryAt {
+		t.Errorf("workbook doesn't list the summary first, then the months: %s", workbook)
+	}
+
+	tests := []struct {
+		part  string
+		cells map[string]string
+	}{
+		{
+			part: "xl/worksheets/sheet1.xml",
+			cells: map[string]string{
+				"A1": "Type", "H1": "Amount",
+				"A2": EntryBaseIncome, "H2": "3000",
+				"A3": EntryIncome, "B3": "2026-01-05", "C3": "Refund", "H3": "20",
+				"A4": EntryExpense, "C4": "Tom & Jerry <Diner>", "D4": "Food", "E4": "eating out;friends", "H4": "70",
+				"A6": "Total expenses", "H6": "70",
+				"A7": "Remaining", "H7": "2950",
+			},
+		},
+		{
+			part: "xl/worksheets/sheet2.xml",
+			cells: map[string]string{
+				"A2": EntryExpense, "C2": "Bus", "H2": "2.5",
+				"A4": "Total expenses", "H4": "2.5",
+				"A5": "",
+			},
+		},
+		{
+			part: "xl/worksheets/sheet3.xml",
+			cells: map[string]string{
+				"A2": "2026-01", "B2": "3020", "C2": "70", "D2": "2950",
+				"A3": "2026-02", "B3": "", "C3": "2.5", "D3": "",
+				"A4": "Total", "B4": "3020", "C4": "72.5", "D4": "2947.5",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var sheet xlsxTestSheet
+		if err := xml.Unmarshal(readZipPart(t, archive, tt.part), &sheet); err != nil {
+			t.Fatalf("decode %s: %v", tt.part, err)
+		}
+		cells := sheet.cells()
+		for ref, want := range tt.cells {
+			if cells[ref] != want {
+				t.Errorf("%s %s = %q, want %q", tt.part, ref, cells[ref], want)
+			}
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{7, "H"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// GetAccounts lists the user's accounts with their current balances
+// GET /accounts
+func (ah *AccountHandler) GetAccounts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	accounts, err := ah.accountService.GetAccounts(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(accounts)
+}
+
+// GetAccountByID retrieves an account with its current balance
+// GET /accounts/:accountId
+func (ah *AccountHandler) GetAccountByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	account, err := ah.accountService.GetAccountByID(c.Context(), userID, accountID)
+	if err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(account)
+}
+
+// CreateAccount creates a new account
+// POST /accounts
+func (ah *AccountHandler) CreateAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.AccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	account, err := ah.accountService.CreateAccount(c.Context(), userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(account)
+}
+
+// UpdateAccount updates an existing account
+// PUT /accounts/:accountId
+func (ah *AccountHandler) UpdateAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	var req models.AccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	account, err := ah.accountService.UpdateAccount(c.Context(), userID, accountID, req)
+	if err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(account)
+}
+
+// DeleteAccount deletes an account that has no linked entries or transfers
+// DELETE /accounts/:accountId
+func (ah *AccountHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	if err := ah.accountService.DeleteAccount(c.Context(), userID, accountID); err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "account deleted successfully",
+	})
+}
+
+// GetAccountLedger lists an account's movements with running balances
+// GET /accounts/:accountId/ledger?from=2026-01-01&to=2026-01-31
+func (ah *AccountHandler) GetAccountLedger(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	var from, to *time.Time
+	if raw := c.Query("from"); raw != "" {
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid from parameter, expected YYYY-MM-DD",
+			})
+		}
+		from = &date
+	}
+	if raw := c.Query("to"); raw != "" {
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid to parameter, expected YYYY-MM-DD",
+			})
+		}
+		// Include the whole last day
+		endOfDay := date.Add(24*time.Hour - time.Nanosecond)
+		to = &endOfDay
+	}
+
+	ledger, err := ah.accountService.GetAccountLedger(c.Context(), userID, accountID, from, to)
+	if err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ledger)
+}
+
+// GetTransfers lists transfers, optionally for one account
+// GET /transfers?accountId=...
+func (ah *AccountHandler) GetTransfers(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	transfers, err := ah.accountService.GetTransfers(c.Context(), userID, c.Query("accountId"))
+	if err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(transfers)
+}
+
+// CreateTransfer moves money between two accounts
+// POST /transfers
+func (ah *AccountHandler) CreateTransfer(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.TransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	transfer, err := ah.accountService.CreateTransfer(c.Context(), userID, req)
+	if err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// DeleteTransfer deletes a transfer
+// DELETE /transfers/:transferId
+func (ah *AccountHandler) DeleteTransfer(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	transferID := c.Params("transferId")
+
+	if err := ah.accountService.DeleteTransfer(c.Context(), userID, transferID); err != nil {
+		return accountError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "transfer deleted successfully",
+	})
+}
+
+// accountError maps account service errors onto HTTP responses
+func accountError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "account not found or doesn't belong to user", "transfer not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "account has linked expenses, incomes or transfers", "transfer is reconciled and locked",
+		"opening balance can't be changed once the account has a completed reconciliation":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
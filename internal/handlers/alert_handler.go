@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type AlertHandler struct {
+	alertService *services.AlertService
+}
+
+func NewAlertHandler(alertService *services.AlertService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+	}
+}
+
+// GetAlerts lists the user's budget alerts
+// GET /alerts
+func (ah *AlertHandler) GetAlerts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	alerts, err := ah.alertService.GetAlerts(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(alerts)
+}
+
+// GetTriggers lists the alerts that fired in a month, the current one by default
+// GET /alerts/triggers?year=2026&month=1
+func (ah *AlertHandler) GetTriggers(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	now := time.Now()
+	year := c.QueryInt("year", now.Year())
+	month := c.QueryInt("month", int(now.Month()))
+	if month < 1 || month > 12 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "month must be between 1 and 12",
+		})
+	}
+
+	triggers, err := ah.alertService.GetTriggers(c.Context(), userID, year, month)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(triggers)
+}
+
+// GetAlertByID retrieves a budget alert
+// GET /alerts/:alertId
+func (ah *AlertHandler) GetAlertByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	alertID := c.Params("alertId")
+
+	alert, err := ah.alertService.GetAlertByID(c.Context(), userID, alertID)
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(alert)
+}
+
+// CreateAlert creates a new budget alert
+// POST /alerts
+func (ah *AlertHandler) CreateAlert(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.BudgetAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	alert, err := ah.alertService.CreateAlert(c.Context(), userID, req)
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(alert)
+}
+
+// UpdateAlert updates a budget alert
+// PUT /alerts/:alertId
+func (ah *AlertHandler) UpdateAlert(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	alertID := c.Params("alertId")
+
+	var req models.BudgetAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	alert, err := ah.alertService.UpdateAlert(c.Context(), userID, alertID, req)
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(alert)
+}
+
+// DeleteAlert deletes a budget alert
+// DELETE /alerts/:alertId
+func (ah *AlertHandler) DeleteAlert(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	alertID := c.Params("alertId")
+
+	if err := ah.alertService.DeleteAlert(c.Context(), userID, alertID); err != nil {
+		return alertError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "alert deleted successfully",
+	})
+}
+
+// alertError maps budget alert errors onto HTTP responses
+func alertError(c *fiber.Ctx, err error) error {
+	if err.Error() == "alert not found or doesn't belong to user" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
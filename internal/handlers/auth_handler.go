@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"log"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/huxxnainali/finance-app/internal/auth"
 	"github.com/huxxnainali/finance-app/internal/config"
@@ -10,12 +13,14 @@ import (
 
 type AuthHandler struct {
 	userService *services.UserService
+	notifier    services.Notifier
 	config      *config.Config
 }
 
-func NewAuthHandler(userService *services.UserService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(userService *services.UserService, notifier services.Notifier, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
 		userService: userService,
+		notifier:    notifier,
 		config:      cfg,
 	}
 }
@@ -89,6 +94,19 @@ func (ah *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
+	// Tell the user about the login; a failure must not block it
+	err = ah.notifier.Notify(c.Context(), user.ID.Hex(), models.NotificationEvent{
+		Type: models.NotificationSecurityNewLogin,
+		Data: map[string]string{
+			"time":      time.Now().UTC().Format("2006-01-02 15:04 MST"),
+			"ip":        c.IP(),
+			"userAgent": c.Get(fiber.HeaderUserAgent, "unknown device"),
+		},
+	})
+	if err != nil {
+		log.Printf("Login notification for user %s failed: %v", user.ID.Hex(), err)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(models.AuthResponse{
 		Token: token,
 	})
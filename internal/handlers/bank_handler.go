@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/connectors"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type BankHandler struct {
+	bankSyncService *services.BankSyncService
+}
+
+func NewBankHandler(bankSyncService *services.BankSyncService) *BankHandler {
+	return &BankHandler{
+		bankSyncService: bankSyncService,
+	}
+}
+
+// GetConnections lists the user's bank connections
+// GET /bank-connections
+func (bh *BankHandler) GetConnections(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	connections, err := bh.bankSyncService.GetConnections(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(connections)
+}
+
+// GetConnectionByID retrieves a bank connection with its pending transactions
+// GET /bank-connections/:connectionId
+func (bh *BankHandler) GetConnectionByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	connectionID := c.Params("connectionId")
+
+	connection, err := bh.bankSyncService.GetConnectionByID(c.Context(), userID, connectionID)
+	if err != nil {
+		return bankError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(connection)
+}
+
+// LinkConnections links the accounts behind a provider's link token
+// POST /bank-connections
+func (bh *BankHandler) LinkConnections(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.BankLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	connections, err := bh.bankSyncService.LinkConnections(c.Context(), userID, req)
+	if err != nil {
+		return bankError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(connections)
+}
+
+// UpdateConnection links a bank connection to an account
+// PUT /bank-connections/:connectionId
+func (bh *BankHandler) UpdateConnection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	connectionID := c.Params("connectionId")
+
+	var req models.BankConnectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	connection, err := bh.bankSyncService.UpdateConnection(c.Context(), userID, connectionID, req)
+	if err != nil {
+		return bankError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(connection)
+}
+
+// DeleteConnection removes a bank connection
+// DELETE /bank-connections/:connectionId
+func (bh *BankHandler) DeleteConnection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	connectionID := c.Params("connectionId")
+
+	if err := bh.bankSyncService.DeleteConnection(c.Context(), userID, connectionID); err != nil {
+		return bankError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "bank connection deleted successfully",
+	})
+}
+
+// SyncConnection fetches a connection's new transactions right away
+// POST /bank-connections/:connectionId/sync
+func (bh *BankHandler) SyncConnection(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	connectionID := c.Params("connectionId")
+
+	result, err := bh.bankSyncService.SyncConnection(c.Context(), userID, connectionID)
+	if err != nil {
+		return bankError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// bankError maps bank sync errors onto HTTP responses
+func bankError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found or doesn't belong to user"):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err.Error() == "bank connection is already syncing",
+		err.Error() == "bank connection requires the account to be linked again",
+		errors.Is(err, connectors.ErrReauthRequired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
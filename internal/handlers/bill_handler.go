@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type BillHandler struct {
+	billService *services.BillService
+}
+
+func NewBillHandler(billService *services.BillService) *BillHandler {
+	return &BillHandler{
+		billService: billService,
+	}
+}
+
+// GetBills lists the user's bills
+// GET /bills
+func (bh *BillHandler) GetBills(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	bills, err := bh.billService.GetBills(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bills)
+}
+
+// GetUpcomingBills lists the bills due within the next days, overdue ones included
+// GET /bills/upcoming?days=30
+func (bh *BillHandler) GetUpcomingBills(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	upcoming, err := bh.billService.GetUpcomingBills(c.Context(), userID, c.QueryInt("days", services.DefaultUpcomingBillDays))
+	if err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(upcoming)
+}
+
+// GetBillByID retrieves a bill
+// GET /bills/:billId
+func (bh *BillHandler) GetBillByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	billID := c.Params("billId")
+
+	bill, err := bh.billService.GetBillByID(c.Context(), userID, billID)
+	if err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bill)
+}
+
+// CreateBill creates a new bill
+// POST /bills
+func (bh *BillHandler) CreateBill(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.BillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	bill, err := bh.billService.CreateBill(c.Context(), userID, req)
+	if err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(bill)
+}
+
+// UpdateBill updates a bill
+// PUT /bills/:billId
+func (bh *BillHandler) UpdateBill(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	billID := c.Params("billId")
+
+	var req models.BillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	bill, err := bh.billService.UpdateBill(c.Context(), userID, billID, req)
+	if err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(bill)
+}
+
+// DeleteBill deletes a bill
+// DELETE /bills/:billId
+func (bh *BillHandler) DeleteBill(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	billID := c.Params("billId")
+
+	if err := bh.billService.DeleteBill(c.Context(), userID, billID); err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "bill deleted successfully",
+	})
+}
+
+// PayBill marks a bill paid and records the payment as an expense
+// POST /bills/:billId/pay
+func (bh *BillHandler) PayBill(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	billID := c.Params("billId")
+
+	var req models.BillPaymentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request format",
+			})
+		}
+	}
+
+	payment, err := bh.billService.PayBill(c.Context(), userID, billID, req)
+	if err != nil {
+		return billError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(payment)
+}
+
+// billError maps bill service errors onto HTTP responses
+func billError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "bill not found or doesn't belong to user", "account not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "bill is already paid":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
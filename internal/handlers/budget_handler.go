@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,31 +20,23 @@ func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
 	}
 }
 
-// GetCurrentBudget retrieves the current month's budget
+// GetCurrentBudget retrieves the current month's budget without creating it
 // GET /budget/current
 func (bh *BudgetHandler) GetCurrentBudget(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
 	year, month := utils.GetCurrentMonthYear()
 
-	budget, err := bh.budgetService.GetOrCreateBudget(c.Context(), userID, year, month)
+	budget, err := bh.budgetService.GetBudget(c.Context(), userID, year, month)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
-
-	return c.Status(fiber.StatusOK).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
-	})
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
 }
 
-// GetBudgetByMonth retrieves a specific month's budget
+// GetBudgetByMonth retrieves a specific month's budget without creating it
 // GET /budget?year=YYYY&month=MM
 func (bh *BudgetHandler) GetBudgetByMonth(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
@@ -77,22 +70,69 @@ func (bh *BudgetHandler) GetBudgetByMonth(c *fiber.Ctx) error {
 		})
 	}
 
-	budget, err := bh.budgetService.GetOrCreateBudget(c.Context(), userID, year, month)
+	budget, err := bh.budgetService.GetBudget(c.Context(), userID, year, month)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
+}
+
+// GetBudgetSummary summarizes every month in a range without creating budgets
+// GET /budget/summary?fromYear=YYYY&fromMonth=MM&toYear=YYYY&toMonth=MM
+// GET /budget/summary?year=YYYY
+func (bh *BudgetHandler) GetBudgetSummary(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
 
-	return c.Status(fiber.StatusOK).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
-	})
+	fromYear, fromMonth, toYear, toMonth, err := parseMonthRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	summary, err := bh.budgetService.GetBudgetSummary(c.Context(), userID, fromYear, fromMonth, toYear, toMonth)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(summary)
+}
+
+// parseMonthRange reads an inclusive month range from the query string.
+// Either year (a whole calendar year) or fromYear/fromMonth/toYear/toMonth must be given.
+func parseMonthRange(c *fiber.Ctx) (fromYear, fromMonth, toYear, toMonth int, err error) {
+	if yearStr := c.Query("year"); yearStr != "" && c.Query("fromYear") == "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year <= 0 {
+			return 0, 0, 0, 0, fmt.Errorf("invalid year parameter")
+		}
+		return year, 1, year, 12, nil
+	}
+
+	params := []string{"fromYear", "fromMonth", "toYear", "toMonth"}
+	values := make([]int, len(params))
+	for i, name := range params {
+		raw := c.Query(name)
+		if raw == "" {
+			return 0, 0, 0, 0, fmt.Errorf("year or fromYear, fromMonth, toYear and toMonth query parameters are required")
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			return 0, 0, 0, 0, fmt.Errorf("invalid %s parameter", name)
+		}
+		values[i] = value
+	}
+
+	if values[1] > 12 || values[3] > 12 {
+		return 0, 0, 0, 0, fmt.Errorf("month must be between 1 and 12")
+	}
+
+	return values[0], values[1], values[2], values[3], nil
 }
 
 // SetBaseIncome sets or updates the base income for the current month
@@ -123,13 +163,5 @@ func (bh *BudgetHandler) SetBaseIncome(c *fiber.Ctx) error {
 		})
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
-
-	return c.Status(fiber.StatusOK).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
-	})
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
 }
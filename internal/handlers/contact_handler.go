@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type ContactHandler struct {
+	contactService *services.ContactService
+	fundService    *services.FundService
+}
+
+func NewContactHandler(contactService *services.ContactService, fundService *services.FundService) *ContactHandler {
+	return &ContactHandler{
+		contactService: contactService,
+		fundService:    fundService,
+	}
+}
+
+// GetContacts lists the user's contacts
+// GET /contacts
+func (ch *ContactHandler) GetContacts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	contacts, err := ch.contactService.GetContacts(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(contacts)
+}
+
+// GetBalances lists every contact's net position
+// GET /contacts/balances?asOf=2026-06-30
+func (ch *ContactHandler) GetBalances(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	balances, err := ch.fundService.GetContactBalances(c.Context(), userID, asOf)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(balances)
+}
+
+// GetContactByID retrieves a contact
+// GET /contacts/:contactId
+func (ch *ContactHandler) GetContactByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	contact, err := ch.contactService.GetContactByID(c.Context(), userID, contactID)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(contact)
+}
+
+// GetBalance retrieves a contact's net position with its funds
+// GET /contacts/:contactId/balance?asOf=2026-06-30
+func (ch *ContactHandler) GetBalance(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	balance, err := ch.fundService.GetContactBalance(c.Context(), userID, contactID, asOf)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(balance)
+}
+
+// CreateContact creates a new contact
+// POST /contacts
+func (ch *ContactHandler) CreateContact(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.ContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	contact, err := ch.contactService.CreateContact(c.Context(), userID, req)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(contact)
+}
+
+// UpdateContact updates a contact
+// PUT /contacts/:contactId
+func (ch *ContactHandler) UpdateContact(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	var req models.ContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	contact, err := ch.contactService.UpdateContact(c.Context(), userID, contactID, req)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(contact)
+}
+
+// DeleteContact deletes a contact without funds
+// DELETE /contacts/:contactId
+func (ch *ContactHandler) DeleteContact(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	if err := ch.contactService.DeleteContact(c.Context(), userID, contactID); err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "contact deleted successfully",
+	})
+}
+
+// SettleContact pays off the contact's open funds against each other
+// POST /contacts/:contactId/settlements
+func (ch *ContactHandler) SettleContact(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	var req models.SettlementRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request format",
+			})
+		}
+	}
+
+	settlement, err := ch.fundService.SettleContact(c.Context(), userID, contactID, req)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(settlement)
+}
+
+// GetSettlements lists the contact's settlements
+// GET /contacts/:contactId/settlements
+func (ch *ContactHandler) GetSettlements(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	contactID := c.Params("contactId")
+
+	settlements, err := ch.fundService.GetSettlements(c.Context(), userID, contactID)
+	if err != nil {
+		return contactError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(settlements)
+}
+
+// contactError maps contact service errors onto HTTP responses
+func contactError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "contact not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "a contact with this name already exists", "contact has linked funds", "fund is being updated by another request, try again":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
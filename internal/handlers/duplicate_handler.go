@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type DuplicateHandler struct {
+	duplicateService *services.DuplicateService
+}
+
+func NewDuplicateHandler(duplicateService *services.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{
+		duplicateService: duplicateService,
+	}
+}
+
+// GetDuplicates lists likely duplicate expense pairs with a confidence score
+// GET /duplicates?maxDays=3&minConfidence=0.6
+func (dh *DuplicateHandler) GetDuplicates(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	maxDays := c.QueryInt("maxDays", services.DefaultDuplicateMaxDays)
+	minConfidence := c.QueryFloat("minConfidence", services.DefaultDuplicateMinConfidence)
+
+	candidates, err := dh.duplicateService.FindDuplicates(c.Context(), userID, maxDays, minConfidence)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(candidates)
+}
+
+// MergeDuplicates keeps one expense of a pair and deletes the other
+// POST /duplicates/merge
+func (dh *DuplicateHandler) MergeDuplicates(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.DuplicateMergeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	budget, err := dh.duplicateService.MergeDuplicates(c.Context(), userID, req)
+	if err != nil {
+		return duplicateError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
+}
+
+// DismissDuplicate marks a pair of expenses as not duplicates
+// POST /duplicates/dismiss
+func (dh *DuplicateHandler) DismissDuplicate(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.DuplicateDismissRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	if err := dh.duplicateService.DismissDuplicate(c.Context(), userID, req); err != nil {
+		return duplicateError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "duplicate dismissed successfully",
+	})
+}
+
+// duplicateError maps duplicate service errors onto HTTP responses
+func duplicateError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "expense not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "expense is reconciled and locked":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
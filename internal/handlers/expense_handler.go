@@ -10,12 +10,16 @@ import (
 )
 
 type ExpenseHandler struct {
-	budgetService *services.BudgetService
+	budgetService  *services.BudgetService
+	ruleService    *services.RuleService
+	accountService *services.AccountService
 }
 
-func NewExpenseHandler(budgetService *services.BudgetService) *ExpenseHandler {
+func NewExpenseHandler(budgetService *services.BudgetService, ruleService *services.RuleService, accountService *services.AccountService) *ExpenseHandler {
 	return &ExpenseHandler{
-		budgetService: budgetService,
+		budgetService:  budgetService,
+		ruleService:    ruleService,
+		accountService: accountService,
 	}
 }
 
@@ -44,11 +48,30 @@ func (eh *ExpenseHandler) AddExpense(c *fiber.Ctx) error {
 		})
 	}
 
+	accountID, err := eh.accountService.ResolveAccountID(c.Context(), userID, stringValue(req.AccountID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	expense := models.Expense{
-		ID:        primitive.NewObjectID(),
-		Title:     req.Title,
-		Amount:    req.Amount,
-		CreatedAt: time.Now(),
+		ID:            primitive.NewObjectID(),
+		Title:         req.Title,
+		Amount:        req.Amount,
+		Category:      stringValue(req.Category),
+		Tags:          req.Tags,
+		Merchant:      stringValue(req.Merchant),
+		PaymentMethod: stringValue(req.PaymentMethod),
+		AccountID:     accountID,
+		CreatedAt:     time.Now(),
+	}
+
+	// Fill in category, tags and title from the user's rules
+	if err := eh.ruleService.Categorize(c.Context(), userID, &expense); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	budget, err := eh.budgetService.AddExpense(c.Context(), userID, req.Year, req.Month, expense)
@@ -58,15 +81,7 @@ func (eh *ExpenseHandler) AddExpense(c *fiber.Ctx) error {
 		})
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
-
-	return c.Status(fiber.StatusCreated).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
-	})
+	return c.Status(fiber.StatusCreated).JSON(services.NewBudgetResponse(budget))
 }
 
 // UpdateExpense updates an existing expense
@@ -89,27 +104,33 @@ func (eh *ExpenseHandler) UpdateExpense(c *fiber.Ctx) error {
 		})
 	}
 
-	updatedExpense := models.Expense{
-		Title:  req.Title,
-		Amount: req.Amount,
+	// Fields left out of the request keep their value, so clients that don't know
+	// about them don't clear them
+	update := models.ExpenseUpdate{
+		Title:         req.Title,
+		Amount:        req.Amount,
+		Category:      req.Category,
+		Tags:          req.Tags,
+		Merchant:      req.Merchant,
+		PaymentMethod: req.PaymentMethod,
+	}
+	if req.AccountID != nil {
+		accountID, err := eh.accountService.ResolveAccountID(c.Context(), userID, *req.AccountID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		update.SetAccount = true
+		update.AccountID = accountID
 	}
 
-	budget, err := eh.budgetService.UpdateExpense(c.Context(), userID, expenseID, updatedExpense)
+	budget, err := eh.budgetService.UpdateExpense(c.Context(), userID, expenseID, update)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return expenseError(c, err)
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
-
-	return c.Status(fiber.StatusOK).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
-	})
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
 }
 
 // DeleteExpense deletes an expense
@@ -120,18 +141,33 @@ func (eh *ExpenseHandler) DeleteExpense(c *fiber.Ctx) error {
 
 	budget, err := eh.budgetService.DeleteExpense(c.Context(), userID, expenseID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return expenseError(c, err)
 	}
 
-	remaining := services.CalculateRemaining(budget.BaseIncome, budget.Expenses)
+	return c.Status(fiber.StatusOK).JSON(services.NewBudgetResponse(budget))
+}
 
-	return c.Status(fiber.StatusOK).JSON(models.BudgetResponse{
-		Year:       budget.Year,
-		Month:      budget.Month,
-		BaseIncome: budget.BaseIncome,
-		Expenses:   budget.Expenses,
-		Remaining:  remaining,
+// expenseError maps expense update and delete errors onto HTTP responses
+func expenseError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "expense not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "expense is reconciled and locked":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
 	})
 }
+
+// stringValue is the value of an optional string, empty when it is left out
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportBudgets streams the budgets of a month range as a file download
+// GET /exports?format=csv&year=2026
+// GET /exports?format=xlsx&fromYear=2025&fromMonth=7&toYear=2026&toMonth=6
+func (eh *ExportHandler) ExportBudgets(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	format := models.ExportFormat(strings.ToUpper(c.Query("format", string(models.ExportFormatCSV))))
+	contentType, extension, err := services.ExportContentType(format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	fromYear, fromMonth, toYear, toMonth, err := parseMonthRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The body is written after the handler returns, so it can't use the request context
+	write, err := eh.exportService.Export(context.Background(), userID, format, fromYear, fromMonth, toYear, toMonth)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	fileName := fmt.Sprintf("budget-%04d-%02d-to-%04d-%02d.%s", fromYear, fromMonth, toYear, toMonth, extension)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			// Headers are already sent, so the client just gets a truncated file
+			log.Printf("Export failed: %v", err)
+		}
+		w.Flush()
+	})
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/huxxnainali/finance-app/internal/models"
 	"github.com/huxxnainali/finance-app/internal/services"
@@ -16,74 +20,61 @@ func NewFundHandler(fundService *services.FundService) *FundHandler {
 	}
 }
 
-// GetAllFunds retrieves all funds for the authenticated user
-// GET /funds
+// GetAllFunds lists the authenticated user's funds, filtered, sorted and paged. The
+// number of funds matching the filters is sent in the X-Total-Count header.
+// GET /funds?type=GIVEN&status=OVERDUE&person=ali&sort=outstanding&order=desc&page=1&limit=20&transactions=false&asOf=2026-06-30
 func (fh *FundHandler) GetAllFunds(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
 
-	funds, err := fh.fundService.GetAllFunds(c.Context(), userID)
+	asOf, err := parseAsOf(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Build response with computed values
-	fundResponses := make([]models.FundResponse, 0, len(funds))
-	for _, fund := range funds {
-		totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), &fund)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		status, err := fh.fundService.GetFundStatus(c.Context(), &fund)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-
-		transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
+	order := c.Query("order", "asc")
+	if order != "asc" && order != "desc" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "order must be asc or desc",
+		})
+	}
 
-		fundResponses = append(fundResponses, models.FundResponse{
-			ID:              fund.ID.Hex(),
-			PersonName:      fund.PersonName,
-			Type:            fund.Type,
-			PrincipalAmount: fund.PrincipalAmount,
-			StartDate:       fund.StartDate,
-			Notes:           fund.Notes,
-			TotalPaid:       totalPaid,
-			Outstanding:     outstanding,
-			Status:          status,
-			Transactions:    transactions,
-			CreatedAt:       fund.CreatedAt,
-			UpdatedAt:       fund.UpdatedAt,
+	funds, total, err := fh.fundService.ListFunds(c.Context(), userID, services.FundListOptions{
+		Type:                models.FundType(c.Query("type")),
+		Status:              models.FundStatus(c.Query("status")),
+		ContactID:           c.Query("contactId"),
+		Person:              c.Query("person"),
+		AsOf:                asOf,
+		Sort:                c.Query("sort"),
+		Desc:                order == "desc",
+		Page:                c.QueryInt("page", 1),
+		Limit:               c.QueryInt("limit", 0),
+		IncludeTransactions: c.QueryBool("transactions", true),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fundResponses)
+	c.Set("X-Total-Count", strconv.Itoa(total))
+	return c.Status(fiber.StatusOK).JSON(funds)
 }
 
-// GetFundByID retrieves a specific fund by ID
-// GET /funds/:fundId
+// GetFundByID retrieves a specific fund by ID, with its balances as of a day
+// GET /funds/:fundId?asOf=2026-06-30
 func (fh *FundHandler) GetFundByID(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
 	fundID := c.Params("fundId")
 
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	fund, err := fh.fundService.GetFundByID(c.Context(), userID, fundID)
 	if err != nil {
 		if err.Error() == "fund not found or doesn't belong to user" {
@@ -97,48 +88,28 @@ func (fh *FundHandler) GetFundByID(c *fiber.Ctx) error {
 	}
 
 	// Calculate computed values
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, asOf)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
 
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+// parseAsOf reads the optional asOf query parameter; a zero time means the default day
+func parseAsOf(c *fiber.Ctx) (time.Time, error) {
+	raw := c.Query("asOf")
+	if raw == "" {
+		return time.Time{}, nil
 	}
 
-	transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
+	asOf, err := time.Parse("2006-01-02", raw)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return time.Time{}, fmt.Errorf("invalid asOf parameter, expected YYYY-MM-DD")
 	}
-
-	return c.Status(fiber.StatusOK).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    transactions,
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	return asOf, nil
 }
 
 // CreateFund creates a new fund
@@ -154,9 +125,9 @@ func (fh *FundHandler) CreateFund(c *fiber.Ctx) error {
 	}
 
 	// Validate required fields
-	if req.PersonName == "" {
+	if req.PersonName == "" && req.ContactID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "person name is required",
+			"error": "person name or contact ID is required",
 		})
 	}
 
@@ -180,41 +151,14 @@ func (fh *FundHandler) CreateFund(c *fiber.Ctx) error {
 	}
 
 	// Calculate computed values
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    []models.Transaction{},
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // UpdateFund updates an existing fund
@@ -231,9 +175,9 @@ func (fh *FundHandler) UpdateFund(c *fiber.Ctx) error {
 	}
 
 	// Validate required fields
-	if req.PersonName == "" {
+	if req.PersonName == "" && req.ContactID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "person name is required",
+			"error": "person name or contact ID is required",
 		})
 	}
 
@@ -251,59 +195,18 @@ func (fh *FundHandler) UpdateFund(c *fiber.Ctx) error {
 
 	fund, err := fh.fundService.UpdateFund(c.Context(), userID, fundID, req)
 	if err != nil {
-		if err.Error() == "fund not found or doesn't belong to user" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fundError(c, err)
 	}
 
 	// Calculate computed values
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	return c.Status(fiber.StatusOK).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    transactions,
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
 // DeleteFund deletes a fund and all its transactions
@@ -314,14 +217,7 @@ func (fh *FundHandler) DeleteFund(c *fiber.Ctx) error {
 
 	err := fh.fundService.DeleteFund(c.Context(), userID, fundID)
 	if err != nil {
-		if err.Error() == "fund not found or doesn't belong to user" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fundError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -342,8 +238,8 @@ func (fh *FundHandler) AddTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate transaction amount
-	if req.Amount <= 0 {
+	// Validate transaction amount; only adjustments can be negative
+	if req.Amount <= 0 && req.Type != models.TransactionAdjustment {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "transaction amount must be greater than 0",
 		})
@@ -351,14 +247,7 @@ func (fh *FundHandler) AddTransaction(c *fiber.Ctx) error {
 
 	_, err := fh.fundService.AddTransaction(c.Context(), userID, fundID, req)
 	if err != nil {
-		if err.Error() == "fund not found or doesn't belong to user" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fundError(c, err)
 	}
 
 	// Return updated fund with computed values
@@ -369,48 +258,14 @@ func (fh *FundHandler) AddTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    transactions,
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // UpdateTransaction updates an existing transaction
@@ -427,8 +282,8 @@ func (fh *FundHandler) UpdateTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate transaction amount
-	if req.Amount <= 0 {
+	// Validate transaction amount; only adjustments can be negative
+	if req.Amount <= 0 && req.Type != models.TransactionAdjustment {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "transaction amount must be greater than 0",
 		})
@@ -436,14 +291,7 @@ func (fh *FundHandler) UpdateTransaction(c *fiber.Ctx) error {
 
 	_, err := fh.fundService.UpdateTransaction(c.Context(), userID, fundID, transactionID, req)
 	if err != nil {
-		if err.Error() == "fund not found or doesn't belong to user" || err.Error() == "transaction not found or doesn't belong to fund" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fundError(c, err)
 	}
 
 	// Return updated fund with computed values
@@ -454,67 +302,92 @@ func (fh *FundHandler) UpdateTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DeleteTransaction deletes a transaction
+// DELETE /funds/:fundId/transactions/:transactionId
+func (fh *FundHandler) DeleteTransaction(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+	transactionID := c.Params("transactionId")
+
+	err := fh.fundService.DeleteTransaction(c.Context(), userID, fundID, transactionID)
+	if err != nil {
+		return fundError(c, err)
+	}
+
+	// Return updated fund with computed values
+	fund, err := fh.fundService.GetFundByID(c.Context(), userID, fundID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// WriteOffFund writes off part or all of what is owed on a fund
+// POST /funds/:fundId/write-offs
+func (fh *FundHandler) WriteOffFund(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+
+	var req models.WriteOffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	_, err := fh.fundService.WriteOff(c.Context(), userID, fundID, req)
+	if err != nil {
+		return fundError(c, err)
+	}
+
+	// Return updated fund with computed values
+	fund, err := fh.fundService.GetFundByID(c.Context(), userID, fundID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    transactions,
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
-// DeleteTransaction deletes a transaction
-// DELETE /funds/:fundId/transactions/:transactionId
-func (fh *FundHandler) DeleteTransaction(c *fiber.Ctx) error {
+// ReverseWriteOff undoes a write-off
+// POST /funds/:fundId/write-offs/:transactionId/reverse
+func (fh *FundHandler) ReverseWriteOff(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
 	fundID := c.Params("fundId")
 	transactionID := c.Params("transactionId")
 
-	err := fh.fundService.DeleteTransaction(c.Context(), userID, fundID, transactionID)
+	_, err := fh.fundService.ReverseWriteOff(c.Context(), userID, fundID, transactionID)
 	if err != nil {
-		if err.Error() == "fund not found or doesn't belong to user" || err.Error() == "transaction not found or doesn't belong to fund" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return fundError(c, err)
 	}
 
 	// Return updated fund with computed values
@@ -525,47 +398,143 @@ func (fh *FundHandler) DeleteTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	totalPaid, err := fh.fundService.CalculateTotalPaid(c.Context(), fund.ID)
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	outstanding, err := fh.fundService.CalculateOutstanding(c.Context(), fund)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetRepaymentSchedule lists a fund's instalments and how far each is paid
+// GET /funds/:fundId/schedule?asOf=2026-06-30
+func (fh *FundHandler) GetRepaymentSchedule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+
+	asOf, err := parseAsOf(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	schedule, err := fh.fundService.GetRepaymentSchedule(c.Context(), userID, fundID, asOf)
+	if err != nil {
+		return fundError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(schedule)
+}
+
+// SetRepaymentPlan attaches a repayment plan to a fund and returns its schedule
+// PUT /funds/:fundId/plan
+func (fh *FundHandler) SetRepaymentPlan(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+
+	var plan models.RepaymentPlan
+	if err := c.BodyParser(&plan); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	if _, err := fh.fundService.SetRepaymentPlan(c.Context(), userID, fundID, plan); err != nil {
+		return fundError(c, err)
+	}
+
+	schedule, err := fh.fundService.GetRepaymentSchedule(c.Context(), userID, fundID, time.Time{})
+	if err != nil {
+		return fundError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(schedule)
+}
+
+// DeleteRepaymentPlan removes a fund's repayment plan
+// DELETE /funds/:fundId/plan
+func (fh *FundHandler) DeleteRepaymentPlan(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+
+	if err := fh.fundService.DeleteRepaymentPlan(c.Context(), userID, fundID); err != nil {
+		return fundError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "repayment plan deleted successfully",
+	})
+}
+
+// GetOverdueFunds lists the overdue funds, most days late first
+// GET /funds/overdue?asOf=2026-06-30
+func (fh *FundHandler) GetOverdueFunds(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	status, err := fh.fundService.GetFundStatus(c.Context(), fund)
+	overdue, err := fh.fundService.GetOverdueFunds(c.Context(), userID, asOf)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	transactions, err := fh.fundService.GetTransactionsByFundID(c.Context(), fund.ID)
+	return c.Status(fiber.StatusOK).JSON(overdue)
+}
+
+// SetFundStatus marks a fund DISPUTED or WRITTEN_OFF, or clears the mark
+// PUT /funds/:fundId/status
+func (fh *FundHandler) SetFundStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	fundID := c.Params("fundId")
+
+	var req models.FundStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	fund, err := fh.fundService.SetFundStatus(c.Context(), userID, fundID, req)
+	if err != nil {
+		return fundError(c, err)
+	}
+
+	response, err := fh.fundService.NewFundResponse(c.Context(), fund, time.Time{})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.FundResponse{
-		ID:              fund.ID.Hex(),
-		PersonName:      fund.PersonName,
-		Type:            fund.Type,
-		PrincipalAmount: fund.PrincipalAmount,
-		StartDate:       fund.StartDate,
-		Notes:           fund.Notes,
-		TotalPaid:       totalPaid,
-		Outstanding:     outstanding,
-		Status:          status,
-		Transactions:    transactions,
-		CreatedAt:       fund.CreatedAt,
-		UpdatedAt:       fund.UpdatedAt,
-	})
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
+// fundError maps fund service errors onto HTTP responses
+func fundError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "fund not found or doesn't belong to user", "transaction not found or doesn't belong to fund", "fund has no repayment plan",
+		"write-off not found or doesn't belong to fund":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "transaction is part of a settlement", "fund is being updated by another request, try again",
+		"write-offs can only be reversed", "write-off is already reversed", "expense is reconciled and locked",
+		"principal amount can't be changed once the fund has transactions, add a DISBURSEMENT or ADJUSTMENT instead":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
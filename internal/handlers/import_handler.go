@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// CreateImport uploads a statement file for previewing and committing
+// POST /imports (multipart form: file, format)
+func (ih *ImportHandler) CreateImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is required",
+		})
+	}
+
+	if fileHeader.Size > services.MaxImportFileSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is too large",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, services.MaxImportFileSize+1))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+
+	format := models.ImportFormat(strings.ToUpper(c.FormValue("format", string(models.ImportFormatCSV))))
+
+	imp, err := ih.importService.CreateImport(c.Context(), userID, format, fileHeader.Filename, content)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(imp)
+}
+
+// GetImports lists the authenticated user's imports
+// GET /imports
+func (ih *ImportHandler) GetImports(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	imports, err := ih.importService.GetImports(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(imports)
+}
+
+// GetImportByID retrieves a specific import
+// GET /imports/:importId
+func (ih *ImportHandler) GetImportByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	importID := c.Params("importId")
+
+	imp, err := ih.importService.GetImportByID(c.Context(), userID, importID)
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(imp)
+}
+
+// PreviewImport parses an import with a column mapping without writing expenses
+// POST /imports/:importId/preview
+func (ih *ImportHandler) PreviewImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	importID := c.Params("importId")
+
+	var mapping *models.ImportMapping
+	if len(c.Body()) > 0 {
+		mapping = &models.ImportMapping{}
+		if err := c.BodyParser(mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request format",
+			})
+		}
+	}
+
+	preview, err := ih.importService.PreviewImport(c.Context(), userID, importID, mapping)
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(preview)
+}
+
+// CommitImport writes the valid rows of an import into the monthly budgets
+// POST /imports/:importId/commit
+func (ih *ImportHandler) CommitImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	importID := c.Params("importId")
+
+	var req models.ImportCommitRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request format",
+			})
+		}
+	}
+
+	imp, err := ih.importService.CommitImport(c.Context(), userID, importID, req)
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(imp)
+}
+
+// RollbackImport removes every expense created by an import
+// POST /imports/:importId/rollback
+func (ih *ImportHandler) RollbackImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	importID := c.Params("importId")
+
+	imp, err := ih.importService.RollbackImport(c.Context(), userID, importID)
+	if err != nil {
+		return importError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(imp)
+}
+
+// importError maps import service errors onto HTTP responses
+func importError(c *fiber.Ctx, err error) error {
+	if err.Error() == "import not found or doesn't belong to user" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	switch err.Error() {
+	case "import has reconciled entries and can't be rolled back",
+		"these transactions are being imported by another request, try again":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
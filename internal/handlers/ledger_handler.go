@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// GetAccounts returns the account names used in journal exports
+// GET /exports/journal/accounts
+func (lh *LedgerHandler) GetAccounts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	accounts, err := lh.ledgerService.GetAccounts(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(accounts)
+}
+
+// UpdateAccounts sets the account names used in journal exports
+// PUT /exports/journal/accounts
+func (lh *LedgerHandler) UpdateAccounts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.LedgerAccounts
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	accounts, err := lh.ledgerService.UpdateAccounts(c.Context(), userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(accounts)
+}
+
+// ExportJournal streams expenses, income and fund activity as a plain-text accounting journal
+// GET /exports/journal?format=beancount (all time)
+// GET /exports/journal?format=ledger&year=2026
+func (lh *LedgerHandler) ExportJournal(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	format := models.JournalFormat(strings.ToUpper(c.Query("format", string(models.JournalFormatLedger))))
+	extension, err := services.JournalExtension(format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	allTime := c.Query("year") == "" && c.Query("fromYear") == ""
+	var fromYear, fromMonth, toYear, toMonth int
+	if !allTime {
+		fromYear, fromMonth, toYear, toMonth, err = parseMonthRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// The body is written after the handler returns, so it can't use the request context
+	write, err := lh.ledgerService.ExportJournal(context.Background(), userID, format, allTime, fromYear, fromMonth, toYear, toMonth)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	fileName := "finances." + extension
+	if !allTime {
+		fileName = fmt.Sprintf("finances-%04d-%02d-to-%04d-%02d.%s", fromYear, fromMonth, toYear, toMonth, extension)
+	}
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			// Headers are already sent, so the client just gets a truncated file
+			log.Printf("Journal export failed: %v", err)
+		}
+		w.Flush()
+	})
+
+	return nil
+}
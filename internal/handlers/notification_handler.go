@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetInbox lists the user's in-app notifications
+// GET /notifications?unread=true&limit=50
+func (nh *NotificationHandler) GetInbox(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	inbox, err := nh.notificationService.GetInbox(c.Context(), userID, c.QueryBool("unread"), c.QueryInt("limit", services.DefaultInboxLimit))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(inbox)
+}
+
+// MarkRead marks a notification as read
+// POST /notifications/:notificationId/read
+func (nh *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	notificationID := c.Params("notificationId")
+
+	notification, err := nh.notificationService.MarkRead(c.Context(), userID, notificationID)
+	if err != nil {
+		if err.Error() == "notification not found or doesn't belong to user" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(notification)
+}
+
+// MarkAllRead marks every notification as read
+// POST /notifications/read-all
+func (nh *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	marked, err := nh.notificationService.MarkAllRead(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"marked": marked,
+	})
+}
+
+// GetPreferences retrieves the user's notification preferences
+// GET /notifications/preferences
+func (nh *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	prefs, err := nh.notificationService.GetPreferences(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(prefs)
+}
+
+// UpdatePreferences replaces the user's notification preferences
+// PUT /notifications/preferences
+func (nh *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.NotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	prefs, err := nh.notificationService.UpdatePreferences(c.Context(), userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(prefs)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService *services.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// GetReconciliations lists an account's reconciliations
+// GET /accounts/:accountId/reconciliations
+func (rh *ReconciliationHandler) GetReconciliations(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	reconciliations, err := rh.reconciliationService.GetReconciliations(c.Context(), userID, accountID)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reconciliations)
+}
+
+// StartReconciliation starts reconciling an account against a statement
+// POST /accounts/:accountId/reconciliations
+func (rh *ReconciliationHandler) StartReconciliation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	accountID := c.Params("accountId")
+
+	var req models.ReconciliationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	reconciliation, err := rh.reconciliationService.StartReconciliation(c.Context(), userID, accountID, req)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(reconciliation)
+}
+
+// GetReconciliation retrieves a reconciliation with its cleared balance and difference
+// GET /reconciliations/:reconciliationId
+func (rh *ReconciliationHandler) GetReconciliation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	reconciliationID := c.Params("reconciliationId")
+
+	reconciliation, err := rh.reconciliationService.GetReconciliation(c.Context(), userID, reconciliationID)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reconciliation)
+}
+
+// ClearEntries marks account entries as cleared or uncleared
+// POST /reconciliations/:reconciliationId/clear
+func (rh *ReconciliationHandler) ClearEntries(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	reconciliationID := c.Params("reconciliationId")
+
+	var req models.ClearEntriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	reconciliation, err := rh.reconciliationService.ClearEntries(c.Context(), userID, reconciliationID, req)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reconciliation)
+}
+
+// CompleteReconciliation locks the cleared entries once they match the statement
+// POST /reconciliations/:reconciliationId/complete
+func (rh *ReconciliationHandler) CompleteReconciliation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	reconciliationID := c.Params("reconciliationId")
+
+	reconciliation, err := rh.reconciliationService.CompleteReconciliation(c.Context(), userID, reconciliationID)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reconciliation)
+}
+
+// DeleteReconciliation abandons a reconciliation in progress
+// DELETE /reconciliations/:reconciliationId
+func (rh *ReconciliationHandler) DeleteReconciliation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	reconciliationID := c.Params("reconciliationId")
+
+	if err := rh.reconciliationService.DeleteReconciliation(c.Context(), userID, reconciliationID); err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "reconciliation deleted successfully",
+	})
+}
+
+// reconciliationError maps reconciliation service errors onto HTTP responses
+func reconciliationError(c *fiber.Ctx, err error) error {
+	switch {
+	case err.Error() == "account not found or doesn't belong to user",
+		err.Error() == "reconciliation not found or doesn't belong to user":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err.Error() == "account already has a reconciliation in progress",
+		err.Error() == "reconciliation is already completed",
+		strings.HasPrefix(err.Error(), "cleared balance differs"),
+		strings.HasSuffix(err.Error(), "is reconciled and locked"):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/services"
+)
+
+type RuleHandler struct {
+	ruleService *services.RuleService
+}
+
+func NewRuleHandler(ruleService *services.RuleService) *RuleHandler {
+	return &RuleHandler{
+		ruleService: ruleService,
+	}
+}
+
+// GetRules lists the authenticated user's categorization rules in priority order
+// GET /rules
+func (rh *RuleHandler) GetRules(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	rules, err := rh.ruleService.GetRules(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rules)
+}
+
+// GetRuleByID retrieves a specific rule
+// GET /rules/:ruleId
+func (rh *RuleHandler) GetRuleByID(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	ruleID := c.Params("ruleId")
+
+	rule, err := rh.ruleService.GetRuleByID(c.Context(), userID, ruleID)
+	if err != nil {
+		return ruleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rule)
+}
+
+// CreateRule creates a new categorization rule
+// POST /rules
+func (rh *RuleHandler) CreateRule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.CategoryRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	rule, err := rh.ruleService.CreateRule(c.Context(), userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateRule replaces an existing rule
+// PUT /rules/:ruleId
+func (rh *RuleHandler) UpdateRule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	ruleID := c.Params("ruleId")
+
+	var req models.CategoryRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	rule, err := rh.ruleService.UpdateRule(c.Context(), userID, ruleID, req)
+	if err != nil {
+		return ruleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rule)
+}
+
+// DeleteRule deletes a rule
+// DELETE /rules/:ruleId
+func (rh *RuleHandler) DeleteRule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	ruleID := c.Params("ruleId")
+
+	if err := rh.ruleService.DeleteRule(c.Context(), userID, ruleID); err != nil {
+		return ruleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "rule deleted successfully",
+	})
+}
+
+// DryRunRule shows which existing expenses an unsaved rule would change
+// POST /rules/dry-run?overwrite=true
+func (rh *RuleHandler) DryRunRule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	var req models.CategoryRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request format",
+		})
+	}
+
+	result, err := rh.ruleService.DryRunRule(c.Context(), userID, req, c.QueryBool("overwrite"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// DryRunSavedRule shows which existing expenses a saved rule would change
+// POST /rules/:ruleId/dry-run?overwrite=true
+func (rh *RuleHandler) DryRunSavedRule(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	ruleID := c.Params("ruleId")
+
+	result, err := rh.ruleService.DryRunSavedRule(c.Context(), userID, ruleID, c.QueryBool("overwrite"))
+	if err != nil {
+		return ruleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// ApplyRuleToHistory applies a saved rule to all existing expenses
+// POST /rules/:ruleId/apply?overwrite=true
+func (rh *RuleHandler) ApplyRuleToHistory(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+	ruleID := c.Params("ruleId")
+
+	result, err := rh.ruleService.ApplyRuleToHistory(c.Context(), userID, ruleID, c.QueryBool("overwrite"))
+	if err != nil {
+		return ruleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// ruleError maps rule service errors onto HTTP responses
+func ruleError(c *fiber.Ctx, err error) error {
+	if err.Error() == "rule not found or doesn't belong to user" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/huxxnainali/finance-app/internal/exporters"
+	"github.com/huxxnainali/finance-app/internal/services"
+	"github.com/huxxnainali/finance-app/internal/utils"
+)
+
+type StatementHandler struct {
+	statementService *services.StatementService
+}
+
+func NewStatementHandler(statementService *services.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// GetMonthlyStatement renders a printable PDF statement for a month (current month by default)
+// GET /budget/statement?year=2026&month=1
+func (sh *StatementHandler) GetMonthlyStatement(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(string)
+
+	year, month := utils.GetCurrentMonthYear()
+	if yearStr, monthStr := c.Query("year"), c.Query("month"); yearStr != "" || monthStr != "" {
+		var err error
+		if year, err = strconv.Atoi(yearStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid year parameter",
+			})
+		}
+		if month, err = strconv.Atoi(monthStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid month parameter",
+			})
+		}
+	}
+
+	statement, err := sh.statementService.GetMonthlyStatement(c.Context(), userID, year, month)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var pdf bytes.Buffer
+	if err := exporters.WriteStatementPDF(&pdf, *statement); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="statement-%04d-%02d.pdf"`, year, month))
+	return c.Status(fiber.StatusOK).Send(pdf.Bytes())
+}
@@ -0,0 +1,177 @@
+package importers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// camtDocument covers the parts of an ISO 20022 camt.053 bank-to-customer statement
+// that are needed to build transactions. Tags are matched regardless of the
+// namespace, so any camt.053.001.xx version decodes.
+type camtDocument struct {
+	Statements []struct {
+		Account struct {
+			IBAN  string `xml:"Id>IBAN"`
+			Other string `xml:"Id>Othr>Id"`
+		} `xml:"Acct"`
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Reference          string     `xml:"NtryRef"`
+	Amount             string     `xml:"Amt"`
+	CreditDebit        string     `xml:"CdtDbtInd"`
+	Reversal           bool       `xml:"RvslInd"`
+	Status             camtStatus `xml:"Sts"`
+	BookingDate        camtDate   `xml:"BookgDt"`
+	ValueDate          camtDate   `xml:"ValDt"`
+	ServicerRef        string     `xml:"AcctSvcrRef"`
+	AdditionalInfo     string     `xml:"AddtlNtryInf"`
+	TransactionDetails []struct {
+		ServicerRef  string   `xml:"Refs>AcctSvcrRef"`
+		EndToEndID   string   `xml:"Refs>EndToEndId"`
+		Creditor     string   `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPty  string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Debtor       string   `xml:"RltdPties>Dbtr>Nm"`
+		DebtorPty    string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+		Unstructured []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus holds the entry status, which is plain text up to version 07
+// and a nested code from version 08 on
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		value := strings.TrimSpace(d.DateTime)
+		if len(value) >= 10 {
+			return time.Parse("2006-01-02", value[:10])
+		}
+	}
+	return time.Time{}, fmt.Errorf("date is missing")
+}
+
+// ParseCAMT053 decodes the entries of an ISO 20022 camt.053 statement.
+// Debit entries become negative amounts, reversals flip the sign and pending
+// entries are flagged. The account servicer's entry reference is the external ID.
+func ParseCAMT053(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 file: %v", err)
+	}
+
+	var transactions []Transaction
+	for _, stmt := range doc.Statements {
+		account := strings.TrimSpace(stmt.Account.IBAN)
+		if account == "" {
+			account = strings.TrimSpace(stmt.Account.Other)
+		}
+
+		for i, entry := range stmt.Entries {
+			transactions = append(transactions, camtTransaction(entry, account, i+1))
+		}
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("camt.053 file contains no entries")
+	}
+
+	return transactions, nil
+}
+
+func camtTransaction(entry camtEntry, account string, index int) Transaction {
+	// camt files have no meaningful line numbers; number entries instead
+	tx := Transaction{Line: index}
+
+	status := strings.ToUpper(strings.TrimSpace(firstNonEmpty(entry.Status.Code, entry.Status.Text)))
+	tx.Pending = status == "PDNG" || status == "INFO"
+
+	var unstructured []string
+	for _, details := range entry.TransactionDetails {
+		if tx.Payee == "" {
+			if strings.EqualFold(entry.CreditDebit, "DBIT") {
+				tx.Payee = firstNonEmpty(details.Creditor, details.CreditorPty)
+			} else {
+				tx.Payee = firstNonEmpty(details.Debtor, details.DebtorPty)
+			}
+		}
+		unstructured = append(unstructured, details.Unstructured...)
+	}
+	tx.Payee = strings.TrimSpace(tx.Payee)
+	tx.Description = firstNonEmpty(tx.Payee, strings.TrimSpace(strings.Join(unstructured, " ")), strings.TrimSpace(entry.AdditionalInfo))
+
+	reference := firstNonEmpty(entry.ServicerRef, entry.Reference)
+	if reference == "" && len(entry.TransactionDetails) == 1 {
+		reference = firstNonEmpty(entry.TransactionDetails[0].ServicerRef, entry.TransactionDetails[0].EndToEndID)
+		if reference == "NOTPROVIDED" {
+			reference = ""
+		}
+	}
+	if reference != "" {
+		tx.ExternalID = "CAMT:" + account + ":" + strings.TrimSpace(reference)
+	}
+
+	bookingDate, bookingErr := entry.BookingDate.parse()
+	valueDate, valueErr := entry.ValueDate.parse()
+	switch {
+	case bookingErr == nil:
+		tx.Date = bookingDate
+	case valueErr == nil:
+		tx.Date = valueDate
+	default:
+		tx.Error = "entry has no booking or value date"
+		return tx
+	}
+	if valueErr == nil {
+		tx.ValueDate = valueDate
+	}
+
+	amount, err := ParseAmount(entry.Amount, ".", "")
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(entry.CreditDebit)) {
+	case "DBIT":
+		amount = -amount
+	case "CRDT":
+	default:
+		tx.Error = fmt.Sprintf("invalid credit/debit indicator %q", entry.CreditDebit)
+		return tx
+	}
+	if entry.Reversal {
+		amount = -amount
+	}
+	tx.Amount = amount
+
+	if tx.Description == "" {
+		tx.Error = "entry has no description"
+	}
+
+	return tx
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
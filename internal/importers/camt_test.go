@@ -0,0 +1,115 @@
+package importers
+
+import (
+	"testing"
+	"time"
+)
+
+const testCAMT053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">42.10</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2026-01-05</Dt></BookgDt>
+        <ValDt><Dt>2026-01-06</Dt></ValDt>
+        <AcctSvcrRef>REF1</AcctSvcrRef>
+        <NtryDtls><TxDtls><RltdPties><Cdtr><Nm>Grocer</Nm></Cdtr></RltdPties></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">100.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><DtTm>2026-01-07T10:00:00</DtTm></BookgDt>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-7</EndToEndId></Refs>
+          <RmtInf><Ustrd>Refund</Ustrd><Ustrd>order 12</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <NtryRef>R3</NtryRef>
+        <Amt Ccy="EUR">1.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <AddtlNtryInf>Fee</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">1.00</Amt>
+        <CdtDbtInd>XX</CdtDbtInd>
+        <ValDt><Dt>2026-01-08</Dt></ValDt>
+        <AddtlNtryInf>Odd</AddtlNtryInf>
+        <NtryDtls><TxDtls><Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+func TestParseCAMT053(t *testing.T) {
+	account := "CAMT:DE89370400440532013000:"
+	want := []Transaction{
+		{
+			Line:        1,
+			Date:        time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC),
+			Description: "Grocer",
+			Payee:       "Grocer",
+			Amount:      -42.1,
+			ExternalID:  account + "REF1",
+		},
+		{
+			Line:        2,
+			Date:        time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+			Pending:     true,
+			Description: "Refund order 12",
+			Amount:      -100,
+			ExternalID:  account + "E2E-7",
+		},
+		{
+			Line:        3,
+			Description: "Fee",
+			ExternalID:  account + "R3",
+			Error:       "entry has no booking or value date",
+		},
+		{
+			Line:        4,
+			Date:        time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC),
+			Description: "Odd",
+			Error:       `invalid credit/debit indicator "XX"`,
+		},
+	}
+
+	got, err := ParseCAMT053([]byte(testCAMT053))
+	if err != nil {
+		t.Fatalf("ParseCAMT053: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCAMT053RejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not XML", "Date,Description,Amount\n"},
+		{"no entries", `<Document><BkToCstmrStmt><Stmt><Acct><Id><IBAN>X</IBAN></Id></Acct></Stmt></BkToCstmrStmt></Document>`},
+		{"truncated", `<Document><BkToCstmrStmt><Stmt>`},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseCAMT053([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseCAMT053 succeeded, want an error", tt.name)
+		}
+	}
+}
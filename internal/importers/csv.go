@@ -0,0 +1,221 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// csvSampleRows is how many data rows are returned to help with column mapping
+const csvSampleRows = 5
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvRecord is a CSV record along with the file line it starts on
+type csvRecord struct {
+	line   int
+	fields []string
+}
+
+// readCSV reads every record of a CSV file using the given delimiter (default ",")
+func readCSV(data []byte, delimiter string) ([]csvRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	if delimiter != "" {
+		if delimiter == `\t` {
+			delimiter = "\t"
+		}
+		runes := []rune(delimiter)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("delimiter must be a single character")
+		}
+		reader.Comma = runes[0]
+	}
+	// Trimming leading space would also swallow empty fields between whitespace delimiters
+	reader.TrimLeadingSpace = !unicode.IsSpace(reader.Comma)
+
+	var records []csvRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, csvRecord{line: line, fields: fields})
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	return records, nil
+}
+
+// SampleCSV returns the header row (if any) and the first few data rows of a CSV file
+func SampleCSV(data []byte, delimiter string, hasHeader bool) ([]string, [][]string, error) {
+	records, err := readCSV(data, delimiter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var headers []string
+	if hasHeader {
+		headers = records[0].fields
+		records = records[1:]
+	}
+
+	sample := make([][]string, 0, csvSampleRows)
+	for _, record := range records {
+		if len(sample) == csvSampleRows {
+			break
+		}
+		sample = append(sample, record.fields)
+	}
+
+	return headers, sample, nil
+}
+
+// ParseCSV decodes every data row of a CSV file according to a column mapping.
+// Rows that can't be decoded are returned with Error set rather than failing the whole file.
+func ParseCSV(data []byte, mapping models.ImportMapping) ([]Transaction, error) {
+	if mapping.DateColumn == nil || mapping.DescriptionColumn == nil {
+		return nil, fmt.Errorf("date and description columns are required")
+	}
+	if mapping.AmountColumn == nil && mapping.DebitColumn == nil && mapping.CreditColumn == nil {
+		return nil, fmt.Errorf("an amount column or debit/credit columns are required")
+	}
+	if mapping.AmountSign != "" && mapping.AmountSign != models.AmountSignNegativeIsExpense && mapping.AmountSign != models.AmountSignPositiveIsExpense {
+		return nil, fmt.Errorf("invalid amount sign, must be NEGATIVE_IS_EXPENSE or POSITIVE_IS_EXPENSE")
+	}
+
+	layout, err := DateLayout(mapping.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := readCSV(data, mapping.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.HasHeader {
+		records = records[1:]
+	}
+
+	transactions := make([]Transaction, 0, len(records))
+	for _, record := range records {
+		if isBlankRecord(record.fields) {
+			continue
+		}
+
+		tx := Transaction{Line: record.line}
+		if err := decodeCSVRecord(record.fields, mapping, layout, &tx); err != nil {
+			tx.Error = err.Error()
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
+func decodeCSVRecord(record []string, mapping models.ImportMapping, layout string, tx *Transaction) error {
+	rawDate, err := csvField(record, mapping.DateColumn, "date")
+	if err != nil {
+		return err
+	}
+	date, err := time.Parse(layout, rawDate)
+	if err != nil {
+		return fmt.Errorf("invalid date %q", rawDate)
+	}
+	tx.Date = date
+
+	description, err := csvField(record, mapping.DescriptionColumn, "description")
+	if err != nil {
+		return err
+	}
+	if description == "" {
+		return fmt.Errorf("description is empty")
+	}
+	tx.Description = description
+
+	if mapping.CategoryColumn != nil {
+		category, err := csvField(record, mapping.CategoryColumn, "category")
+		if err != nil {
+			return err
+		}
+		tx.Category = category
+	}
+
+	if mapping.AmountColumn != nil {
+		rawAmount, err := csvField(record, mapping.AmountColumn, "amount")
+		if err != nil {
+			return err
+		}
+		amount, err := ParseAmount(rawAmount, mapping.DecimalSeparator, mapping.ThousandsSeparator)
+		if err != nil {
+			return err
+		}
+		if mapping.AmountSign == models.AmountSignPositiveIsExpense {
+			amount = -amount
+		}
+		tx.Amount = amount
+		return nil
+	}
+
+	// Separate debit and credit columns: usually only one of them is filled in
+	for _, column := range []struct {
+		index *int
+		name  string
+		sign  float64
+	}{
+		{mapping.DebitColumn, "debit", -1},
+		{mapping.CreditColumn, "credit", 1},
+	} {
+		if column.index == nil {
+			continue
+		}
+		raw, err := csvField(record, column.index, column.name)
+		if err != nil {
+			return err
+		}
+		if raw == "" {
+			continue
+		}
+		amount, err := ParseAmount(raw, mapping.DecimalSeparator, mapping.ThousandsSeparator)
+		if err != nil {
+			return err
+		}
+		if amount < 0 {
+			amount = -amount
+		}
+		tx.Amount += column.sign * amount
+	}
+
+	return nil
+}
+
+func csvField(record []string, column *int, name string) (string, error) {
+	if *column < 0 || *column >= len(record) {
+		return "", fmt.Errorf("row has no %s column (%d)", name, *column)
+	}
+	return strings.TrimSpace(record[*column]), nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
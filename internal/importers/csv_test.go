@@ -0,0 +1,147 @@
+package importers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func column(i int) *int {
+	return &i
+}
+
+func TestParseCSV(t *testing.T) {
+	jan := func(day int) time.Time {
+		return time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		mapping models.ImportMapping
+		want    []Transaction
+	}{
+		{
+			name: "amount column with header and BOM",
+			data: "\xEF\xBB\xBFDate,Description,Amount,Category\n" +
+				"05/01/2026,\"Coffee, large\",-3.50,Food\n" +
+				"\n" +
+				"31/01/2026,Salary,2500,\n",
+			mapping: models.ImportMapping{
+				HasHeader:         true,
+				DateColumn:        column(0),
+				DescriptionColumn: column(1),
+				AmountColumn:      column(2),
+				CategoryColumn:    column(3),
+				DateFormat:        "DD/MM/YYYY",
+			},
+			want: []Transaction{
+				{Line: 2, Date: jan(5), Description: "Coffee, large", Amount: -3.5, Category: "Food"},
+				{Line: 4, Date: jan(31), Description: "Salary", Amount: 2500},
+			},
+		},
+		{
+			name: "positive amounts are expenses",
+			data: "2026-01-05;Card payment;1.234,50\n",
+			mapping: models.ImportMapping{
+				Delimiter:          ";",
+				DateColumn:         column(0),
+				DescriptionColumn:  column(1),
+				AmountColumn:       column(2),
+				DateFormat:         "YYYY-MM-DD",
+				DecimalSeparator:   ",",
+				ThousandsSeparator: ".",
+				AmountSign:         models.AmountSignPositiveIsExpense,
+			},
+			want: []Transaction{
+				{Line: 1, Date: jan(5), Description: "Card payment", Amount: -1234.5},
+			},
+		},
+		{
+			name: "debit and credit columns",
+			data: "01/05/2026\tRent\t900.00\t\n01/06/2026\tRefund\t\t-20\n",
+			mapping: models.ImportMapping{
+				Delimiter:         `\t`,
+				DateColumn:        column(0),
+				DescriptionColumn: column(1),
+				DebitColumn:       column(2),
+				CreditColumn:      column(3),
+				DateFormat:        "MM/DD/YYYY",
+			},
+			want: []Transaction{
+				{Line: 1, Date: jan(5), Description: "Rent", Amount: -900},
+				{Line: 2, Date: jan(6), Description: "Refund", Amount: 20},
+			},
+		},
+		{
+			name: "rows that can't be decoded keep an error",
+			data: "31/13/2026,Coffee,3\n05/01/2026,,3\n05/01/2026,Tea,12#50\n05/01/2026,Cake\n",
+			mapping: models.ImportMapping{
+				DateColumn:        column(0),
+				DescriptionColumn: column(1),
+				AmountColumn:      column(2),
+				DateFormat:        "DD/MM/YYYY",
+			},
+			want: []Transaction{
+				{Line: 1, Error: `invalid date "31/13/2026"`},
+				{Line: 2, Date: jan(5), Error: "description is empty"},
+				{Line: 3, Date: jan(5), Description: "Tea", Error: `invalid amount "12#50"`},
+				{Line: 4, Date: jan(5), Description: "Cake", Error: "row has no amount column (2)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV([]byte(tt.data), tt.mapping)
+			if err != nil {
+				t.Fatalf("ParseCSV: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("transaction %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVRejectsIncompleteMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		mapping models.ImportMapping
+	}{
+		{"no date column", "a,b,c\n", models.ImportMapping{DescriptionColumn: column(1), AmountColumn: column(2), DateFormat: "DD/MM/YYYY"}},
+		{"no amount columns", "a,b,c\n", models.ImportMapping{DateColumn: column(0), DescriptionColumn: column(1), DateFormat: "DD/MM/YYYY"}},
+		{"invalid amount sign", "a,b,c\n", models.ImportMapping{DateColumn: column(0), DescriptionColumn: column(1), AmountColumn: column(2), DateFormat: "DD/MM/YYYY", AmountSign: "BOTH"}},
+		{"no date format", "a,b,c\n", models.ImportMapping{DateColumn: column(0), DescriptionColumn: column(1), AmountColumn: column(2)}},
+		{"long delimiter", "a,b,c\n", models.ImportMapping{DateColumn: column(0), DescriptionColumn: column(1), AmountColumn: column(2), DateFormat: "DD/MM/YYYY", Delimiter: "::"}},
+		{"empty file", "", models.ImportMapping{DateColumn: column(0), DescriptionColumn: column(1), AmountColumn: column(2), DateFormat: "DD/MM/YYYY"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseCSV([]byte(tt.data), tt.mapping); err == nil {
+			t.Errorf("%s: ParseCSV succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func TestSampleCSV(t *testing.T) {
+	data := "Date,Description,Amount\n1,a,1\n2,b,2\n3,c,3\n4,d,4\n5,e,5\n6,f,6\n"
+
+	headers, sample, err := SampleCSV([]byte(data), "", true)
+	if err != nil {
+		t.Fatalf("SampleCSV: %v", err)
+	}
+	if len(headers) != 3 || headers[0] != "Date" {
+		t.Errorf("headers = %v, want the first row", headers)
+	}
+	if len(sample) != csvSampleRows || sample[0][1] != "a" {
+		t.Errorf("sample = %v, want the first %d data rows", sample, csvSampleRows)
+	}
+}
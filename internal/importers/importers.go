@@ -0,0 +1,131 @@
+package importers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Transaction is a single statement line decoded from an import file.
+// Amount is signed: negative amounts are money going out of the account.
+// ExternalID identifies the transaction at the bank so re-imports can be detected.
+// Date is the booking date; ValueDate is only set by formats that report one.
+type Transaction struct {
+	Line        int
+	Date        time.Time
+	ValueDate   time.Time
+	Pending     bool
+	Description string
+	Payee       string
+	Amount      float64
+	Category    string
+	ExternalID  string
+	Error       string
+}
+
+// dateTokens maps human-friendly date format tokens onto Go layout elements,
+// longest tokens first so that "YYYY" wins over "YY".
+var dateTokens = []struct {
+	token  string
+	layout string
+}{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMMM", "January"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"M", "1"},
+	{"DD", "02"},
+	{"D", "2"},
+}
+
+// DateLayout converts a date format such as "DD/MM/YYYY" into a Go time layout.
+// Formats that already are Go layouts (e.g. "02.01.2006") are returned unchanged.
+func DateLayout(format string) (string, error) {
+	format = strings.TrimSpace(format)
+	if format == "" {
+		return "", fmt.Errorf("date format is required")
+	}
+	if !strings.ContainsAny(format, "YD") {
+		return format, nil
+	}
+
+	var layout strings.Builder
+	seen := map[byte]bool{}
+	for i := 0; i < len(format); {
+		matched := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				seen[t.token[0]] = true
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			layout.WriteByte(format[i])
+			i++
+		}
+	}
+
+	if !seen['Y'] || !seen['M'] || !seen['D'] {
+		return "", fmt.Errorf("date format must contain a day, month and year")
+	}
+
+	return layout.String(), nil
+}
+
+// ParseAmount parses a number written with the given decimal and thousands separators.
+// Currency symbols and whitespace are ignored and "(12.50)" is read as -12.50.
+func ParseAmount(raw, decimalSeparator, thousandsSeparator string) (float64, error) {
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+
+	// Drop surrounding currency symbols and codes, e.g. "EUR 12,50" or "$12.50"
+	value := strings.TrimFunc(raw, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.Is(unicode.Sc, r)
+	})
+	if value == "" {
+		return 0, fmt.Errorf("amount is empty")
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+
+	if thousandsSeparator != "" {
+		value = strings.ReplaceAll(value, thousandsSeparator, "")
+	}
+	value = strings.ReplaceAll(value, decimalSeparator, ".")
+
+	var cleaned strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9', r == '.':
+			cleaned.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		case r == '+', r == ' ':
+		case r == ',' || r == '\'':
+			return 0, fmt.Errorf("invalid amount %q", raw)
+		default:
+			return 0, fmt.Errorf("invalid amount %q", raw)
+		}
+	}
+
+	amount, err := strconv.ParseFloat(cleaned.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
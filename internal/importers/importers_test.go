@@ -0,0 +1,76 @@
+package importers
+
+import "testing"
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "DD/MM/YYYY", want: "02/01/2006"},
+		{format: "MM/DD/YYYY", want: "01/02/2006"},
+		{format: "YYYY-MM-DD", want: "2006-01-02"},
+		{format: "M/D/YY", want: "1/2/06"},
+		{format: "DD MMM YYYY", want: "02 Jan 2006"},
+		{format: "D MMMM YYYY", want: "2 January 2006"},
+		{format: " DD.MM.YYYY ", want: "02.01.2006"},
+		{format: "02.01.2006", want: "02.01.2006"},
+		{format: "", wantErr: true},
+		{format: "MM/YYYY", wantErr: true},
+		{format: "DD/MM", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := DateLayout(tt.format)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("DateLayout(%q) = %q, want an error", tt.format, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("DateLayout(%q) = %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw       string
+		decimal   string
+		thousands string
+		want      float64
+		wantErr   bool
+	}{
+		{raw: "12.50", want: 12.5},
+		{raw: "-12.50", want: -12.5},
+		{raw: "+5", want: 5},
+		{raw: "(12.50)", want: -12.5},
+		{raw: "12.50-", want: -12.5},
+		{raw: "$12.50", want: 12.5},
+		{raw: " 7 ", want: 7},
+		{raw: "EUR 12,50", decimal: ",", want: 12.5},
+		{raw: "1,234.56", decimal: ".", thousands: ",", want: 1234.56},
+		{raw: "1.234,56", decimal: ",", thousands: ".", want: 1234.56},
+		{raw: "1'234.50", thousands: "'", want: 1234.5},
+		{raw: "1,234.56", wantErr: true},
+		{raw: "1.2.3", wantErr: true},
+		{raw: "12#50", wantErr: true},
+		{raw: "", wantErr: true},
+		{raw: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.raw, tt.decimal, tt.thousands)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAmount(%q, %q, %q) = %v, want an error", tt.raw, tt.decimal, tt.thousands, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmount(%q, %q, %q) = %v, %v, want %v", tt.raw, tt.decimal, tt.thousands, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mt940Tag matches the start of an MT940 field, e.g. ":61:" or ":60F:"
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+// mt940Line matches the statement line (:61:) subfields:
+// value date, optional booking date, debit/credit mark, optional funds code,
+// amount, transaction type, customer reference and optional bank reference
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/]*?)(?://(.*))?$`)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// ParseMT940 decodes the statement lines of a SWIFT MT940 file.
+// The :61: value date is kept as the value date and its booking date (when
+// present) as the date; :86: information supplies the counterparty and purpose.
+// The bank reference (or the customer reference) is the external ID.
+func ParseMT940(data []byte) ([]Transaction, error) {
+	fields, err := readMT940Fields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		transactions []Transaction
+		account      string
+		occurrences  = map[string]int{}
+	)
+
+	for i, field := range fields {
+		switch field.tag {
+		case "25":
+			account = strings.TrimSpace(field.value)
+		case "61":
+			tx := mt940Transaction(field)
+			if i+1 < len(fields) && fields[i+1].tag == "86" {
+				applyMT940Information(&tx, fields[i+1].value)
+			}
+			switch {
+			case tx.ExternalID != "":
+				tx.ExternalID = "MT940:" + account + ":" + tx.ExternalID
+			case tx.Error == "":
+				// Without references, identify the line by its content and position among equal lines
+				key := fmt.Sprintf("%s|%.2f|%s", tx.Date.Format("2006-01-02"), tx.Amount, tx.Description)
+				occurrences[key]++
+				sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+				tx.ExternalID = "MT940:" + account + ":" + hex.EncodeToString(sum[:10])
+			}
+			if tx.Error == "" && tx.Description == "" {
+				tx.Error = "statement line has no description"
+			}
+			transactions = append(transactions, tx)
+		}
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("MT940 file contains no statement lines")
+	}
+
+	return transactions, nil
+}
+
+// readMT940Fields splits an MT940 file into tagged fields, joining continuation lines
+func readMT940Fields(data []byte) ([]mt940Field, error) {
+	var fields []mt940Field
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		// Strip SWIFT envelope blocks such as {1:...}{2:...}{4: and the closing -}
+		if strings.HasPrefix(text, "{") {
+			if i := strings.LastIndex(text, "{4:"); i >= 0 {
+				text = text[i+3:]
+			} else {
+				continue
+			}
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "-") {
+			continue
+		}
+
+		if match := mt940Tag.FindStringSubmatch(text); match != nil {
+			fields = append(fields, mt940Field{
+				tag:   match[1],
+				value: text[len(match[0]):],
+				line:  line,
+			})
+			continue
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+		last := &fields[len(fields)-1]
+		last.value += "\n" + text
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid MT940 file: %v", err)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid MT940 file: no fields found")
+	}
+
+	return fields, nil
+}
+
+func mt940Transaction(field mt940Field) Transaction {
+	tx := Transaction{Line: field.line}
+
+	firstLine := field.value
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	match := mt940Line.FindStringSubmatch(strings.TrimSpace(firstLine))
+	if match == nil {
+		tx.Error = fmt.Sprintf("invalid statement line %q", firstLine)
+		return tx
+	}
+
+	valueDate, err := time.Parse("060102", match[1])
+	if err != nil {
+		tx.Error = fmt.Sprintf("invalid value date %q", match[1])
+		return tx
+	}
+	tx.ValueDate = valueDate
+	tx.Date = valueDate
+
+	if match[2] != "" {
+		bookingDate, err := time.Parse("0102", match[2])
+		if err != nil {
+			tx.Error = fmt.Sprintf("invalid booking date %q", match[2])
+			return tx
+		}
+		// The booking date has no year; it can fall in the year before or after the value date
+		year := valueDate.Year()
+		switch {
+		case bookingDate.Month() == time.December && valueDate.Month() == time.January:
+			year--
+		case bookingDate.Month() == time.January && valueDate.Month() == time.December:
+			year++
+		}
+		tx.Date = time.Date(year, bookingDate.Month(), bookingDate.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	amount, err := ParseAmount(match[5], ",", "")
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+
+	// C/D are credits/debits, RC/RD reverse them
+	switch match[3] {
+	case "D", "RC":
+		amount = -amount
+	}
+	tx.Amount = amount
+
+	customerRef := strings.TrimSpace(match[7])
+	bankRef := strings.TrimSpace(match[8])
+	switch {
+	case bankRef != "":
+		tx.ExternalID = bankRef
+	case customerRef != "" && customerRef != "NONREF":
+		tx.ExternalID = customerRef
+	}
+
+	return tx
+}
+
+// applyMT940Information reads the :86: field, which is either free text or
+// structured with ?NN subfields (?20-?29 purpose, ?32-?33 counterparty name)
+func applyMT940Information(tx *Transaction, value string) {
+	value = strings.ReplaceAll(value, "\n", "")
+
+	if !strings.Contains(value, "?") {
+		tx.Description = strings.TrimSpace(value)
+		return
+	}
+
+	var purpose, name []string
+	for _, part := range strings.Split(value, "?")[1:] {
+		if len(part) < 2 {
+			continue
+		}
+		// Subfields are split at fixed widths, so keep inner spacing when joining
+		code, text := part[:2], part[2:]
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose = append(purpose, strings.TrimSpace(text))
+		case code == "32" || code == "33":
+			name = append(name, text)
+		}
+	}
+
+	tx.Payee = strings.TrimSpace(strings.Join(name, ""))
+	tx.Description = firstNonEmpty(tx.Payee, strings.Join(purpose, " "))
+}
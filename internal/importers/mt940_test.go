@@ -0,0 +1,128 @@
+package importers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMT940 = "{1:F01BANKBEBBAXXX0000000000}{2:O9400000000000BANKBEBBXXXX00000000000000000000N}{4:\r\n" +
+	":20:STMT1\r\n" +
+	":25:DE89370400440532013000\r\n" +
+	":28C:1/1\r\n" +
+	":60F:C260101EUR1000,00\r\n" +
+	":61:2601050105D12,50NTRFNONREF//BANKREF1\r\n" +
+	":86:?20Coffee?32Cafe ?33Central\r\n" +
+	":61:2512310102C100,00NTRFCUSTREF\r\n" +
+	":86:?20Salary\r\n" +
+	"?21January\r\n" +
+	":61:2601080108RD5,00NTRFNONREF//REV1\r\n" +
+	":86:Reversed card payment\r\n" +
+	":61:2601070107D3,00NMSCNONREF\r\n" +
+	":86:Parking\r\n" +
+	":61:2601070107D3,00NMSCNONREF\r\n" +
+	":86:Parking\r\n" +
+	":61:260109C1,00NMSCNONREF\r\n" +
+	":61:garbage\r\n" +
+	":62F:C260109EUR1089,50\r\n" +
+	"-}\r\n"
+
+func TestParseMT940(t *testing.T) {
+	account := "MT940:DE89370400440532013000:"
+	want := []Transaction{
+		{
+			Line:        6,
+			Date:        time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			Description: "Cafe Central",
+			Payee:       "Cafe Central",
+			Amount:      -12.5,
+			ExternalID:  account + "BANKREF1",
+		},
+		{
+			// The booking date falls in the year after the value date
+			Line:        8,
+			Date:        time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			Description: "Salary January",
+			Amount:      100,
+			ExternalID:  account + "CUSTREF",
+		},
+		{
+			Line:        11,
+			Date:        time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC),
+			Description: "Reversed card payment",
+			Amount:      5,
+			ExternalID:  account + "REV1",
+		},
+		{
+			Line:        13,
+			Date:        time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+			Description: "Parking",
+			Amount:      -3,
+		},
+		{
+			Line:        15,
+			Date:        time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+			ValueDate:   time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+			Description: "Parking",
+			Amount:      -3,
+		},
+		{
+			Line:      17,
+			Date:      time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC),
+			ValueDate: time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC),
+			Amount:    1,
+			Error:     "statement line has no description",
+		},
+		{
+			Line:  18,
+			Error: `invalid statement line "garbage"`,
+		},
+	}
+
+	got, err := ParseMT940([]byte(testMT940))
+	if err != nil {
+		t.Fatalf("ParseMT940: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(got), len(want), got)
+	}
+
+	// Lines without references are identified by a hash of their content
+	hashed := map[string]bool{}
+	for i := range want {
+		if want[i].ExternalID == "" && got[i].ExternalID != "" {
+			if !strings.HasPrefix(got[i].ExternalID, account) || hashed[got[i].ExternalID] {
+				t.Errorf("transaction %d has external ID %q, want a new hash of its content", i, got[i].ExternalID)
+			}
+			hashed[got[i].ExternalID] = true
+			got[i].ExternalID = ""
+		}
+		if got[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if len(hashed) != 3 {
+		t.Errorf("%d lines got a content hash, want the 3 decoded lines without references", len(hashed))
+	}
+}
+
+func TestParseMT940RejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"no fields", "Date,Description,Amount\n"},
+		{"no statement lines", ":20:STMT1\n:25:DE89370400440532013000\n:60F:C260101EUR1000,00\n"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseMT940([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseMT940 succeeded, want an error", tt.name)
+		}
+	}
+}
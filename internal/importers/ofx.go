@@ -0,0 +1,159 @@
+package importers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseOFX decodes the statement transactions of an OFX or QFX file.
+// Both the SGML (OFX 1.x, leaf elements without closing tags) and XML (OFX 2.x)
+// variants are supported. Each transaction's FITID is used as its external ID,
+// scoped to the account it belongs to.
+func ParseOFX(data []byte) ([]Transaction, error) {
+	start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+	if start < 0 {
+		return nil, fmt.Errorf("invalid OFX file: missing <OFX> element")
+	}
+
+	var (
+		transactions []Transaction
+		current      map[string]string
+		account      string
+		inAccount    bool
+		openTag      string
+		line         = 1 + bytes.Count(data[:start], []byte("\n"))
+		txLine       int
+	)
+
+	body := string(data[start:])
+	for len(body) > 0 {
+		lt := strings.IndexByte(body, '<')
+		if lt < 0 {
+			break
+		}
+		text := body[:lt]
+		line += strings.Count(text, "\n")
+		if openTag != "" {
+			setOFXValue(openTag, strings.TrimSpace(text), current, &account, inAccount)
+		}
+
+		gt := strings.IndexByte(body[lt:], '>')
+		if gt < 0 {
+			return nil, fmt.Errorf("invalid OFX file: unterminated element on line %d", line)
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[lt+1 : lt+gt]))
+		body = body[lt+gt+1:]
+		openTag = ""
+
+		switch {
+		case tag == "STMTTRN":
+			current = map[string]string{}
+			txLine = line
+		case tag == "/STMTTRN":
+			if current != nil {
+				transactions = append(transactions, ofxTransaction(current, account, txLine))
+			}
+			current = nil
+		case tag == "BANKACCTFROM" || tag == "CCACCTFROM":
+			inAccount = true
+		case tag == "/BANKACCTFROM" || tag == "/CCACCTFROM":
+			inAccount = false
+		case strings.HasPrefix(tag, "/") || strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!"):
+		default:
+			openTag = tag
+		}
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("OFX file contains no transactions")
+	}
+
+	return transactions, nil
+}
+
+func setOFXValue(tag, value string, current map[string]string, account *string, inAccount bool) {
+	if value == "" {
+		return
+	}
+	if inAccount && tag == "ACCTID" {
+		*account = value
+		return
+	}
+	if current != nil {
+		if _, exists := current[tag]; !exists {
+			current[tag] = value
+		}
+	}
+}
+
+func ofxTransaction(fields map[string]string, account string, line int) Transaction {
+	tx := Transaction{
+		Line:  line,
+		Payee: decodeOFXText(fields["NAME"]),
+	}
+
+	memo := decodeOFXText(fields["MEMO"])
+	tx.Description = tx.Payee
+	if tx.Description == "" {
+		tx.Description = memo
+	}
+
+	if fitID := fields["FITID"]; fitID != "" {
+		tx.ExternalID = "OFX:" + fitID
+		if account != "" {
+			tx.ExternalID = "OFX:" + account + ":" + fitID
+		}
+	}
+
+	rawDate := fields["DTPOSTED"]
+	if rawDate == "" {
+		rawDate = fields["DTUSER"]
+	}
+	date, err := parseOFXDate(rawDate)
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.Date = date
+
+	amount, err := ParseAmount(ofxDecimal(fields["TRNAMT"]), ".", "")
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.Amount = amount
+
+	if tx.Description == "" {
+		tx.Description = fields["TRNTYPE"]
+	}
+
+	return tx
+}
+
+// parseOFXDate reads the date part of an OFX datetime (YYYYMMDD[HHMMSS[.XXX]][[-5:EST]])
+func parseOFXDate(raw string) (time.Time, error) {
+	if len(raw) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+	date, err := time.Parse("20060102", raw[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+	return date, nil
+}
+
+// ofxDecimal normalizes amounts written with a decimal comma, which some banks emit
+func ofxDecimal(raw string) string {
+	if strings.Contains(raw, ",") && !strings.Contains(raw, ".") {
+		return strings.Replace(raw, ",", ".", 1)
+	}
+	return raw
+}
+
+var ofxEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ")
+
+func decodeOFXText(value string) string {
+	return strings.TrimSpace(ofxEntities.Replace(value))
+}
@@ -0,0 +1,127 @@
+package importers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKACCTFROM>
+<BANKID>999
+<ACCTID>12345
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260105120000.000[-5:EST]
+<TRNAMT>-12.50
+<FITID>A1
+<NAME>Tom &amp; Jerry's
+<MEMO>Lunch
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTUSER>20260131
+<TRNAMT>2500,00
+<FITID>A2
+<MEMO>Salary
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>FEE
+<DTPOSTED>20260201
+<TRNAMT>-1.00
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+    <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>20260110</DTPOSTED>
+        <TRNAMT>-40.00</TRNAMT>
+        <FITID>X9</FITID>
+        <NAME>Books &lt;and&gt; more</NAME>
+      </STMTTRN>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>2026-01-11</DTPOSTED>
+        <TRNAMT>-5</TRNAMT>
+        <FITID>X10</FITID>
+        <NAME>Parking</NAME>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+	tests := []struct {
+		name string
+		data string
+		want []Transaction
+	}{
+		{
+			name: "SGML",
+			data: sgml,
+			want: []Transaction{
+				{Line: 12, Date: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Description: "Tom & Jerry's", Payee: "Tom & Jerry's", Amount: -12.5, ExternalID: "OFX:12345:A1"},
+				{Line: 20, Date: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Description: "Salary", Amount: 2500, ExternalID: "OFX:12345:A2"},
+				{Line: 27, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Description: "FEE", Amount: -1},
+			},
+		},
+		{
+			name: "XML",
+			data: xml,
+			want: []Transaction{
+				{Line: 7, Date: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), Description: "Books <and> more", Payee: "Books <and> more", Amount: -40, ExternalID: "OFX:4111:X9"},
+				{Line: 14, Description: "Parking", Payee: "Parking", ExternalID: "OFX:4111:X10", Error: `invalid date "2026-01-11"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOFX([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseOFX: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("transaction %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOFXRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not OFX", "Date,Description,Amount\n"},
+		{"no transactions", "<OFX><BANKTRANLIST></BANKTRANLIST></OFX>"},
+		{"unterminated element", "<OFX><STMTTRN><TRNAMT"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseOFX([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseOFX succeeded, want an error", tt.name)
+		}
+	}
+}
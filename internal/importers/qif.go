@@ -0,0 +1,175 @@
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultQIFDateFormat is used when no date format is given for a QIF file
+const DefaultQIFDateFormat = "MM/DD/YYYY"
+
+// ParseQIF decodes the bank, cash and credit card transactions of a QIF file.
+// QIF has no transaction IDs, so the external ID is a hash of the transaction's
+// fields plus its occurrence count, which is stable when the same file is imported again.
+func ParseQIF(data []byte, dateFormat, decimalSeparator string) ([]Transaction, error) {
+	if dateFormat == "" {
+		dateFormat = DefaultQIFDateFormat
+	}
+	layout, err := DateLayout(dateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		transactions []Transaction
+		fields       = map[byte]string{}
+		startLine    int
+		skipSection  bool
+		occurrences  = map[string]int{}
+	)
+
+	flush := func() {
+		if len(fields) == 0 {
+			return
+		}
+		if !skipSection {
+			tx := qifTransaction(fields, startLine, layout, decimalSeparator)
+			key := fields['D'] + "|" + fields['T'] + "|" + fields['P'] + "|" + fields['M']
+			occurrences[key]++
+			sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+			tx.ExternalID = "QIF:" + hex.EncodeToString(sum[:10])
+			transactions = append(transactions, tx)
+		}
+		fields = map[byte]string{}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if text[0] == '!' {
+			flush()
+			header := strings.ToLower(strings.TrimSpace(text))
+			// Only account register sections hold transactions
+			skipSection = !strings.HasPrefix(header, "!type:bank") &&
+				!strings.HasPrefix(header, "!type:cash") &&
+				!strings.HasPrefix(header, "!type:ccard") &&
+				!strings.HasPrefix(header, "!type:oth")
+			continue
+		}
+
+		if text[0] == '^' {
+			flush()
+			continue
+		}
+
+		if len(fields) == 0 {
+			startLine = line
+		}
+		code := text[0]
+		// Split lines (S, E, $) describe categories of a split; keep the first value only
+		if _, exists := fields[code]; !exists {
+			fields[code] = strings.TrimSpace(text[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid QIF file: %v", err)
+	}
+	flush()
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("QIF file contains no transactions")
+	}
+
+	return transactions, nil
+}
+
+func qifTransaction(fields map[byte]string, line int, layout, decimalSeparator string) Transaction {
+	tx := Transaction{
+		Line:     line,
+		Payee:    fields['P'],
+		Category: qifCategory(fields['L']),
+	}
+
+	tx.Description = tx.Payee
+	if tx.Description == "" {
+		tx.Description = fields['M']
+	}
+
+	date, err := parseQIFDate(fields['D'], layout)
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.Date = date
+
+	rawAmount := fields['T']
+	if rawAmount == "" {
+		rawAmount = fields['U']
+	}
+	thousandsSeparator := ","
+	if decimalSeparator == "," {
+		thousandsSeparator = "."
+	}
+	amount, err := ParseAmount(rawAmount, decimalSeparator, thousandsSeparator)
+	if err != nil {
+		tx.Error = err.Error()
+		return tx
+	}
+	tx.Amount = amount
+
+	if tx.Description == "" {
+		tx.Error = "transaction has no payee or memo"
+	}
+
+	return tx
+}
+
+// parseQIFDate parses QIF dates, which use an apostrophe before two-digit years from 2000 on (e.g. 1/31'26)
+func parseQIFDate(raw, layout string) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	normalized := strings.ReplaceAll(value, " ", "")
+	if i := strings.Index(normalized, "'"); i >= 0 {
+		year := normalized[i+1:]
+		if len(year) == 2 {
+			year = "20" + year
+		}
+		normalized = normalized[:i] + "/" + year
+	}
+
+	for _, candidate := range []string{value, normalized} {
+		if date, err := time.Parse(layout, candidate); err == nil {
+			return date, nil
+		}
+	}
+
+	// Quicken drops leading zeros, e.g. 1/5/2026 for 01/05/2026
+	loose := strings.NewReplacer("01", "1", "02", "2").Replace(layout)
+	if date, err := time.Parse(loose, normalized); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
+
+// qifCategory drops transfer markers ([Account]) and subcategories' class suffix (/Class)
+func qifCategory(raw string) string {
+	category := strings.TrimSpace(raw)
+	if strings.HasPrefix(category, "[") {
+		return ""
+	}
+	if i := strings.Index(category, "/"); i >= 0 {
+		category = category[:i]
+	}
+	return category
+}
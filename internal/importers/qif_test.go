@@ -0,0 +1,116 @@
+package importers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQIF(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		dateFormat       string
+		decimalSeparator string
+		want             []Transaction
+	}{
+		{
+			name: "bank register",
+			data: "!Type:Bank\r\n" +
+				"D01/05/2026\r\nT-12.50\r\nPCoffee\r\nLFood:Drinks/Work\r\n^\r\n" +
+				"D1/31'26\r\nT1,250.00\r\nMSalary\r\n^\r\n" +
+				"D2/1/2026\r\nU-300.00\r\nPTransfer to savings\r\nL[Savings]\r\n^\r\n",
+			want: []Transaction{
+				{Line: 2, Date: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Description: "Coffee", Payee: "Coffee", Amount: -12.5, Category: "Food:Drinks"},
+				{Line: 7, Date: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Description: "Salary", Amount: 1250},
+				{Line: 11, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Description: "Transfer to savings", Payee: "Transfer to savings", Amount: -300},
+			},
+		},
+		{
+			name:             "day first with decimal comma",
+			data:             "!Type:CCard\nD31.01.2026\nT-1.234,50\nPFurniture\n^\n",
+			dateFormat:       "DD.MM.YYYY",
+			decimalSeparator: ",",
+			want: []Transaction{
+				{Line: 2, Date: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Description: "Furniture", Payee: "Furniture", Amount: -1234.5},
+			},
+		},
+		{
+			name: "other sections are skipped",
+			data: "!Type:Cat\nNFood\n^\n!Type:Memorized\nPRent\nT-900\n^\n!Type:Cash\nD01/02/2026\nT-3\nPBus\n^\n",
+			want: []Transaction{
+				{Line: 9, Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Description: "Bus", Payee: "Bus", Amount: -3},
+			},
+		},
+		{
+			name: "invalid transactions keep an error",
+			data: "!Type:Bank\nD13/45/2026\nT-1\nPBad date\n^\nD01/02/2026\nT-1\n^\n",
+			want: []Transaction{
+				{Line: 2, Payee: "Bad date", Description: "Bad date", Error: `invalid date "13/45/2026"`},
+				{Line: 6, Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Amount: -1, Error: "transaction has no payee or memo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQIF([]byte(tt.data), tt.dateFormat, tt.decimalSeparator)
+			if err != nil {
+				t.Fatalf("ParseQIF: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i].ExternalID == "" {
+					t.Errorf("transaction %d has no external ID", i)
+				}
+				got[i].ExternalID = ""
+				if got[i] != tt.want[i] {
+					t.Errorf("transaction %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseQIFExternalIDs(t *testing.T) {
+	// The same purchase twice on one day is two transactions, and importing the
+	// file again yields the same IDs
+	data := []byte("!Type:Bank\nD01/05/2026\nT-3.00\nPCoffee\n^\nD01/05/2026\nT-3.00\nPCoffee\n^\n")
+
+	first, err := ParseQIF(data, "", "")
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+	again, err := ParseQIF(data, "", "")
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+
+	if first[0].ExternalID == first[1].ExternalID {
+		t.Errorf("identical transactions share the external ID %q", first[0].ExternalID)
+	}
+	for i := range first {
+		if first[i].ExternalID != again[i].ExternalID {
+			t.Errorf("transaction %d has ID %q, then %q", i, first[i].ExternalID, again[i].ExternalID)
+		}
+	}
+}
+
+func TestParseQIFRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		dateFormat string
+	}{
+		{"no transactions", "!Type:Bank\n", ""},
+		{"only categories", "!Type:Cat\nNFood\n^\n", ""},
+		{"invalid date format", "!Type:Bank\nD01/05/2026\nT-1\nPA\n^\n", "MM/YYYY"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseQIF([]byte(tt.data), tt.dateFormat, ""); err == nil {
+			t.Errorf("%s: ParseQIF succeeded, want an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn once immediately and then at every interval until ctx is cancelled.
+// Errors are logged and don't stop the job.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	EveryOrWake(ctx, name, interval, nil, fn)
+}
+
+// EveryOrWake is like Every, but also runs fn early whenever wake is signalled
+func EveryOrWake(ctx context.Context, name string, interval time.Duration, wake <-chan struct{}, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		log.Printf("Job %s disabled", name)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fn(ctx); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
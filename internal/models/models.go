@@ -16,10 +16,34 @@ type User struct {
 
 // Expense represents a single expense
 type Expense struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title     string             `bson:"title" json:"title"`
-	Amount    float64            `bson:"amount" json:"amount"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Title            string              `bson:"title" json:"title"`
+	Amount           float64             `bson:"amount" json:"amount"`
+	Category         string              `bson:"category,omitempty" json:"category,omitempty"`
+	Tags             []string            `bson:"tags,omitempty" json:"tags,omitempty"`
+	Merchant         string              `bson:"merchant,omitempty" json:"merchant,omitempty"`
+	PaymentMethod    string              `bson:"paymentMethod,omitempty" json:"paymentMethod,omitempty"`
+	Date             *time.Time          `bson:"date,omitempty" json:"date,omitempty"`
+	AccountID        *primitive.ObjectID `bson:"accountId,omitempty" json:"accountId,omitempty"`
+	Cleared          bool                `bson:"cleared,omitempty" json:"cleared,omitempty"`
+	ReconciliationID *primitive.ObjectID `bson:"reconciliationId,omitempty" json:"reconciliationId,omitempty"`
+	ImportID         *primitive.ObjectID `bson:"importId,omitempty" json:"importId,omitempty"`
+	ExternalID       string              `bson:"externalId,omitempty" json:"externalId,omitempty"`
+	CreatedAt        time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// Income represents money received on top of the base income, e.g. from a bank statement
+type Income struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Title            string              `bson:"title" json:"title"`
+	Amount           float64             `bson:"amount" json:"amount"`
+	Date             *time.Time          `bson:"date,omitempty" json:"date,omitempty"`
+	AccountID        *primitive.ObjectID `bson:"accountId,omitempty" json:"accountId,omitempty"`
+	Cleared          bool                `bson:"cleared,omitempty" json:"cleared,omitempty"`
+	ReconciliationID *primitive.ObjectID `bson:"reconciliationId,omitempty" json:"reconciliationId,omitempty"`
+	ImportID         *primitive.ObjectID `bson:"importId,omitempty" json:"importId,omitempty"`
+	ExternalID       string              `bson:"externalId,omitempty" json:"externalId,omitempty"`
+	CreatedAt        time.Time           `bson:"createdAt" json:"createdAt"`
 }
 
 // MonthlyBudget represents a user's budget for a specific month
@@ -29,6 +53,7 @@ type MonthlyBudget struct {
 	Year       int                `bson:"year" json:"year"`
 	Month      int                `bson:"month" json:"month"`
 	BaseIncome *float64           `bson:"baseIncome" json:"baseIncome"`
+	Incomes    []Income           `bson:"incomes,omitempty" json:"incomes"`
 	Expenses   []Expense          `bson:"expenses" json:"expenses"`
 	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
@@ -39,10 +64,48 @@ type BudgetResponse struct {
 	Year       int       `json:"year"`
 	Month      int       `json:"month"`
 	BaseIncome *float64  `json:"baseIncome"`
+	Incomes    []Income  `json:"incomes"`
 	Expenses   []Expense `json:"expenses"`
 	Remaining  *float64  `json:"remaining"`
 }
 
+// CategoryTotal is the amount spent in a single category
+type CategoryTotal struct {
+	Category string  `bson:"category" json:"category"`
+	Total    float64 `bson:"total" json:"total"`
+	Count    int     `bson:"count" json:"count"`
+}
+
+// MonthSummary summarizes a single month of a budget summary
+type MonthSummary struct {
+	Year        int             `json:"year"`
+	Month       int             `json:"month"`
+	Income      *float64        `json:"income"`
+	Expenses    float64         `json:"expenses"`
+	Remaining   *float64        `json:"remaining"`
+	SavingsRate *float64        `json:"savingsRate"`
+	Categories  []CategoryTotal `json:"categories"`
+}
+
+// BudgetSummaryResponse is the response format for the budget summary endpoint
+type BudgetSummaryResponse struct {
+	FromYear  int            `json:"fromYear"`
+	FromMonth int            `json:"fromMonth"`
+	ToYear    int            `json:"toYear"`
+	ToMonth   int            `json:"toMonth"`
+	Months    []MonthSummary `json:"months"`
+	Totals    SummaryTotals  `json:"totals"`
+}
+
+// SummaryTotals aggregates all months of a budget summary
+type SummaryTotals struct {
+	Income      float64         `json:"income"`
+	Expenses    float64         `json:"expenses"`
+	Remaining   float64         `json:"remaining"`
+	SavingsRate *float64        `json:"savingsRate"`
+	Categories  []CategoryTotal `json:"categories"`
+}
+
 // AuthRequest is the request format for auth endpoints
 type AuthRequest struct {
 	Email    string `json:"email"`
@@ -61,12 +124,33 @@ type BaseIncomeRequest struct {
 	Month  int     `json:"month"`
 }
 
-// ExpenseRequest is the request format for expense endpoints
+// ExpenseRequest is the request format for expense endpoints. On update, a category,
+// tags, merchant, payment method or account left out keeps its value; an empty
+// accountId unlinks the expense from its account.
 type ExpenseRequest struct {
-	Title  string  `json:"title"`
-	Amount float64 `json:"amount"`
-	Year   int     `json:"year"`
-	Month  int     `json:"month"`
+	Title         string   `json:"title"`
+	Amount        float64  `json:"amount"`
+	Category      *string  `json:"category,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Merchant      *string  `json:"merchant,omitempty"`
+	PaymentMethod *string  `json:"paymentMethod,omitempty"`
+	AccountID     *string  `json:"accountId,omitempty"`
+	Year          int      `json:"year"`
+	Month         int      `json:"month"`
+}
+
+// ExpenseUpdate changes an expense's title and amount. Its other fields are only
+// changed when given: nil leaves them as they are.
+type ExpenseUpdate struct {
+	Title         string
+	Amount        float64
+	Category      *string
+	Tags          []string
+	Merchant      *string
+	PaymentMethod *string
+	// SetAccount links the expense to AccountID, or unlinks it when AccountID is nil
+	SetAccount bool
+	AccountID  *primitive.ObjectID
 }
 
 // JWTClaims represents JWT claims
@@ -82,57 +166,1053 @@ const (
 	FundTypeGiven    FundType = "GIVEN"
 )
 
+// FundStatus represents where a fund stands. DISPUTED and WRITTEN_OFF are set by the
+// user, the others are derived from its balance and due dates.
+type FundStatus string
+
+const (
+	FundStatusOpen       FundStatus = "OPEN"
+	FundStatusPaid       FundStatus = "PAID"
+	FundStatusOverdue    FundStatus = "OVERDUE"
+	FundStatusDisputed   FundStatus = "DISPUTED"
+	FundStatusWrittenOff FundStatus = "WRITTEN_OFF"
+)
+
+// FundStatusRequest sets or clears (OPEN) a fund's user-set status
+type FundStatusRequest struct {
+	Status FundStatus `json:"status"`
+}
+
+// LateFeeFrequency represents how often a late fee is charged while a fund is behind
+type LateFeeFrequency string
+
+const (
+	LateFeeOnce    LateFeeFrequency = "ONCE"
+	LateFeeMonthly LateFeeFrequency = "MONTHLY"
+)
+
+// LateFeeRule charges a flat amount plus a percentage of the overdue amount when a
+// fund or instalment is still unpaid GraceDays after its due date
+type LateFeeRule struct {
+	Flat      float64          `bson:"flat,omitempty" json:"flat,omitempty"`
+	Percent   float64          `bson:"percent,omitempty" json:"percent,omitempty"`
+	GraceDays int              `bson:"graceDays" json:"graceDays"`
+	Frequency LateFeeFrequency `bson:"frequency" json:"frequency"`
+}
+
+// InterestType represents how interest accrues on a fund
+type InterestType string
+
+const (
+	InterestTypeNone     InterestType = "NONE"
+	InterestTypeSimple   InterestType = "SIMPLE"
+	InterestTypeCompound InterestType = "COMPOUND"
+)
+
+// CompoundingPeriod represents how often compound interest is added to the balance
+type CompoundingPeriod string
+
+const (
+	CompoundingDaily     CompoundingPeriod = "DAILY"
+	CompoundingMonthly   CompoundingPeriod = "MONTHLY"
+	CompoundingQuarterly CompoundingPeriod = "QUARTERLY"
+	CompoundingAnnually  CompoundingPeriod = "ANNUALLY"
+)
+
+// DayCountConvention represents how the fraction of a year between two dates is counted
+type DayCountConvention string
+
+const (
+	DayCountActual365 DayCountConvention = "ACT_365"
+	DayCountActual360 DayCountConvention = "ACT_360"
+	DayCount30360     DayCountConvention = "30_360"
+)
+
 // Fund represents a borrowing or lending agreement
 type Fund struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID         primitive.ObjectID `bson:"userId" json:"userId"`
-	PersonName     string             `bson:"personName" json:"personName"`
-	Type           FundType           `bson:"type" json:"type"`
-	PrincipalAmount float64           `bson:"principalAmount" json:"principalAmount"`
-	StartDate      time.Time          `bson:"startDate" json:"startDate"`
-	Notes          string             `bson:"notes,omitempty" json:"notes,omitempty"`
-	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID `bson:"userId" json:"userId"`
+	ContactID         primitive.ObjectID `bson:"contactId,omitempty" json:"contactId,omitempty"`
+	PersonName        string             `bson:"personName" json:"personName"`
+	Type              FundType           `bson:"type" json:"type"`
+	PrincipalAmount   float64            `bson:"principalAmount" json:"principalAmount"`
+	StartDate         time.Time          `bson:"startDate" json:"startDate"`
+	Notes             string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	InterestRate      float64            `bson:"interestRate,omitempty" json:"interestRate,omitempty"`
+	InterestType      InterestType       `bson:"interestType,omitempty" json:"interestType,omitempty"`
+	CompoundingPeriod CompoundingPeriod  `bson:"compoundingPeriod,omitempty" json:"compoundingPeriod,omitempty"`
+	DayCount          DayCountConvention `bson:"dayCount,omitempty" json:"dayCount,omitempty"`
+	RepaymentPlan     *RepaymentPlan     `bson:"repaymentPlan,omitempty" json:"repaymentPlan,omitempty"`
+	DueDate           *time.Time         `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
+	LateFee           *LateFeeRule       `bson:"lateFee,omitempty" json:"lateFee,omitempty"`
+	MarkedStatus      FundStatus         `bson:"markedStatus,omitempty" json:"markedStatus,omitempty"`
+	LockedUntil       *time.Time         `bson:"lockedUntil,omitempty" json:"-"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
 
-// Transaction represents a partial payment for a fund
+// TransactionType represents what a fund transaction does to the fund's balance
+type TransactionType string
+
+const (
+	// TransactionRepayment pays the fund back. Transactions stored without a type are repayments.
+	TransactionRepayment TransactionType = "REPAYMENT"
+	// TransactionDisbursement lends or borrows more principal
+	TransactionDisbursement TransactionType = "DISBURSEMENT"
+	// TransactionFee charges a fee, which is paid back before interest and principal
+	TransactionFee TransactionType = "FEE"
+	// TransactionInterest charges interest on top of any the fund's terms accrue
+	TransactionInterest TransactionType = "INTEREST"
+	// TransactionAdjustment corrects the principal up or, with a negative amount, down
+	TransactionAdjustment TransactionType = "ADJUSTMENT"
+	// TransactionWriteOff gives up on part or all of what is owed, like a repayment
+	// that isn't paid. It is created and reversed through the write-off endpoints.
+	TransactionWriteOff TransactionType = "WRITE_OFF"
+)
+
+// Transaction represents an entry in a fund's ledger, a repayment unless its type
+// says otherwise. The fee, interest and principal portions of repayments are computed
+// from the fund's terms and aren't stored.
 type Transaction struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	FundID           primitive.ObjectID  `bson:"fundId" json:"fundId"`
+	Type             TransactionType     `bson:"type,omitempty" json:"type"`
+	Amount           float64             `bson:"amount" json:"amount"`
+	Date             time.Time           `bson:"date" json:"date"`
+	Note             string              `bson:"note,omitempty" json:"note,omitempty"`
+	Reason           string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	ExpenseID        *primitive.ObjectID `bson:"expenseId,omitempty" json:"expenseId,omitempty"`
+	ReversedAt       *time.Time          `bson:"reversedAt,omitempty" json:"reversedAt,omitempty"`
+	SettlementID     *primitive.ObjectID `bson:"settlementId,omitempty" json:"settlementId,omitempty"`
+	FeePortion       float64             `bson:"-" json:"feePortion"`
+	InterestPortion  float64             `bson:"-" json:"interestPortion"`
+	PrincipalPortion float64             `bson:"-" json:"principalPortion"`
+	CreatedAt        time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// FundRequest is the request format for fund endpoints. InterestRate is an annual
+// percentage. A fund is linked to ContactID, or else to the contact named PersonName,
+// which is created when the user has none by that name.
+type FundRequest struct {
+	ContactID         string             `json:"contactId,omitempty"`
+	PersonName        string             `json:"personName"`
+	Type              FundType           `json:"type"`
+	PrincipalAmount   float64            `json:"principalAmount"`
+	StartDate         time.Time          `json:"startDate"`
+	Notes             string             `json:"notes,omitempty"`
+	InterestRate      float64            `json:"interestRate,omitempty"`
+	InterestType      InterestType       `json:"interestType,omitempty"`
+	CompoundingPeriod CompoundingPeriod  `json:"compoundingPeriod,omitempty"`
+	DayCount          DayCountConvention `json:"dayCount,omitempty"`
+	DueDate           *time.Time         `json:"dueDate,omitempty"`
+	LateFee           *LateFeeRule       `json:"lateFee,omitempty"`
+}
+
+// TransactionRequest is the request format for transaction endpoints. Type defaults
+// to REPAYMENT.
+type TransactionRequest struct {
+	Type   TransactionType `json:"type,omitempty"`
+	Amount float64         `json:"amount"`
+	Date   time.Time       `json:"date"`
+	Note   string          `json:"note,omitempty"`
+}
+
+// WriteOffRequest is the request format for writing off a fund. Without an amount
+// everything outstanding on the date is written off; the date defaults to today.
+// RecordExpense records a written-off GIVEN fund as an expense in the month of the
+// write-off, under Category or "Bad Debt".
+type WriteOffRequest struct {
+	Amount        *float64   `json:"amount,omitempty"`
+	Date          *time.Time `json:"date,omitempty"`
+	Reason        string     `json:"reason"`
+	RecordExpense bool       `json:"recordExpense,omitempty"`
+	Category      string     `json:"category,omitempty"`
+}
+
+// FundResponse is the response format for fund endpoints. Balances are as of AsOf:
+// Outstanding is the unpaid principal plus the accrued, unpaid interest and fees.
+// PrincipalAmount is InitialPrincipal plus Disbursed, and TotalPaid counts repayments.
+type FundResponse struct {
+	ID                   string             `json:"id"`
+	ContactID            string             `json:"contactId,omitempty"`
+	PersonName           string             `json:"personName"`
+	Type                 FundType           `json:"type"`
+	PrincipalAmount      float64            `json:"principalAmount"`
+	InitialPrincipal     float64            `json:"initialPrincipal"`
+	Disbursed            float64            `json:"disbursed"`
+	StartDate            time.Time          `json:"startDate"`
+	Notes                string             `json:"notes,omitempty"`
+	InterestRate         float64            `json:"interestRate,omitempty"`
+	InterestType         InterestType       `json:"interestType,omitempty"`
+	CompoundingPeriod    CompoundingPeriod  `json:"compoundingPeriod,omitempty"`
+	DayCount             DayCountConvention `json:"dayCount,omitempty"`
+	AsOf                 time.Time          `json:"asOf"`
+	TotalPaid            float64            `json:"totalPaid"`
+	PrincipalPaid        float64            `json:"principalPaid"`
+	InterestPaid         float64            `json:"interestPaid"`
+	PrincipalOutstanding float64            `json:"principalOutstanding"`
+	AccruedInterest      float64            `json:"accruedInterest"`
+	InterestCharged      float64            `json:"interestCharged"`
+	FeesCharged          float64            `json:"feesCharged"`
+	LateFeesCharged      float64            `json:"lateFeesCharged"`
+	LateFees             float64            `json:"lateFees"`
+	Adjustments          float64            `json:"adjustments"`
+	WrittenOff           float64            `json:"writtenOff"`
+	Outstanding          float64            `json:"outstanding"`
+	DueDate              *time.Time         `json:"dueDate,omitempty"`
+	NextDueDate          *time.Time         `json:"nextDueDate"`
+	DaysLate             int                `json:"daysLate"`
+	Status               FundStatus         `json:"status"`
+	MarkedStatus         FundStatus         `json:"markedStatus,omitempty"`
+	Transactions         []Transaction      `json:"transactions"`
+	CreatedAt            time.Time          `json:"createdAt"`
+	UpdatedAt            time.Time          `json:"updatedAt"`
+}
+
+// RepaymentPlanType represents how a fund's instalments are worked out
+type RepaymentPlanType string
+
+const (
+	RepaymentPlanEqual      RepaymentPlanType = "EQUAL"
+	RepaymentPlanCustom     RepaymentPlanType = "CUSTOM"
+	RepaymentPlanAmortizing RepaymentPlanType = "AMORTIZING"
+)
+
+// RepaymentFrequency represents the time between two instalments
+type RepaymentFrequency string
+
+const (
+	RepaymentWeekly    RepaymentFrequency = "WEEKLY"
+	RepaymentMonthly   RepaymentFrequency = "MONTHLY"
+	RepaymentQuarterly RepaymentFrequency = "QUARTERLY"
+)
+
+// PlannedInstalment is one instalment of a custom repayment plan
+type PlannedInstalment struct {
+	DueDate time.Time `bson:"dueDate" json:"dueDate"`
+	Amount  float64   `bson:"amount" json:"amount"`
+}
+
+// RepaymentPlan is the agreed way of repaying a fund. EQUAL and AMORTIZING plans
+// have Count instalments every Frequency from FirstDueDate; CUSTOM plans list them.
+type RepaymentPlan struct {
+	Type         RepaymentPlanType   `bson:"type" json:"type"`
+	Frequency    RepaymentFrequency  `bson:"frequency,omitempty" json:"frequency,omitempty"`
+	Count        int                 `bson:"count,omitempty" json:"count,omitempty"`
+	FirstDueDate time.Time           `bson:"firstDueDate,omitempty" json:"firstDueDate,omitempty"`
+	Instalments  []PlannedInstalment `bson:"instalments,omitempty" json:"instalments,omitempty"`
+}
+
+// InstalmentStatus represents how far an instalment has been paid
+type InstalmentStatus string
+
+const (
+	InstalmentPaid    InstalmentStatus = "PAID"
+	InstalmentPartial InstalmentStatus = "PARTIAL"
+	InstalmentDue     InstalmentStatus = "DUE"
+	InstalmentOverdue InstalmentStatus = "OVERDUE"
+)
+
+// Instalment is one expected payment of a fund's repayment schedule and the payments
+// matched to it
+type Instalment struct {
+	Number         int              `json:"number"`
+	DueDate        time.Time        `json:"dueDate"`
+	Amount         float64          `json:"amount"`
+	Principal      float64          `json:"principal"`
+	Interest       float64          `json:"interest"`
+	Paid           float64          `json:"paid"`
+	Remaining      float64          `json:"remaining"`
+	Status         InstalmentStatus `json:"status"`
+	PaidOn         *time.Time       `json:"paidOn,omitempty"`
+	TransactionIDs []string         `json:"transactionIds"`
+}
+
+// RepaymentScheduleResponse is a fund's expected schedule as of a day
+type RepaymentScheduleResponse struct {
+	FundID        string        `json:"fundId"`
+	Plan          RepaymentPlan `json:"plan"`
+	AsOf          time.Time     `json:"asOf"`
+	Instalments   []Instalment  `json:"instalments"`
+	TotalDue      float64       `json:"totalDue"`
+	TotalPaid     float64       `json:"totalPaid"`
+	OverdueAmount float64       `json:"overdueAmount"`
+	NextDueDate   *time.Time    `json:"nextDueDate"`
+}
+
+// OverdueFund is a fund that is behind, with how late it is
+type OverdueFund struct {
+	Fund          FundResponse `json:"fund"`
+	OverdueSince  time.Time    `json:"overdueSince"`
+	DaysLate      int          `json:"daysLate"`
+	OverdueAmount float64      `json:"overdueAmount"`
+}
+
+// Contact is a person the user borrows from or lends to. NameKey is the name
+// lowercased with its whitespace collapsed, so "Ali" and "ali " are one contact.
+type Contact struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	FundID    primitive.ObjectID `bson:"fundId" json:"fundId"`
-	Amount    float64            `bson:"amount" json:"amount"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Name      string             `bson:"name" json:"name"`
+	NameKey   string             `bson:"nameKey" json:"-"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	Phone     string             `bson:"phone,omitempty" json:"phone,omitempty"`
+	Notes     string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ContactRequest is the request format for contact endpoints
+type ContactRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// ContactBalance sums the outstanding amounts of a contact's funds. Given is what the
+// contact owes the user, Borrowed what the user owes the contact, and Net is Given
+// minus Borrowed: positive when the contact owes the user overall.
+type ContactBalance struct {
+	Contact   Contact        `json:"contact"`
+	AsOf      time.Time      `json:"asOf"`
+	Given     float64        `json:"given"`
+	Borrowed  float64        `json:"borrowed"`
+	Net       float64        `json:"net"`
+	OpenFunds int            `json:"openFunds"`
+	Funds     []FundResponse `json:"funds,omitempty"`
+}
+
+// Settlement records settling a contact's open funds in one go: every fund is paid
+// off by a transaction and only Net, Given minus Borrowed, changes hands. A positive
+// Net is paid by the contact, a negative one by the user.
+type Settlement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	ContactID primitive.ObjectID `bson:"contactId" json:"contactId"`
 	Date      time.Time          `bson:"date" json:"date"`
+	Given     float64            `bson:"given" json:"given"`
+	Borrowed  float64            `bson:"borrowed" json:"borrowed"`
+	Net       float64            `bson:"net" json:"net"`
 	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	Funds     []SettledFund      `bson:"funds" json:"funds"`
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 }
 
-// FundRequest is the request format for fund endpoints
-type FundRequest struct {
+// SettledFund is a fund paid off by a settlement, with the transaction that did it
+type SettledFund struct {
+	FundID        primitive.ObjectID `bson:"fundId" json:"fundId"`
+	TransactionID primitive.ObjectID `bson:"transactionId" json:"transactionId"`
+	Type          FundType           `bson:"type" json:"type"`
+	Amount        float64            `bson:"amount" json:"amount"`
+}
+
+// SettlementRequest is the request format for settling a contact's funds. Date
+// defaults to today.
+type SettlementRequest struct {
+	Date *time.Time `json:"date,omitempty"`
+	Note string     `json:"note,omitempty"`
+}
+
+// AccountType represents the kind of a bank or wallet account
+type AccountType string
+
+const (
+	AccountTypeChecking   AccountType = "CHECKING"
+	AccountTypeSavings    AccountType = "SAVINGS"
+	AccountTypeCash       AccountType = "CASH"
+	AccountTypeCreditCard AccountType = "CREDIT_CARD"
+)
+
+// Account is a place money is kept or spent from. Balances of credit cards
+// are negative while money is owed on them.
+type Account struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"userId" json:"userId"`
+	Name           string             `bson:"name" json:"name"`
+	Type           AccountType        `bson:"type" json:"type"`
+	OpeningBalance float64            `bson:"openingBalance" json:"openingBalance"`
+	OpeningDate    time.Time          `bson:"openingDate" json:"openingDate"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AccountRequest is the request format for account endpoints
+type AccountRequest struct {
+	Name           string      `json:"name"`
+	Type           AccountType `json:"type"`
+	OpeningBalance float64     `json:"openingBalance"`
+	OpeningDate    time.Time   `json:"openingDate"`
+}
+
+// AccountResponse is the response format for account endpoints
+type AccountResponse struct {
+	Account
+	Balance float64 `json:"balance"`
+}
+
+// Transfer moves money between two of a user's accounts. Transfers are not spending.
+type Transfer struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"userId" json:"userId"`
+	FromAccountID primitive.ObjectID `bson:"fromAccountId" json:"fromAccountId"`
+	ToAccountID   primitive.ObjectID `bson:"toAccountId" json:"toAccountId"`
+	Amount        float64            `bson:"amount" json:"amount"`
+	Date          time.Time          `bson:"date" json:"date"`
+	Note          string             `bson:"note,omitempty" json:"note,omitempty"`
+	// Each side of a transfer is cleared and reconciled on its own account's statement
+	FromCleared          bool                `bson:"fromCleared,omitempty" json:"fromCleared,omitempty"`
+	ToCleared            bool                `bson:"toCleared,omitempty" json:"toCleared,omitempty"`
+	FromReconciliationID *primitive.ObjectID `bson:"fromReconciliationId,omitempty" json:"fromReconciliationId,omitempty"`
+	ToReconciliationID   *primitive.ObjectID `bson:"toReconciliationId,omitempty" json:"toReconciliationId,omitempty"`
+	CreatedAt            time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// TransferRequest is the request format for transfer endpoints
+type TransferRequest struct {
+	FromAccountID string    `json:"fromAccountId"`
+	ToAccountID   string    `json:"toAccountId"`
+	Amount        float64   `json:"amount"`
+	Date          time.Time `json:"date"`
+	Note          string    `json:"note,omitempty"`
+}
+
+// AccountEntryKind tells what moved money in or out of an account
+type AccountEntryKind string
+
+const (
+	AccountEntryExpense     AccountEntryKind = "EXPENSE"
+	AccountEntryIncome      AccountEntryKind = "INCOME"
+	AccountEntryTransferIn  AccountEntryKind = "TRANSFER_IN"
+	AccountEntryTransferOut AccountEntryKind = "TRANSFER_OUT"
+)
+
+// AccountEntry is one movement on an account with the balance after it
+type AccountEntry struct {
+	Kind        AccountEntryKind `json:"kind"`
+	ID          string           `json:"id"`
+	Date        time.Time        `json:"date"`
+	Year        int              `json:"year,omitempty"`
+	Month       int              `json:"month,omitempty"`
+	Description string           `json:"description"`
+	Amount      float64          `json:"amount"`
+	Balance     float64          `json:"balance"`
+	Cleared     bool             `json:"cleared"`
+	Reconciled  bool             `json:"reconciled"`
+}
+
+// AccountLedgerResponse lists an account's movements with running balances
+type AccountLedgerResponse struct {
+	Account        AccountResponse `json:"account"`
+	From           *time.Time      `json:"from,omitempty"`
+	To             *time.Time      `json:"to,omitempty"`
+	OpeningBalance float64         `json:"openingBalance"`
+	ClosingBalance float64         `json:"closingBalance"`
+	Entries        []AccountEntry  `json:"entries"`
+}
+
+// ReconciliationStatus represents the state of a statement reconciliation
+type ReconciliationStatus string
+
+const (
+	ReconciliationInProgress ReconciliationStatus = "IN_PROGRESS"
+	ReconciliationCompleted  ReconciliationStatus = "COMPLETED"
+)
+
+// Reconciliation matches an account against a bank statement. Once completed,
+// the cleared entries are locked.
+type Reconciliation struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID   `bson:"userId" json:"userId"`
+	AccountID      primitive.ObjectID   `bson:"accountId" json:"accountId"`
+	StatementDate  time.Time            `bson:"statementDate" json:"statementDate"`
+	ClosingBalance float64              `bson:"closingBalance" json:"closingBalance"`
+	Status         ReconciliationStatus `bson:"status" json:"status"`
+	CreatedAt      time.Time            `bson:"createdAt" json:"createdAt"`
+	CompletedAt    *time.Time           `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+}
+
+// ReconciliationRequest is the request format for starting a reconciliation
+type ReconciliationRequest struct {
+	StatementDate  time.Time `json:"statementDate"`
+	ClosingBalance float64   `json:"closingBalance"`
+}
+
+// ClearEntriesRequest is the request format for marking account entries cleared or uncleared
+type ClearEntriesRequest struct {
+	EntryIDs []string `json:"entryIds"`
+	Cleared  bool     `json:"cleared"`
+}
+
+// ReconciliationResponse shows how far the cleared entries are from the statement
+type ReconciliationResponse struct {
+	Reconciliation
+	ClearedBalance   float64        `json:"clearedBalance"`
+	Difference       float64        `json:"difference"`
+	ClearedEntries   []AccountEntry `json:"clearedEntries"`
+	UnclearedEntries []AccountEntry `json:"unclearedEntries"`
+}
+
+// BankConnectionStatus represents the state of a bank connection
+type BankConnectionStatus string
+
+const (
+	BankConnectionActive         BankConnectionStatus = "ACTIVE"
+	BankConnectionReauthRequired BankConnectionStatus = "REAUTH_REQUIRED"
+	BankConnectionError          BankConnectionStatus = "ERROR"
+)
+
+// PendingBankTransaction is a transaction the bank hasn't posted yet. It is held on
+// the connection and only written to the budget once it posts.
+type PendingBankTransaction struct {
+	ID          string    `bson:"id" json:"id"`
+	Date        time.Time `bson:"date" json:"date"`
+	Description string    `bson:"description" json:"description"`
+	Payee       string    `bson:"payee,omitempty" json:"payee,omitempty"`
+	Amount      float64   `bson:"amount" json:"amount"`
+}
+
+// BankConnection links a bank account at an aggregation provider to the user's
+// budgets, and optionally to one of their accounts
+type BankConnection struct {
+	ID                primitive.ObjectID       `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID       `bson:"userId" json:"userId"`
+	Provider          string                   `bson:"provider" json:"provider"`
+	ExternalAccountID string                   `bson:"externalAccountId" json:"externalAccountId"`
+	Name              string                   `bson:"name" json:"name"`
+	AccountID         *primitive.ObjectID      `bson:"accountId,omitempty" json:"accountId,omitempty"`
+	AccessToken       string                   `bson:"accessToken" json:"-"`
+	RefreshToken      string                   `bson:"refreshToken" json:"-"`
+	TokenExpiresAt    time.Time                `bson:"tokenExpiresAt" json:"-"`
+	Cursor            string                   `bson:"cursor" json:"-"`
+	Pending           []PendingBankTransaction `bson:"pending" json:"pending"`
+	Status            BankConnectionStatus     `bson:"status" json:"status"`
+	LastError         string                   `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	LastSyncedAt      *time.Time               `bson:"lastSyncedAt,omitempty" json:"lastSyncedAt,omitempty"`
+	LockedUntil       *time.Time               `bson:"lockedUntil,omitempty" json:"-"`
+	CreatedAt         time.Time                `bson:"createdAt" json:"createdAt"`
+}
+
+// BankLinkRequest is the request format for linking accounts at a provider
+type BankLinkRequest struct {
+	Provider  string `json:"provider"`
+	LinkToken string `json:"linkToken"`
+}
+
+// BankConnectionRequest is the request format for updating a bank connection
+type BankConnectionRequest struct {
+	AccountID string `json:"accountId"`
+}
+
+// BankSyncResult summarizes a sync of a bank connection
+type BankSyncResult struct {
+	ConnectionID string `json:"connectionId"`
+	Added        int    `json:"added"`
+	Skipped      int    `json:"skipped"`
+	Pending      int    `json:"pending"`
+}
+
+// ImportFormat represents the file format of a statement import
+type ImportFormat string
+
+const (
+	ImportFormatCSV ImportFormat = "CSV"
+	ImportFormatOFX ImportFormat = "OFX"
+	ImportFormatQIF ImportFormat = "QIF"
+	// ImportFormatCAMT053 is an ISO 20022 camt.053 bank-to-customer statement
+	ImportFormatCAMT053 ImportFormat = "CAMT053"
+	// ImportFormatMT940 is a SWIFT MT940 customer statement
+	ImportFormatMT940 ImportFormat = "MT940"
+)
+
+// DateBasis tells which statement date places a transaction in a month
+type DateBasis string
+
+const (
+	DateBasisBooking DateBasis = "BOOKING"
+	DateBasisValue   DateBasis = "VALUE"
+)
+
+// ImportStatus represents the state of a statement import
+type ImportStatus string
+
+const (
+	ImportStatusPending    ImportStatus = "PENDING"
+	ImportStatusCommitting ImportStatus = "COMMITTING"
+	ImportStatusCommitted  ImportStatus = "COMMITTED"
+	ImportStatusRolledBack ImportStatus = "ROLLED_BACK"
+)
+
+// AmountSign tells which sign a CSV amount column uses for money going out
+type AmountSign string
+
+const (
+	AmountSignNegativeIsExpense AmountSign = "NEGATIVE_IS_EXPENSE"
+	AmountSignPositiveIsExpense AmountSign = "POSITIVE_IS_EXPENSE"
+)
+
+// ImportMapping describes how the columns of a CSV file map onto expense fields.
+// Columns are zero-based indexes; either AmountColumn or DebitColumn/CreditColumn must be set.
+// Only DateFormat and DecimalSeparator apply to QIF files; OFX files need no mapping.
+// DateBasis applies to camt.053 and MT940 files, which report booking and value dates.
+type ImportMapping struct {
+	HasHeader          bool       `bson:"hasHeader" json:"hasHeader"`
+	Delimiter          string     `bson:"delimiter,omitempty" json:"delimiter,omitempty"`
+	DateColumn         *int       `bson:"dateColumn" json:"dateColumn"`
+	DescriptionColumn  *int       `bson:"descriptionColumn" json:"descriptionColumn"`
+	AmountColumn       *int       `bson:"amountColumn,omitempty" json:"amountColumn,omitempty"`
+	DebitColumn        *int       `bson:"debitColumn,omitempty" json:"debitColumn,omitempty"`
+	CreditColumn       *int       `bson:"creditColumn,omitempty" json:"creditColumn,omitempty"`
+	CategoryColumn     *int       `bson:"categoryColumn,omitempty" json:"categoryColumn,omitempty"`
+	DateFormat         string     `bson:"dateFormat" json:"dateFormat"`
+	DecimalSeparator   string     `bson:"decimalSeparator,omitempty" json:"decimalSeparator,omitempty"`
+	ThousandsSeparator string     `bson:"thousandsSeparator,omitempty" json:"thousandsSeparator,omitempty"`
+	AmountSign         AmountSign `bson:"amountSign,omitempty" json:"amountSign,omitempty"`
+	DateBasis          DateBasis  `bson:"dateBasis,omitempty" json:"dateBasis,omitempty"`
+}
+
+// Import represents an uploaded statement file and what was imported from it
+type Import struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID  `bson:"userId" json:"userId"`
+	Format        ImportFormat        `bson:"format" json:"format"`
+	FileName      string              `bson:"fileName" json:"fileName"`
+	Content       []byte              `bson:"content" json:"-"`
+	Headers       []string            `bson:"headers,omitempty" json:"headers,omitempty"`
+	SampleRows    [][]string          `bson:"sampleRows,omitempty" json:"sampleRows,omitempty"`
+	Mapping       *ImportMapping      `bson:"mapping,omitempty" json:"mapping,omitempty"`
+	AccountID     *primitive.ObjectID `bson:"accountId,omitempty" json:"accountId,omitempty"`
+	Status        ImportStatus        `bson:"status" json:"status"`
+	ImportedCount int                 `bson:"importedCount" json:"importedCount"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	CommittedAt   *time.Time          `bson:"committedAt,omitempty" json:"committedAt,omitempty"`
+	RolledBackAt  *time.Time          `bson:"rolledBackAt,omitempty" json:"rolledBackAt,omitempty"`
+}
+
+// ImportRowKind tells whether an import row becomes an expense or an income
+type ImportRowKind string
+
+const (
+	ImportRowExpense ImportRowKind = "EXPENSE"
+	ImportRowIncome  ImportRowKind = "INCOME"
+)
+
+// ImportRow is a single parsed row of an import preview
+type ImportRow struct {
+	Line        int           `json:"line"`
+	Kind        ImportRowKind `json:"kind,omitempty"`
+	ExternalID  string        `json:"externalId,omitempty"`
+	Date        *time.Time    `json:"date,omitempty"`
+	ValueDate   *time.Time    `json:"valueDate,omitempty"`
+	Year        int           `json:"year,omitempty"`
+	Month       int           `json:"month,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Merchant    string        `json:"merchant,omitempty"`
+	Amount      float64       `json:"amount"`
+	Category    string        `json:"category,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Skipped     bool          `json:"skipped,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// ImportPreviewResponse is the response format for import previews
+type ImportPreviewResponse struct {
+	ImportID string      `json:"importId"`
+	Total    int         `json:"total"`
+	Valid    int         `json:"valid"`
+	Invalid  int         `json:"invalid"`
+	Skipped  int         `json:"skipped"`
+	Rows     []ImportRow `json:"rows"`
+}
+
+// ImportCommitRequest is the request format for committing an import
+type ImportCommitRequest struct {
+	Mapping     *ImportMapping `json:"mapping,omitempty"`
+	SkipInvalid bool           `json:"skipInvalid"`
+	AccountID   string         `json:"accountId,omitempty"`
+}
+
+// RuleConditions are the checks an expense must pass for a rule to apply.
+// Patterns are case-insensitive regular expressions; empty conditions always match.
+type RuleConditions struct {
+	TitlePattern    string   `bson:"titlePattern,omitempty" json:"titlePattern,omitempty"`
+	MerchantPattern string   `bson:"merchantPattern,omitempty" json:"merchantPattern,omitempty"`
+	MinAmount       *float64 `bson:"minAmount,omitempty" json:"minAmount,omitempty"`
+	MaxAmount       *float64 `bson:"maxAmount,omitempty" json:"maxAmount,omitempty"`
+	PaymentMethod   string   `bson:"paymentMethod,omitempty" json:"paymentMethod,omitempty"`
+}
+
+// RuleActions are the changes a rule makes to a matching expense
+type RuleActions struct {
+	SetCategory string   `bson:"setCategory,omitempty" json:"setCategory,omitempty"`
+	AddTags     []string `bson:"addTags,omitempty" json:"addTags,omitempty"`
+	RenameTo    string   `bson:"renameTo,omitempty" json:"renameTo,omitempty"`
+}
+
+// CategoryRule automatically categorizes, tags and renames expenses
+type CategoryRule struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	Name       string             `bson:"name" json:"name"`
+	Priority   int                `bson:"priority" json:"priority"`
+	Enabled    bool               `bson:"enabled" json:"enabled"`
+	Conditions RuleConditions     `bson:"conditions" json:"conditions"`
+	Actions    RuleActions        `bson:"actions" json:"actions"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CategoryRuleRequest is the request format for rule endpoints
+type CategoryRuleRequest struct {
+	Name       string         `json:"name"`
+	Priority   int            `json:"priority"`
+	Enabled    *bool          `json:"enabled,omitempty"`
+	Conditions RuleConditions `json:"conditions"`
+	Actions    RuleActions    `json:"actions"`
+}
+
+// RuleChange describes how a rule would change one existing expense
+type RuleChange struct {
+	ExpenseID string  `json:"expenseId"`
+	Year      int     `json:"year"`
+	Month     int     `json:"month"`
+	Amount    float64 `json:"amount"`
+	Before    Expense `json:"before"`
+	After     Expense `json:"after"`
+}
+
+// RuleDryRunResponse is the response format for rule dry runs and history applies.
+// Skipped counts the expenses the rule would change but that are locked by a
+// reconciliation; they aren't listed in Changes.
+type RuleDryRunResponse struct {
+	Matched int          `json:"matched"`
+	Changed int          `json:"changed"`
+	Skipped int          `json:"skipped"`
+	Applied bool         `json:"applied"`
+	Changes []RuleChange `json:"changes"`
+}
+
+// ExportFormat represents the file format of a budget export
+type ExportFormat string
+
+const (
+	ExportFormatCSV  ExportFormat = "CSV"
+	ExportFormatXLSX ExportFormat = "XLSX"
+	ExportFormatJSON ExportFormat = "JSON"
+)
+
+// JournalFormat represents a plain-text accounting file format
+type JournalFormat string
+
+const (
+	JournalFormatLedger    JournalFormat = "LEDGER"
+	JournalFormatHledger   JournalFormat = "HLEDGER"
+	JournalFormatBeancount JournalFormat = "BEANCOUNT"
+)
+
+// AccountMapping maps a category or fund counterparty onto a journal account
+type AccountMapping struct {
+	Name    string `bson:"name" json:"name"`
+	Account string `bson:"account" json:"account"`
+}
+
+// LedgerAccounts are a user's account names for plain-text accounting exports
+type LedgerAccounts struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID         primitive.ObjectID `bson:"userId" json:"-"`
+	Currency       string             `bson:"currency" json:"currency"`
+	Asset          string             `bson:"asset" json:"asset"`
+	BaseIncome     string             `bson:"baseIncome" json:"baseIncome"`
+	OtherIncome    string             `bson:"otherIncome" json:"otherIncome"`
+	ExpenseRoot    string             `bson:"expenseRoot" json:"expenseRoot"`
+	Receivables    string             `bson:"receivables" json:"receivables"`
+	Payables       string             `bson:"payables" json:"payables"`
+	FundIncome     string             `bson:"fundIncome" json:"fundIncome"`
+	FundExpense    string             `bson:"fundExpense" json:"fundExpense"`
+	Categories     []AccountMapping   `bson:"categories" json:"categories"`
+	Counterparties []AccountMapping   `bson:"counterparties" json:"counterparties"`
+	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// StatementCategory is the expenses of one category in a monthly statement
+type StatementCategory struct {
+	Category      string    `json:"category"`
+	Total         float64   `json:"total"`
+	PreviousTotal float64   `json:"previousTotal"`
+	Expenses      []Expense `json:"expenses"`
+}
+
+// StatementFund is an open fund listed on a monthly statement
+type StatementFund struct {
 	PersonName      string    `json:"personName"`
 	Type            FundType  `json:"type"`
 	PrincipalAmount float64   `json:"principalAmount"`
+	TotalPaid       float64   `json:"totalPaid"`
+	Outstanding     float64   `json:"outstanding"`
 	StartDate       time.Time `json:"startDate"`
-	Notes           string    `json:"notes,omitempty"`
 }
 
-// TransactionRequest is the request format for transaction endpoints
-type TransactionRequest struct {
-	Amount float64   `json:"amount"`
-	Date   time.Time `json:"date"`
-	Note   string    `json:"note,omitempty"`
+// MonthlyStatement holds everything shown on a printable monthly statement
+type MonthlyStatement struct {
+	Budget      BudgetResponse      `json:"budget"`
+	Previous    BudgetResponse      `json:"previous"`
+	Categories  []StatementCategory `json:"categories"`
+	OpenFunds   []StatementFund     `json:"openFunds"`
+	GeneratedAt time.Time           `json:"generatedAt"`
 }
 
-// FundResponse is the response format for fund endpoints
-type FundResponse struct {
-	ID              string       `json:"id"`
-	PersonName      string       `json:"personName"`
-	Type            FundType     `json:"type"`
-	PrincipalAmount float64      `json:"principalAmount"`
-	StartDate       time.Time    `json:"startDate"`
-	Notes           string       `json:"notes,omitempty"`
-	TotalPaid       float64      `json:"totalPaid"`
-	Outstanding     float64      `json:"outstanding"`
-	Status          string       `json:"status"`
-	Transactions    []Transaction `json:"transactions"`
-	CreatedAt       time.Time    `json:"createdAt"`
-	UpdatedAt       time.Time    `json:"updatedAt"`
-}
\ No newline at end of file
+// DuplicateExpense is one side of a duplicate candidate together with the month it's in
+type DuplicateExpense struct {
+	Year    int     `json:"year"`
+	Month   int     `json:"month"`
+	Expense Expense `json:"expense"`
+}
+
+// DuplicateCandidate is a pair of expenses that look like the same purchase
+type DuplicateCandidate struct {
+	Expenses        []DuplicateExpense `json:"expenses"`
+	Confidence      float64            `json:"confidence"`
+	DaysApart       int                `json:"daysApart"`
+	TitleSimilarity float64            `json:"titleSimilarity"`
+}
+
+// DuplicateDismissal remembers that a user marked a pair of expenses as not duplicates
+type DuplicateDismissal struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	ExpenseA  primitive.ObjectID `bson:"expenseA" json:"expenseA"`
+	ExpenseB  primitive.ObjectID `bson:"expenseB" json:"expenseB"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// DuplicateMergeRequest is the request format for merging two duplicate expenses
+type DuplicateMergeRequest struct {
+	KeepExpenseID      string `json:"keepExpenseId"`
+	DuplicateExpenseID string `json:"duplicateExpenseId"`
+}
+
+// DuplicateDismissRequest is the request format for dismissing a duplicate candidate
+type DuplicateDismissRequest struct {
+	ExpenseIDs []string `json:"expenseIds"`
+}
+
+// BillRecurrence represents how often a bill comes due
+type BillRecurrence string
+
+const (
+	BillRecurrenceNone      BillRecurrence = "NONE"
+	BillRecurrenceWeekly    BillRecurrence = "WEEKLY"
+	BillRecurrenceMonthly   BillRecurrence = "MONTHLY"
+	BillRecurrenceQuarterly BillRecurrence = "QUARTERLY"
+	BillRecurrenceYearly    BillRecurrence = "YEARLY"
+)
+
+// Bill is a payment that comes due on a date, e.g. rent or a card payment.
+// DueDate is the next unpaid due date; one-off bills become inactive once paid.
+type Bill struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID  `bson:"userId" json:"userId"`
+	Payee        string              `bson:"payee" json:"payee"`
+	Amount       float64             `bson:"amount" json:"amount"`
+	Estimated    bool                `bson:"estimated" json:"estimated"`
+	Category     string              `bson:"category,omitempty" json:"category,omitempty"`
+	AccountID    *primitive.ObjectID `bson:"accountId,omitempty" json:"accountId,omitempty"`
+	DueDate      time.Time           `bson:"dueDate" json:"dueDate"`
+	DueDay       int                 `bson:"dueDay" json:"-"`
+	Recurrence   BillRecurrence      `bson:"recurrence" json:"recurrence"`
+	Autopay      bool                `bson:"autopay" json:"autopay"`
+	ReminderDays int                 `bson:"reminderDays" json:"reminderDays"`
+	RemindedFor  *time.Time          `bson:"remindedFor,omitempty" json:"-"`
+	Active       bool                `bson:"active" json:"active"`
+	LastPaidAt   *time.Time          `bson:"lastPaidAt,omitempty" json:"lastPaidAt,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// BillRequest is the request format for bill endpoints
+type BillRequest struct {
+	Payee        string         `json:"payee"`
+	Amount       float64        `json:"amount"`
+	Estimated    bool           `json:"estimated"`
+	Category     string         `json:"category,omitempty"`
+	AccountID    string         `json:"accountId,omitempty"`
+	DueDate      time.Time      `json:"dueDate"`
+	Recurrence   BillRecurrence `json:"recurrence"`
+	Autopay      bool           `json:"autopay"`
+	ReminderDays *int           `json:"reminderDays,omitempty"`
+}
+
+// BillPaymentRequest is the request format for marking a bill paid. Amount defaults
+// to the bill's amount and Date to today.
+type BillPaymentRequest struct {
+	Amount *float64   `json:"amount,omitempty"`
+	Date   *time.Time `json:"date,omitempty"`
+}
+
+// BillPaymentResponse is the bill after a payment together with the expense it created
+type BillPaymentResponse struct {
+	Bill    Bill    `json:"bill"`
+	Expense Expense `json:"expense"`
+	Year    int     `json:"year"`
+	Month   int     `json:"month"`
+}
+
+// UpcomingBill is one occurrence of a bill within the upcoming window
+type UpcomingBill struct {
+	Bill         Bill      `json:"bill"`
+	DueDate      time.Time `json:"dueDate"`
+	DaysUntilDue int       `json:"daysUntilDue"`
+	Overdue      bool      `json:"overdue"`
+}
+
+// UpcomingBillsResponse lists the bills due up to a date, overdue ones first
+type UpcomingBillsResponse struct {
+	To    time.Time      `json:"to"`
+	Bills []UpcomingBill `json:"bills"`
+	Total float64        `json:"total"`
+}
+
+// NotificationType identifies what a notification is about
+type NotificationType string
+
+const (
+	NotificationBudgetAlert      NotificationType = "BUDGET_ALERT"
+	NotificationBillDue          NotificationType = "BILL_DUE"
+	NotificationFundDue          NotificationType = "FUND_DUE"
+	NotificationSecurityNewLogin NotificationType = "SECURITY_NEW_LOGIN"
+)
+
+// NotificationChannel is a way of delivering notifications
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp   NotificationChannel = "IN_APP"
+	NotificationChannelEmail   NotificationChannel = "EMAIL"
+	NotificationChannelWebhook NotificationChannel = "WEBHOOK"
+)
+
+// NotificationEvent is what a producer hands to the notification service. Data fills
+// the type's template; events with the same DedupKey are only notified once.
+type NotificationEvent struct {
+	Type     NotificationType
+	Data     map[string]string
+	DedupKey string
+}
+
+// Notification is a rendered notification, listed in the user's in-app inbox
+// when the in-app channel is enabled for its type
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Type      NotificationType   `bson:"type" json:"type"`
+	Title     string             `bson:"title" json:"title"`
+	Body      string             `bson:"body" json:"body"`
+	Data      map[string]string  `bson:"data,omitempty" json:"data,omitempty"`
+	DedupKey  string             `bson:"dedupKey,omitempty" json:"-"`
+	Inbox     bool               `bson:"inbox" json:"-"`
+	Read      bool               `bson:"read" json:"read"`
+	ReadAt    *time.Time         `bson:"readAt,omitempty" json:"readAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// NotificationInboxResponse is a page of the in-app inbox
+type NotificationInboxResponse struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int64          `json:"unread"`
+}
+
+// NotificationPreferences holds a user's delivery settings. Channels maps a
+// notification type to the channels it is delivered on; missing types use the defaults.
+type NotificationPreferences struct {
+	ID            primitive.ObjectID                         `bson:"_id,omitempty" json:"-"`
+	UserID        primitive.ObjectID                         `bson:"userId" json:"userId"`
+	Email         string                                     `bson:"email,omitempty" json:"email,omitempty"`
+	WebhookURL    string                                     `bson:"webhookUrl,omitempty" json:"webhookUrl,omitempty"`
+	WebhookSecret string                                     `bson:"webhookSecret,omitempty" json:"-"`
+	Channels      map[NotificationType][]NotificationChannel `bson:"channels" json:"channels"`
+	UpdatedAt     time.Time                                  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NotificationPreferencesRequest is the request format for updating notification preferences.
+// WebhookSecret is only changed when given.
+type NotificationPreferencesRequest struct {
+	Email         string                                     `json:"email"`
+	WebhookURL    string                                     `json:"webhookUrl"`
+	WebhookSecret *string                                    `json:"webhookSecret,omitempty"`
+	Channels      map[NotificationType][]NotificationChannel `json:"channels"`
+}
+
+// DeliveryStatus represents the state of an email or webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "PENDING"
+	DeliverySent    DeliveryStatus = "SENT"
+	DeliveryFailed  DeliveryStatus = "FAILED"
+)
+
+// NotificationDelivery is a queued email or webhook delivery of a notification,
+// retried with backoff until it is sent or runs out of attempts
+type NotificationDelivery struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	NotificationID primitive.ObjectID  `bson:"notificationId" json:"notificationId"`
+	UserID         primitive.ObjectID  `bson:"userId" json:"userId"`
+	Channel        NotificationChannel `bson:"channel" json:"channel"`
+	Target         string              `bson:"target" json:"target"`
+	Secret         string              `bson:"secret,omitempty" json:"-"`
+	Subject        string              `bson:"subject" json:"subject"`
+	Body           string              `bson:"body" json:"-"`
+	Payload        []byte              `bson:"payload,omitempty" json:"-"`
+	Status         DeliveryStatus      `bson:"status" json:"status"`
+	Attempts       int                 `bson:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time           `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LastError      string              `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	SentAt         *time.Time          `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+}
+
+// BudgetAlertType represents the condition a budget alert watches
+type BudgetAlertType string
+
+const (
+	BudgetAlertSpentPercent   BudgetAlertType = "SPENT_PERCENT"
+	BudgetAlertCategoryLimit  BudgetAlertType = "CATEGORY_LIMIT"
+	BudgetAlertRemainingBelow BudgetAlertType = "REMAINING_BELOW"
+)
+
+// BudgetAlert is a user-defined threshold on a month's budget. Threshold is a
+// percentage of income for SPENT_PERCENT and an amount otherwise.
+type BudgetAlert struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Type      BudgetAlertType    `bson:"type" json:"type"`
+	Threshold float64            `bson:"threshold" json:"threshold"`
+	Category  string             `bson:"category,omitempty" json:"category,omitempty"`
+	Enabled   bool               `bson:"enabled" json:"enabled"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// BudgetAlertRequest is the request format for budget alert endpoints
+type BudgetAlertRequest struct {
+	Type      BudgetAlertType `json:"type"`
+	Threshold float64         `json:"threshold"`
+	Category  string          `json:"category,omitempty"`
+	Enabled   *bool           `json:"enabled,omitempty"`
+}
+
+// BudgetAlertTrigger records that an alert fired for a month; an alert fires at most
+// once per month
+type BudgetAlertTrigger struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	AlertKey    string             `bson:"alertKey" json:"alertKey"`
+	Type        BudgetAlertType    `bson:"type" json:"type"`
+	Year        int                `bson:"year" json:"year"`
+	Month       int                `bson:"month" json:"month"`
+	Threshold   float64            `bson:"threshold" json:"threshold"`
+	Category    string             `bson:"category,omitempty" json:"category,omitempty"`
+	Value       float64            `bson:"value" json:"value"`
+	Message     string             `bson:"message" json:"message"`
+	TriggeredAt time.Time          `bson:"triggeredAt" json:"triggeredAt"`
+}
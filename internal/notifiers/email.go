@@ -0,0 +1,57 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP sends plain-text emails through an SMTP server
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTP creates an SMTP sender. Authentication is skipped when username is empty.
+func NewSMTP(host, port, username, password, from string) *SMTP {
+	return &SMTP{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send emails the message to its recipient
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	if strings.ContainsAny(message.To, "\r\n") {
+		return fmt.Errorf("invalid email address")
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	var email strings.Builder
+	email.WriteString("From: " + s.from + "\r\n")
+	email.WriteString("To: " + message.To + "\r\n")
+	email.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	email.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	email.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	email.WriteString("\r\n")
+	email.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	email.WriteString("\r\n")
+
+	return smtp.SendMail(s.addr, auth, s.from, []string{message.To}, []byte(email.String()))
+}
@@ -0,0 +1,77 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// Message is a notification addressed to one recipient. Email senders use Subject
+// and Body; webhooks post Payload, signed with Secret when it is set.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	Payload []byte
+	Secret  string
+}
+
+// Sender delivers messages over one channel
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// notificationTemplate is the title and body of a notification type, filled from the event's data
+type notificationTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+func newTemplate(name, title, body string) notificationTemplate {
+	return notificationTemplate{
+		title: template.Must(template.New(name + ".title").Option("missingkey=zero").Parse(title)),
+		body:  template.Must(template.New(name + ".body").Option("missingkey=zero").Parse(body)),
+	}
+}
+
+var templates = map[models.NotificationType]notificationTemplate{
+	models.NotificationBudgetAlert: newTemplate("budget_alert",
+		"Budget alert for {{.month}}",
+		"{{.message}}"),
+	models.NotificationBillDue: newTemplate("bill_due",
+		"{{.payee}} is due on {{.dueDate}}",
+		"Your bill from {{.payee}} for {{.amount}} is due on {{.dueDate}}.{{if eq .autopay \"true\"}} It will be paid automatically.{{end}}"),
+	models.NotificationFundDue: newTemplate("fund_due",
+		"{{.amount}} {{if eq .type \"GIVEN\"}}from{{else}}to{{end}} {{.personName}} is due on {{.dueDate}}",
+		"{{if eq .type \"GIVEN\"}}{{.personName}} is due to pay you {{.amount}}{{else}}You are due to pay {{.personName}} {{.amount}}{{end}} on {{.dueDate}}."),
+	models.NotificationSecurityNewLogin: newTemplate("security_new_login",
+		"New login to your account",
+		"Your account was logged into on {{.time}} from {{.ip}} ({{.userAgent}}). If this wasn't you, change your password."),
+}
+
+// Render fills the title and body template of a notification type
+func Render(notificationType models.NotificationType, data map[string]string) (string, string, error) {
+	tmpl, ok := templates[notificationType]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification type %q", notificationType)
+	}
+
+	var title, body bytes.Buffer
+	if err := tmpl.title.Execute(&title, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	return title.String(), body.String(), nil
+}
+
+// Known reports whether a notification type has a template
+func Known(notificationType models.NotificationType) bool {
+	_, ok := templates[notificationType]
+	return ok
+}
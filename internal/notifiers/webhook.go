@@ -0,0 +1,139 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Webhook posts JSON payloads to user-configured URLs. When the message has a secret,
+// the X-Signature header carries "sha256=" and the hex HMAC-SHA256 of the body.
+//
+// Webhook URLs come from users, so requests only go to public addresses: every
+// connection is checked after DNS resolution, which also covers a host name that
+// resolves to a public address when saved and to an internal one later. Redirects
+// aren't followed and proxies from the environment aren't used.
+type Webhook struct {
+	client *http.Client
+}
+
+// NewWebhook creates a webhook sender with the given request timeout
+func NewWebhook(timeout time.Duration) *Webhook {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+
+	return &Webhook{client: &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// blockedNetworks are ranges outside the ones net.IP classifies that aren't
+// reachable on the public internet
+var blockedNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // this network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, and broadcast
+		"64:ff9b::/96",  // NAT64, which maps to IPv4 addresses
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// PublicIP reports whether webhooks may be sent to an address: not loopback,
+// private, link-local (which includes cloud metadata services), unspecified,
+// multicast or otherwise reserved
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckWebhookURL verifies that a webhook URL is an http or https URL whose host
+// resolves to public addresses only
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("webhook URL must be an http or https URL")
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return fmt.Errorf("webhook URL host can't be resolved")
+	}
+	for _, address := range addresses {
+		if !PublicIP(address.IP) {
+			return fmt.Errorf("webhook URL must point to a public address")
+		}
+	}
+
+	return nil
+}
+
+// Send posts the message's payload to its URL; any non-2xx response, redirects
+// included, is an error
+func (w *Webhook) Send(ctx context.Context, message Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, message.To, bytes.NewReader(message.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "finance-app-webhook/1.0")
+	if message.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(message.Secret))
+		mac.Write(message.Payload)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
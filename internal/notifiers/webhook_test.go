@@ -0,0 +1,80 @@
+package notifiers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+
+	for _, tt := range tests {
+		if got := PublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("PublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://93.184.216.34/hook", false},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", false},
+		{"ftp://93.184.216.34/hook", true},
+		{"https:///hook", true},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://[::1]/hook", true},
+		{"http://10.0.0.5/hook", true},
+	}
+
+	for _, tt := range tests {
+		err := CheckWebhookURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckWebhookURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookSendRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	err := NewWebhook(5*time.Second).Send(context.Background(), Message{To: server.URL, Payload: []byte("{}")})
+	if err == nil {
+		t.Error("Send to a loopback address succeeded, want an error")
+	}
+	if called {
+		t.Error("the loopback server received the webhook")
+	}
+}
@@ -0,0 +1,593 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AccountService struct {
+	accountCollection        *mongo.Collection
+	transferCollection       *mongo.Collection
+	reconciliationCollection *mongo.Collection
+	budgetService            *BudgetService
+}
+
+func NewAccountService(db *mongo.Database, budgetService *BudgetService) *AccountService {
+	accountCollection := db.Collection("accounts")
+	transferCollection := db.Collection("transfers")
+
+	// Create index on userId for accounts
+	accountIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+		},
+	}
+	accountCollection.Indexes().CreateOne(context.Background(), accountIndexModel)
+
+	// Create indexes on both sides of transfers
+	transferIndexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "fromAccountId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "toAccountId", Value: 1}}},
+	}
+	transferCollection.Indexes().CreateMany(context.Background(), transferIndexModels)
+
+	return &AccountService{
+		accountCollection:        accountCollection,
+		transferCollection:       transferCollection,
+		reconciliationCollection: db.Collection("reconciliations"),
+		budgetService:            budgetService,
+	}
+}
+
+func validateAccountRequest(req models.AccountRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("account name is required")
+	}
+
+	switch req.Type {
+	case models.AccountTypeChecking, models.AccountTypeSavings, models.AccountTypeCash, models.AccountTypeCreditCard:
+	default:
+		return fmt.Errorf("invalid account type, must be CHECKING, SAVINGS, CASH or CREDIT_CARD")
+	}
+
+	return nil
+}
+
+// CreateAccount creates a new account
+func (as *AccountService) CreateAccount(ctx context.Context, userID string, req models.AccountRequest) (*models.AccountResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateAccountRequest(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	openingDate := req.OpeningDate
+	if openingDate.IsZero() {
+		openingDate = now
+	}
+
+	account := models.Account{
+		ID:             primitive.NewObjectID(),
+		UserID:         objID,
+		Name:           strings.TrimSpace(req.Name),
+		Type:           req.Type,
+		OpeningBalance: req.OpeningBalance,
+		OpeningDate:    openingDate,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	_, err = as.accountCollection.InsertOne(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccountResponse{Account: account, Balance: account.OpeningBalance}, nil
+}
+
+// GetAccounts retrieves all accounts of a user with their current balances
+func (as *AccountService) GetAccounts(ctx context.Context, userID string) ([]models.AccountResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	cursor, err := as.accountCollection.Find(ctx, bson.M{"userId": objID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []models.Account
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	entryBalances, err := as.budgetService.AccountBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	transferBalances, err := as.transferBalances(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.AccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		responses = append(responses, models.AccountResponse{
+			Account: account,
+			Balance: account.OpeningBalance + entryBalances[account.ID] + transferBalances[account.ID],
+		})
+	}
+
+	return responses, nil
+}
+
+// getAccount retrieves an account without its balance
+func (as *AccountService) getAccount(ctx context.Context, userID, accountID string) (*models.Account, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	accountObjID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account ID")
+	}
+
+	account := &models.Account{}
+	err = as.accountCollection.FindOne(ctx, bson.M{
+		"_id":    accountObjID,
+		"userId": userObjID,
+	}).Decode(account)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("account not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ResolveAccountID checks that an account belongs to the user and returns its ID.
+// An empty account ID resolves to nil so entries can stay unlinked.
+func (as *AccountService) ResolveAccountID(ctx context.Context, userID, accountID string) (*primitive.ObjectID, error) {
+	if accountID == "" {
+		return nil, nil
+	}
+
+	account, err := as.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &account.ID, nil
+}
+
+// GetAccountByID retrieves an account with its current balance
+func (as *AccountService) GetAccountByID(ctx context.Context, userID, accountID string) (*models.AccountResponse, error) {
+	account, err := as.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := as.accountEntries(ctx, userID, account)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := account.OpeningBalance
+	for _, entry := range entries {
+		balance += entry.Amount
+	}
+
+	return &models.AccountResponse{Account: *account, Balance: balance}, nil
+}
+
+// UpdateAccount updates an existing account
+func (as *AccountService) UpdateAccount(ctx context.Context, userID, accountID string, req models.AccountRequest) (*models.AccountResponse, error) {
+	account, err := as.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAccountRequest(req); err != nil {
+		return nil, err
+	}
+
+	openingDate := req.OpeningDate
+	if openingDate.IsZero() {
+		openingDate = account.OpeningDate
+	}
+
+	// Every reconciled cleared balance starts from the opening balance
+	if req.OpeningBalance != account.OpeningBalance {
+		reconciled, err := as.reconciliationCollection.CountDocuments(ctx, bson.M{
+			"userId":    account.UserID,
+			"accountId": account.ID,
+			"status":    models.ReconciliationCompleted,
+		}, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if reconciled > 0 {
+			return nil, fmt.Errorf("opening balance can't be changed once the account has a completed reconciliation")
+		}
+	}
+
+	_, err = as.accountCollection.UpdateOne(ctx,
+		bson.M{
+			"_id":    account.ID,
+			"userId": account.UserID,
+		},
+		bson.M{
+			"$set": bson.M{
+				"name":           strings.TrimSpace(req.Name),
+				"type":           req.Type,
+				"openingBalance": req.OpeningBalance,
+				"openingDate":    openingDate,
+				"updatedAt":      time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return as.GetAccountByID(ctx, userID, accountID)
+}
+
+// DeleteAccount deletes an account that nothing is linked to
+func (as *AccountService) DeleteAccount(ctx context.Context, userID, accountID string) error {
+	account, err := as.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return err
+	}
+
+	hasEntries, err := as.budgetService.HasAccountEntries(ctx, userID, account.ID)
+	if err != nil {
+		return err
+	}
+	transfers, err := as.transferCollection.CountDocuments(ctx, bson.M{
+		"userId": account.UserID,
+		"$or": bson.A{
+			bson.M{"fromAccountId": account.ID},
+			bson.M{"toAccountId": account.ID},
+		},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if hasEntries || transfers > 0 {
+		return fmt.Errorf("account has linked expenses, incomes or transfers")
+	}
+
+	_, err = as.accountCollection.DeleteOne(ctx, bson.M{
+		"_id":    account.ID,
+		"userId": account.UserID,
+	})
+	return err
+}
+
+// GetAccountLedger lists an account's movements with the running balance after each.
+// Optional from/to bounds limit the listed entries; the opening balance of the
+// listing still includes everything before it.
+func (as *AccountService) GetAccountLedger(ctx context.Context, userID, accountID string, from, to *time.Time) (*models.AccountLedgerResponse, error) {
+	account, err := as.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+
+	entries, err := as.accountEntries(ctx, userID, account)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &models.AccountLedgerResponse{
+		From:    from,
+		To:      to,
+		Entries: []models.AccountEntry{},
+	}
+
+	balance := account.OpeningBalance
+	ledger.OpeningBalance = balance
+	for _, entry := range entries {
+		if from != nil && entry.Date.Before(*from) {
+			balance += entry.Amount
+			ledger.OpeningBalance = balance
+			continue
+		}
+		if to != nil && entry.Date.After(*to) {
+			break
+		}
+		balance += entry.Amount
+		entry.Balance = balance
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+	ledger.ClosingBalance = balance
+
+	current := account.OpeningBalance
+	for _, entry := range entries {
+		current += entry.Amount
+	}
+	ledger.Account = models.AccountResponse{Account: *account, Balance: current}
+
+	return ledger, nil
+}
+
+// accountEntries lists every movement on an account in date order
+func (as *AccountService) accountEntries(ctx context.Context, userID string, account *models.Account) ([]models.AccountEntry, error) {
+	entries, err := as.budgetService.FindAccountEntries(ctx, userID, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := as.transferCollection.Find(ctx, bson.M{
+		"userId": account.UserID,
+		"$or": bson.A{
+			bson.M{"fromAccountId": account.ID},
+			bson.M{"toAccountId": account.ID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transfers []models.Transfer
+	if err := cursor.All(ctx, &transfers); err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range transfers {
+		entry := models.AccountEntry{
+			Kind:        models.AccountEntryTransferIn,
+			ID:          transfer.ID.Hex(),
+			Date:        transfer.Date,
+			Description: transfer.Note,
+			Amount:      transfer.Amount,
+			Cleared:     transfer.ToCleared,
+			Reconciled:  transfer.ToReconciliationID != nil,
+		}
+		if transfer.FromAccountID == account.ID {
+			entry.Kind = models.AccountEntryTransferOut
+			entry.Amount = -transfer.Amount
+			entry.Cleared = transfer.FromCleared
+			entry.Reconciled = transfer.FromReconciliationID != nil
+		}
+		if entry.Description == "" {
+			entry.Description = "Transfer"
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	return entries, nil
+}
+
+// transferBalances sums transfers in minus transfers out per account
+func (as *AccountService) transferBalances(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": userID}}},
+		{{Key: "$project", Value: bson.M{
+			"sides": bson.A{
+				bson.M{"accountId": "$fromAccountId", "amount": bson.M{"$multiply": bson.A{"$amount", -1}}},
+				bson.M{"accountId": "$toAccountId", "amount": "$amount"},
+			},
+		}}},
+		{{Key: "$unwind", Value: "$sides"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$sides.accountId",
+			"total": bson.M{"$sum": "$sides.amount"},
+		}}},
+	}
+
+	cursor, err := as.transferCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		AccountID primitive.ObjectID `bson:"_id"`
+		Total     float64            `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[primitive.ObjectID]float64, len(rows))
+	for _, row := range rows {
+		balances[row.AccountID] = row.Total
+	}
+
+	return balances, nil
+}
+
+// CreateTransfer moves money between two of the user's accounts
+func (as *AccountService) CreateTransfer(ctx context.Context, userID string, req models.TransferRequest) (*models.Transfer, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if req.FromAccountID == "" || req.ToAccountID == "" {
+		return nil, fmt.Errorf("fromAccountId and toAccountId are required")
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+
+	from, err := as.getAccount(ctx, userID, req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := as.getAccount(ctx, userID, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := req.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	transfer := &models.Transfer{
+		ID:            primitive.NewObjectID(),
+		UserID:        from.UserID,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        req.Amount,
+		Date:          date,
+		Note:          req.Note,
+		CreatedAt:     time.Now(),
+	}
+
+	_, err = as.transferCollection.InsertOne(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// GetTransfers lists the user's transfers, newest first, optionally for one account
+func (as *AccountService) GetTransfers(ctx context.Context, userID, accountID string) ([]models.Transfer, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	filter := bson.M{"userId": objID}
+	if accountID != "" {
+		account, err := as.getAccount(ctx, userID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"fromAccountId": account.ID},
+			bson.M{"toAccountId": account.ID},
+		}
+	}
+
+	cursor, err := as.transferCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"date": -1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transfers := []models.Transfer{}
+	if err := cursor.All(ctx, &transfers); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// DeleteTransfer deletes a transfer
+func (as *AccountService) DeleteTransfer(ctx context.Context, userID, transferID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	transferObjID, err := primitive.ObjectIDFromHex(transferID)
+	if err != nil {
+		return fmt.Errorf("invalid transfer ID")
+	}
+
+	transfer := &models.Transfer{}
+	err = as.transferCollection.FindOne(ctx, bson.M{
+		"_id":    transferObjID,
+		"userId": userObjID,
+	}).Decode(transfer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("transfer not found or doesn't belong to user")
+		}
+		return err
+	}
+
+	result, err := as.transferCollection.DeleteOne(ctx, bson.M{
+		"_id":                  transferObjID,
+		"userId":               userObjID,
+		"fromReconciliationId": bson.M{"$exists": false},
+		"toReconciliationId":   bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("transfer is reconciled and locked")
+	}
+
+	return nil
+}
+
+// transferSides are the field names for each side of a transfer
+var transferSides = []struct {
+	account, cleared, reconciliation string
+}{
+	{"fromAccountId", "fromCleared", "fromReconciliationId"},
+	{"toAccountId", "toCleared", "toReconciliationId"},
+}
+
+// SetTransfersCleared marks the account's side of the given transfers as cleared or
+// uncleared, leaving sides locked by a completed reconciliation alone
+func (as *AccountService) SetTransfersCleared(ctx context.Context, account *models.Account, transferIDs []primitive.ObjectID, cleared bool) error {
+	for _, side := range transferSides {
+		_, err := as.transferCollection.UpdateMany(ctx,
+			bson.M{
+				"_id":               bson.M{"$in": transferIDs},
+				"userId":            account.UserID,
+				side.account:        account.ID,
+				side.reconciliation: bson.M{"$exists": false},
+			},
+			bson.M{"$set": bson.M{side.cleared: cleared}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockClearedTransfers stamps the account's side of the given transfers, when cleared
+// and not yet reconciled, with a reconciliation
+func (as *AccountService) LockClearedTransfers(ctx context.Context, account *models.Account, reconciliationID primitive.ObjectID, transferIDs []primitive.ObjectID) error {
+	for _, side := range transferSides {
+		_, err := as.transferCollection.UpdateMany(ctx,
+			bson.M{
+				"_id":               bson.M{"$in": transferIDs},
+				"userId":            account.UserID,
+				side.account:        account.ID,
+				side.cleared:        true,
+				side.reconciliation: bson.M{"$exists": false},
+			},
+			bson.M{"$set": bson.M{side.reconciliation: reconciliationID}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
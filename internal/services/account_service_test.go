@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateAccountKeepsOpeningBalanceOnceReconciled(t *testing.T) {
+	db := testDatabase(t)
+	budgetService := NewBudgetService(db, nil)
+	as := NewAccountService(db, budgetService)
+	rs := NewReconciliationService(db, as, budgetService)
+	userID := primitive.NewObjectID().Hex()
+	ctx := context.Background()
+
+	req := models.AccountRequest{Name: "Checking", Type: models.AccountTypeChecking, OpeningBalance: 1000, OpeningDate: day(2026, 1, 1)}
+	account, err := as.CreateAccount(ctx, userID, req)
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+
+	// Without a completed reconciliation the opening balance can still be corrected
+	req.OpeningBalance = 1200
+	if _, err := as.UpdateAccount(ctx, userID, account.ID.Hex(), req); err != nil {
+		t.Fatalf("UpdateAccount before reconciling: %v", err)
+	}
+
+	reconciliation, err := rs.StartReconciliation(ctx, userID, account.ID.Hex(), models.ReconciliationRequest{StatementDate: day(2026, 1, 31), ClosingBalance: 1200})
+	if err != nil {
+		t.Fatalf("StartReconciliation: %v", err)
+	}
+	if _, err := rs.CompleteReconciliation(ctx, userID, reconciliation.ID.Hex()); err != nil {
+		t.Fatalf("CompleteReconciliation: %v", err)
+	}
+
+	req.OpeningBalance = 1500
+	if _, err := as.UpdateAccount(ctx, userID, account.ID.Hex(), req); err == nil {
+		t.Error("UpdateAccount changed the opening balance of a reconciled account")
+	}
+
+	req.OpeningBalance = 1200
+	req.Name = "Main checking"
+	updated, err := as.UpdateAccount(ctx, userID, account.ID.Hex(), req)
+	if err != nil {
+		t.Fatalf("UpdateAccount keeping the opening balance: %v", err)
+	}
+	if updated.Name != "Main checking" || updated.OpeningBalance != 1200 {
+		t.Errorf("account = %q with %.2f, want %q with 1200", updated.Name, updated.OpeningBalance, "Main checking")
+	}
+}
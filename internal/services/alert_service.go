@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// overspendAlertKey identifies the built-in overspend alert of users without alerts
+const overspendAlertKey = "overspend"
+
+// AlertNotifier delivers a triggered budget alert to the user
+type AlertNotifier interface {
+	NotifyAlert(ctx context.Context, userID string, trigger models.BudgetAlertTrigger) error
+}
+
+// LogAlertNotifier is a stand-in AlertNotifier that only writes alerts to the log
+type LogAlertNotifier struct{}
+
+func (LogAlertNotifier) NotifyAlert(ctx context.Context, userID string, trigger models.BudgetAlertTrigger) error {
+	log.Printf("Budget alert for user %s: %s", userID, trigger.Message)
+	return nil
+}
+
+type AlertService struct {
+	collection        *mongo.Collection
+	triggerCollection *mongo.Collection
+	notifier          AlertNotifier
+}
+
+func NewAlertService(db *mongo.Database, notifier AlertNotifier) *AlertService {
+	collection := db.Collection("budget_alerts")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}},
+	})
+
+	// An alert fires at most once per month
+	triggerCollection := db.Collection("budget_alert_triggers")
+	triggerCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "alertKey", Value: 1},
+			{Key: "year", Value: 1},
+			{Key: "month", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return &AlertService{
+		collection:        collection,
+		triggerCollection: triggerCollection,
+		notifier:          notifier,
+	}
+}
+
+func validateAlertRequest(req models.BudgetAlertRequest) error {
+	switch req.Type {
+	case models.BudgetAlertSpentPercent:
+		if req.Threshold <= 0 {
+			return fmt.Errorf("threshold must be a positive percentage")
+		}
+	case models.BudgetAlertCategoryLimit:
+		if strings.TrimSpace(req.Category) == "" {
+			return fmt.Errorf("category is required for CATEGORY_LIMIT alerts")
+		}
+		if req.Threshold < 0 {
+			return fmt.Errorf("threshold must not be negative")
+		}
+	case models.BudgetAlertRemainingBelow:
+	default:
+		return fmt.Errorf("invalid alert type, must be SPENT_PERCENT, CATEGORY_LIMIT or REMAINING_BELOW")
+	}
+
+	return nil
+}
+
+// alertCategory returns the category of a request, which only CATEGORY_LIMIT alerts have
+func alertCategory(req models.BudgetAlertRequest) string {
+	if req.Type != models.BudgetAlertCategoryLimit {
+		return ""
+	}
+	return strings.TrimSpace(req.Category)
+}
+
+// CreateAlert creates a new budget alert
+func (as *AlertService) CreateAlert(ctx context.Context, userID string, req models.BudgetAlertRequest) (*models.BudgetAlert, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateAlertRequest(req); err != nil {
+		return nil, err
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	now := time.Now()
+	alert := &models.BudgetAlert{
+		ID:        primitive.NewObjectID(),
+		UserID:    objID,
+		Type:      req.Type,
+		Threshold: req.Threshold,
+		Category:  alertCategory(req),
+		Enabled:   enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := as.collection.InsertOne(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// GetAlerts lists the user's budget alerts
+func (as *AlertService) GetAlerts(ctx context.Context, userID string) ([]models.BudgetAlert, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := as.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []models.BudgetAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetAlertByID retrieves a budget alert
+func (as *AlertService) GetAlertByID(ctx context.Context, userID, alertID string) (*models.BudgetAlert, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	alertObjID, err := primitive.ObjectIDFromHex(alertID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert ID")
+	}
+
+	alert := &models.BudgetAlert{}
+	err = as.collection.FindOne(ctx, bson.M{
+		"_id":    alertObjID,
+		"userId": objID,
+	}).Decode(alert)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("alert not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// UpdateAlert updates a budget alert. An alert that already fired this month doesn't
+// fire again until next month, even with a new threshold.
+func (as *AlertService) UpdateAlert(ctx context.Context, userID, alertID string, req models.BudgetAlertRequest) (*models.BudgetAlert, error) {
+	alert, err := as.GetAlertByID(ctx, userID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAlertRequest(req); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"type":      req.Type,
+		"threshold": req.Threshold,
+		"category":  alertCategory(req),
+		"updatedAt": time.Now(),
+	}
+	if req.Enabled != nil {
+		set["enabled"] = *req.Enabled
+	}
+
+	err = as.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": alert.ID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(alert)
+	if err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// DeleteAlert deletes a budget alert. Its triggers are kept as history.
+func (as *AlertService) DeleteAlert(ctx context.Context, userID, alertID string) error {
+	alert, err := as.GetAlertByID(ctx, userID, alertID)
+	if err != nil {
+		return err
+	}
+
+	_, err = as.collection.DeleteOne(ctx, bson.M{"_id": alert.ID})
+	return err
+}
+
+// GetTriggers lists the alerts that fired in a month
+func (as *AlertService) GetTriggers(ctx context.Context, userID string, year, month int) ([]models.BudgetAlertTrigger, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "triggeredAt", Value: 1}})
+	cursor, err := as.triggerCollection.Find(ctx, bson.M{
+		"userId": objID,
+		"year":   year,
+		"month":  month,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	triggers := []models.BudgetAlertTrigger{}
+	if err := cursor.All(ctx, &triggers); err != nil {
+		return nil, err
+	}
+
+	return triggers, nil
+}
+
+// BudgetChanged evaluates the user's alerts against a budget that was just changed.
+// Alerts are best effort, so failures are only logged.
+func (as *AlertService) BudgetChanged(ctx context.Context, userID string, budget *models.MonthlyBudget) {
+	if _, err := as.Evaluate(ctx, userID, budget); err != nil {
+		log.Printf("Budget alerts for user %s failed: %v", userID, err)
+	}
+}
+
+// Evaluate checks the user's enabled alerts against a budget and notifies the ones
+// that fire for the first time this month. Users who never configured an alert get
+// a built-in alert for spending more than their income.
+func (as *AlertService) Evaluate(ctx context.Context, userID string, budget *models.MonthlyBudget) ([]models.BudgetAlertTrigger, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	alerts, err := as.GetAlerts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		alerts = []models.BudgetAlert{{Type: models.BudgetAlertRemainingBelow, Enabled: true}}
+	}
+
+	var triggers []models.BudgetAlertTrigger
+	var errs []error
+	for _, alert := range alerts {
+		if !alert.Enabled {
+			continue
+		}
+
+		value, message, fired := checkAlert(alert, budget)
+		if !fired {
+			continue
+		}
+
+		alertKey := overspendAlertKey
+		if !alert.ID.IsZero() {
+			alertKey = alert.ID.Hex()
+		}
+		trigger := models.BudgetAlertTrigger{
+			ID:          primitive.NewObjectID(),
+			UserID:      objID,
+			AlertKey:    alertKey,
+			Type:        alert.Type,
+			Year:        budget.Year,
+			Month:       budget.Month,
+			Threshold:   alert.Threshold,
+			Category:    alert.Category,
+			Value:       value,
+			Message:     message,
+			TriggeredAt: time.Now(),
+		}
+
+		// The unique index lets only the first change of the month claim the alert
+		if _, err := as.triggerCollection.InsertOne(ctx, trigger); err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := as.notifier.NotifyAlert(ctx, userID, trigger); err != nil {
+			// Unclaim so the next change of the budget tries again
+			as.triggerCollection.DeleteOne(ctx, bson.M{"_id": trigger.ID})
+			errs = append(errs, err)
+			continue
+		}
+		triggers = append(triggers, trigger)
+	}
+
+	return triggers, errors.Join(errs...)
+}
+
+// checkAlert reports whether an alert fires for a budget, with the value it compared
+// against the threshold and a message describing it
+func checkAlert(alert models.BudgetAlert, budget *models.MonthlyBudget) (float64, string, bool) {
+	month := time.Date(budget.Year, time.Month(budget.Month), 1, 0, 0, 0, 0, time.UTC).Format("January 2006")
+
+	switch alert.Type {
+	case models.BudgetAlertSpentPercent:
+		income := 0.0
+		if budget.BaseIncome != nil {
+			income = *budget.BaseIncome
+		}
+		for _, entry := range budget.Incomes {
+			income += entry.Amount
+		}
+		if income <= 0 {
+			return 0, "", false
+		}
+
+		spent := 0.0
+		for _, expense := range budget.Expenses {
+			spent += expense.Amount
+		}
+		percent := spent / income * 100
+		if percent < alert.Threshold {
+			return 0, "", false
+		}
+		return percent, fmt.Sprintf("You have spent %.0f%% of your income in %s (%.2f of %.2f).", percent, month, spent, income), true
+
+	case models.BudgetAlertCategoryLimit:
+		spent := 0.0
+		for _, expense := range budget.Expenses {
+			if strings.EqualFold(expense.Category, alert.Category) {
+				spent += expense.Amount
+			}
+		}
+		if spent <= alert.Threshold {
+			return 0, "", false
+		}
+		return spent, fmt.Sprintf("You have spent %.2f on %s in %s, over your limit of %.2f.", spent, alert.Category, month, alert.Threshold), true
+
+	case models.BudgetAlertRemainingBelow:
+		remaining := CalculateRemaining(budget.BaseIncome, budget.Incomes, budget.Expenses)
+		if remaining == nil || *remaining >= alert.Threshold {
+			return 0, "", false
+		}
+		if alert.Threshold == 0 {
+			return *remaining, fmt.Sprintf("You have spent %.2f more than your income in %s.", -*remaining, month), true
+		}
+		return *remaining, fmt.Sprintf("You have %.2f left for %s, below %.2f.", *remaining, month, alert.Threshold), true
+	}
+
+	return 0, "", false
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func TestCheckAlert(t *testing.T) {
+	income := func(amount float64) *float64 { return &amount }
+	budget := func(baseIncome *float64, incomes []models.Income, expenses ...models.Expense) *models.MonthlyBudget {
+		return &models.MonthlyBudget{Year: 2026, Month: 1, BaseIncome: baseIncome, Incomes: incomes, Expenses: expenses}
+	}
+	groceries := models.Expense{Title: "Groceries", Amount: 300, Category: "Food"}
+	dinner := models.Expense{Title: "Dinner", Amount: 150, Category: "food"}
+	rent := models.Expense{Title: "Rent", Amount: 900, Category: "Housing"}
+
+	tests := []struct {
+		name      string
+		alert     models.BudgetAlert
+		budget    *models.MonthlyBudget
+		wantFired bool
+		wantValue float64
+		wantText  string
+	}{
+		{
+			name:      "spent percent reached",
+			alert:     models.BudgetAlert{Type: models.BudgetAlertSpentPercent, Threshold: 80},
+			budget:    budget(income(1000), []models.Income{{Amount: 500}}, rent, groceries),
+			wantFired: true,
+			wantValue: 80,
+			wantText:  "You have spent 80% of your income in January 2026 (1200.00 of 1500.00).",
+		},
+		{
+			name:   "spent percent below threshold",
+			alert:  models.BudgetAlert{Type: models.BudgetAlertSpentPercent, Threshold: 80},
+			budget: budget(income(2000), nil, rent),
+		},
+		{
+			name:   "spent percent without income",
+			alert:  models.BudgetAlert{Type: models.BudgetAlertSpentPercent, Threshold: 1},
+			budget: budget(nil, nil, rent),
+		},
+		{
+			name:      "category limit matches case-insensitively",
+			alert:     models.BudgetAlert{Type: models.BudgetAlertCategoryLimit, Threshold: 400, Category: "FOOD"},
+			budget:    budget(nil, nil, groceries, dinner, rent),
+			wantFired: true,
+			wantValue: 450,
+			wantText:  "You have spent 450.00 on FOOD in January 2026, over your limit of 400.00.",
+		},
+		{
+			name:   "category limit reached but not exceeded",
+			alert:  models.BudgetAlert{Type: models.BudgetAlertCategoryLimit, Threshold: 450, Category: "Food"},
+			budget: budget(nil, nil, groceries, dinner),
+		},
+		{
+			name:      "remaining below threshold",
+			alert:     models.BudgetAlert{Type: models.BudgetAlertRemainingBelow, Threshold: 200},
+			budget:    budget(income(1000), nil, rent),
+			wantFired: true,
+			wantValue: 100,
+			wantText:  "You have 100.00 left for January 2026, below 200.00.",
+		},
+		{
+			name:      "overspent with a zero threshold",
+			alert:     models.BudgetAlert{Type: models.BudgetAlertRemainingBelow},
+			budget:    budget(income(1000), nil, rent, groceries),
+			wantFired: true,
+			wantValue: -200,
+			wantText:  "You have spent 200.00 more than your income in January 2026.",
+		},
+		{
+			name:   "remaining without income",
+			alert:  models.BudgetAlert{Type: models.BudgetAlertRemainingBelow, Threshold: 200},
+			budget: budget(nil, nil, rent),
+		},
+		{
+			name:   "unknown type",
+			alert:  models.BudgetAlert{Type: "OTHER", Threshold: 1},
+			budget: budget(income(1000), nil, rent),
+		},
+	}
+
+	for _, tt := range tests {
+		value, text, fired := checkAlert(tt.alert, tt.budget)
+		if fired != tt.wantFired || value != tt.wantValue || text != tt.wantText {
+			t.Errorf("%s: checkAlert = %v, %q, %v; want %v, %q, %v",
+				tt.name, value, text, fired, tt.wantValue, tt.wantText, tt.wantFired)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/connectors"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bankSyncLease is how long a sync holds a connection before another sync may take over
+const bankSyncLease = 10 * time.Minute
+
+type BankSyncService struct {
+	collection     *mongo.Collection
+	registry       connectors.Registry
+	budgetService  *BudgetService
+	ruleService    *RuleService
+	accountService *AccountService
+}
+
+func NewBankSyncService(db *mongo.Database, registry connectors.Registry, budgetService *BudgetService, ruleService *RuleService, accountService *AccountService) *BankSyncService {
+	collection := db.Collection("bank_connections")
+
+	// Create unique index so an account is only linked once per provider
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "provider", Value: 1},
+			{Key: "externalAccountId", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &BankSyncService{
+		collection:     collection,
+		registry:       registry,
+		budgetService:  budgetService,
+		ruleService:    ruleService,
+		accountService: accountService,
+	}
+}
+
+// LinkConnections exchanges a link token for credentials and creates a connection for
+// every account it gives access to. Linking an account again replaces its credentials,
+// which is how connections that need re-authentication are repaired.
+func (bs *BankSyncService) LinkConnections(ctx context.Context, userID string, req models.BankLinkRequest) ([]models.BankConnection, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	if strings.TrimSpace(req.LinkToken) == "" {
+		return nil, fmt.Errorf("link token is required")
+	}
+
+	connector, err := bs.registry.Get(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := connector.Link(ctx, req.LinkToken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	connections := make([]models.BankConnection, 0, len(link.Accounts))
+	for _, account := range link.Accounts {
+		connection := models.BankConnection{}
+		err := bs.collection.FindOneAndUpdate(ctx,
+			bson.M{
+				"userId":            objID,
+				"provider":          req.Provider,
+				"externalAccountId": account.ID,
+			},
+			bson.M{
+				"$set": bson.M{
+					"name":           account.Name,
+					"accessToken":    link.Credentials.AccessToken,
+					"refreshToken":   link.Credentials.RefreshToken,
+					"tokenExpiresAt": link.Credentials.ExpiresAt,
+					"status":         models.BankConnectionActive,
+				},
+				"$unset": bson.M{"lastError": ""},
+				"$setOnInsert": bson.M{
+					"cursor":    "",
+					"pending":   []models.PendingBankTransaction{},
+					"createdAt": now,
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&connection)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+
+	return connections, nil
+}
+
+// GetConnections lists the user's bank connections
+func (bs *BankSyncService) GetConnections(ctx context.Context, userID string) ([]models.BankConnection, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := bs.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	connections := []models.BankConnection{}
+	if err := cursor.All(ctx, &connections); err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
+
+// GetConnectionByID retrieves a bank connection with its pending transactions
+func (bs *BankSyncService) GetConnectionByID(ctx context.Context, userID, connectionID string) (*models.BankConnection, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	connectionObjID, err := primitive.ObjectIDFromHex(connectionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection ID")
+	}
+
+	connection := &models.BankConnection{}
+	err = bs.collection.FindOne(ctx, bson.M{
+		"_id":    connectionObjID,
+		"userId": objID,
+	}).Decode(connection)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("bank connection not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// UpdateConnection links a bank connection to one of the user's accounts. Synced
+// entries are linked to that account from the next sync on.
+func (bs *BankSyncService) UpdateConnection(ctx context.Context, userID, connectionID string, req models.BankConnectionRequest) (*models.BankConnection, error) {
+	connection, err := bs.GetConnectionByID(ctx, userID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := bs.accountService.ResolveAccountID(ctx, userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"accountId": accountID}}
+	if accountID == nil {
+		update = bson.M{"$unset": bson.M{"accountId": ""}}
+	}
+
+	err = bs.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": connection.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(connection)
+	if err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// DeleteConnection removes a bank connection. Entries it already synced are kept.
+func (bs *BankSyncService) DeleteConnection(ctx context.Context, userID, connectionID string) error {
+	connection, err := bs.GetConnectionByID(ctx, userID, connectionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = bs.collection.DeleteOne(ctx, bson.M{"_id": connection.ID})
+	return err
+}
+
+// SyncConnection fetches new transactions of one connection right away
+func (bs *BankSyncService) SyncConnection(ctx context.Context, userID, connectionID string) (*models.BankSyncResult, error) {
+	connection, err := bs.GetConnectionByID(ctx, userID, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if connection.Status == models.BankConnectionReauthRequired {
+		return nil, fmt.Errorf("bank connection requires the account to be linked again")
+	}
+
+	return bs.sync(ctx, connection)
+}
+
+// SyncAll syncs every connection that doesn't need re-authentication. It is run by the
+// background sync worker; failing connections are logged and don't stop the others.
+func (bs *BankSyncService) SyncAll(ctx context.Context) error {
+	cursor, err := bs.collection.Find(ctx, bson.M{
+		"status": bson.M{"$ne": models.BankConnectionReauthRequired},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	failed := 0
+	for cursor.Next(ctx) {
+		connection := &models.BankConnection{}
+		if err := cursor.Decode(connection); err != nil {
+			return err
+		}
+
+		result, err := bs.sync(ctx, connection)
+		if err != nil {
+			log.Printf("Bank connection %s failed to sync: %v", connection.ID.Hex(), err)
+			failed++
+			continue
+		}
+		if result.Added > 0 {
+			log.Printf("Bank connection %s synced %d entries", connection.ID.Hex(), result.Added)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d bank connections failed to sync", failed)
+	}
+	return nil
+}
+
+// sync takes the connection's lease, fetches and stores its new transactions and
+// records the outcome. Refreshed credentials are kept even when the sync fails.
+func (bs *BankSyncService) sync(ctx context.Context, connection *models.BankConnection) (*models.BankSyncResult, error) {
+	connector, err := bs.registry.Get(connection.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	leaseUntil := now.Add(bankSyncLease)
+	err = bs.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id": connection.ID,
+			"$or": bson.A{
+				bson.M{"lockedUntil": bson.M{"$exists": false}},
+				bson.M{"lockedUntil": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"lockedUntil": leaseUntil}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(connection)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("bank connection is already syncing")
+		}
+		return nil, err
+	}
+
+	result, syncErr := bs.fetchAndStore(ctx, connector, connection)
+
+	set := bson.M{
+		"accessToken":    connection.AccessToken,
+		"refreshToken":   connection.RefreshToken,
+		"tokenExpiresAt": connection.TokenExpiresAt,
+	}
+	unset := bson.M{"lockedUntil": ""}
+	switch {
+	case syncErr == nil:
+		set["status"] = models.BankConnectionActive
+		set["cursor"] = connection.Cursor
+		set["pending"] = connection.Pending
+		set["lastSyncedAt"] = time.Now()
+		unset["lastError"] = ""
+	case errors.Is(syncErr, connectors.ErrReauthRequired):
+		set["status"] = models.BankConnectionReauthRequired
+		set["lastError"] = syncErr.Error()
+	default:
+		set["status"] = models.BankConnectionError
+		set["lastError"] = syncErr.Error()
+	}
+
+	_, err = bs.collection.UpdateOne(ctx,
+		bson.M{"_id": connection.ID},
+		bson.M{"$set": set, "$unset": unset},
+	)
+	if syncErr != nil {
+		return nil, syncErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// fetchAndStore pages through the connection's new transactions, keeps pending ones on
+// the connection until they post or are removed and writes posted ones to the budget. Transactions already synced,
+// matched by their external ID, are skipped, so a sync that fails after writing can
+// simply be retried.
+func (bs *BankSyncService) fetchAndStore(ctx context.Context, connector connectors.Connector, connection *models.BankConnection) (*models.BankSyncResult, error) {
+	credentials := connectors.Credentials{
+		AccessToken:  connection.AccessToken,
+		RefreshToken: connection.RefreshToken,
+		ExpiresAt:    connection.TokenExpiresAt,
+	}
+	refresh := func() error {
+		refreshed, err := connector.Refresh(ctx, credentials)
+		if err != nil {
+			return err
+		}
+		credentials = *refreshed
+		connection.AccessToken = refreshed.AccessToken
+		connection.RefreshToken = refreshed.RefreshToken
+		connection.TokenExpiresAt = refreshed.ExpiresAt
+		return nil
+	}
+
+	refreshed := false
+	if credentials.Expired(time.Now()) {
+		if err := refresh(); err != nil {
+			return nil, err
+		}
+		refreshed = true
+	}
+
+	pending := map[string]models.PendingBankTransaction{}
+	for _, tx := range connection.Pending {
+		pending[tx.ID] = tx
+	}
+
+	var posted []connectors.Transaction
+	cursor := connection.Cursor
+	for {
+		page, err := connector.Fetch(ctx, credentials, connection.ExternalAccountID, cursor)
+		if errors.Is(err, connectors.ErrCredentialsExpired) && !refreshed {
+			if err := refresh(); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		posted = append(posted, applyPage(pending, page)...)
+
+		cursor = page.Cursor
+		if !page.HasMore {
+			break
+		}
+	}
+
+	// Hold the posted transactions' external IDs while writing them, so an import or
+	// sync running at the same time can't write them too
+	externalIDs := make([]string, 0, len(posted))
+	for _, tx := range posted {
+		externalIDs = append(externalIDs, bankExternalID(connection, tx.ID))
+	}
+	claim, err := bs.budgetService.claimExternalIDs(ctx, connection.UserID.Hex(), externalIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer claim.release()
+
+	result := &models.BankSyncResult{ConnectionID: connection.ID.Hex()}
+	batches, skipped, err := bs.syncBatches(ctx, connection, posted)
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.budgetService.AddEntriesBulk(ctx, connection.UserID.Hex(), batches); err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		result.Added += len(batch.Expenses) + len(batch.Incomes)
+	}
+	result.Skipped = skipped
+
+	connection.Cursor = cursor
+	connection.Pending = make([]models.PendingBankTransaction, 0, len(pending))
+	for _, tx := range pending {
+		connection.Pending = append(connection.Pending, tx)
+	}
+	sort.Slice(connection.Pending, func(i, j int) bool {
+		return connection.Pending[i].Date.Before(connection.Pending[j].Date)
+	})
+	result.Pending = len(connection.Pending)
+
+	return result, nil
+}
+
+// applyPage keeps a page's pending transactions in pending and drops the ones that
+// posted or that the provider removed. It returns the page's posted transactions.
+func applyPage(pending map[string]models.PendingBankTransaction, page *connectors.Page) []connectors.Transaction {
+	var posted []connectors.Transaction
+	for _, tx := range page.Transactions {
+		if tx.Pending {
+			pending[tx.ID] = models.PendingBankTransaction{
+				ID:          tx.ID,
+				Date:        tx.Date,
+				Description: tx.Description,
+				Payee:       tx.Payee,
+				Amount:      tx.Amount,
+			}
+			continue
+		}
+		delete(pending, tx.ID)
+		if tx.PendingID != "" {
+			delete(pending, tx.PendingID)
+		}
+		posted = append(posted, tx)
+	}
+	for _, id := range page.Removed {
+		delete(pending, id)
+	}
+	return posted
+}
+
+// syncBatches turns posted transactions into expenses and incomes per month, applying
+// the user's categorization rules like imports do. It also returns how many
+// transactions were skipped as already synced or zero.
+func (bs *BankSyncService) syncBatches(ctx context.Context, connection *models.BankConnection, posted []connectors.Transaction) ([]MonthlyEntries, int, error) {
+	userID := connection.UserID.Hex()
+	if len(posted) == 0 {
+		return nil, 0, nil
+	}
+
+	externalIDs := make([]string, 0, len(posted))
+	for _, tx := range posted {
+		externalIDs = append(externalIDs, bankExternalID(connection, tx.ID))
+	}
+	existing, err := bs.budgetService.FindExistingExternalIDs(ctx, userID, externalIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ruleSet, err := bs.ruleService.LoadRuleSet(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skipped := 0
+	byMonth := map[int]*MonthlyEntries{}
+	for i, tx := range posted {
+		externalID := externalIDs[i]
+		if tx.Amount == 0 || existing[externalID] {
+			skipped++
+			continue
+		}
+		existing[externalID] = true
+
+		date := truncateDay(tx.Date)
+		year, month := date.Year(), int(date.Month())
+		key := monthIndex(year, month)
+		batch, ok := byMonth[key]
+		if !ok {
+			batch = &MonthlyEntries{Year: year, Month: month}
+			byMonth[key] = batch
+		}
+
+		title := strings.TrimSpace(tx.Description)
+		if title == "" {
+			title = strings.TrimSpace(tx.Payee)
+		}
+		if tx.Amount > 0 {
+			batch.Incomes = append(batch.Incomes, models.Income{
+				Title:      title,
+				Amount:     tx.Amount,
+				Date:       &date,
+				AccountID:  connection.AccountID,
+				ExternalID: externalID,
+			})
+			continue
+		}
+
+		expense := models.Expense{
+			Title:      title,
+			Amount:     math.Abs(tx.Amount),
+			Merchant:   strings.TrimSpace(tx.Payee),
+			Date:       &date,
+			AccountID:  connection.AccountID,
+			ExternalID: externalID,
+		}
+		ruleSet.Apply(&expense)
+		batch.Expenses = append(batch.Expenses, expense)
+	}
+
+	batches := make([]MonthlyEntries, 0, len(byMonth))
+	for _, batch := range byMonth {
+		batches = append(batches, *batch)
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return monthIndex(batches[i].Year, batches[i].Month) < monthIndex(batches[j].Year, batches[j].Month)
+	})
+
+	return batches, skipped, nil
+}
+
+// bankExternalID namespaces a provider's transaction ID so it can't collide with
+// IDs from statement imports or other providers
+func bankExternalID(connection *models.BankConnection, transactionID string) string {
+	return strings.ToUpper(connection.Provider) + ":" + connection.ExternalAccountID + ":" + transactionID
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/huxxnainali/finance-app/internal/connectors"
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func TestApplyPage(t *testing.T) {
+	hold := connectors.Transaction{ID: "p1", Amount: -20, Pending: true}
+	fuel := connectors.Transaction{ID: "p2", Amount: -45, Pending: true}
+
+	tests := []struct {
+		name        string
+		pending     []string
+		page        connectors.Page
+		wantPending []string
+		wantPosted  []string
+	}{
+		{
+			name:        "pending transactions are kept",
+			page:        connectors.Page{Transactions: []connectors.Transaction{hold, fuel}},
+			wantPending: []string{"p1", "p2"},
+		},
+		{
+			name:        "a posted transaction replaces its pending one",
+			pending:     []string{"p1", "p2"},
+			page:        connectors.Page{Transactions: []connectors.Transaction{{ID: "t1", PendingID: "p1", Amount: -20}}},
+			wantPending: []string{"p2"},
+			wantPosted:  []string{"t1"},
+		},
+		{
+			name:        "a pending transaction that posts under its own ID",
+			pending:     []string{"p1"},
+			page:        connectors.Page{Transactions: []connectors.Transaction{{ID: "p1", Amount: -20}}},
+			wantPosted:  []string{"p1"},
+			wantPending: []string{},
+		},
+		{
+			name:        "a cancelled hold is removed",
+			pending:     []string{"p1", "p2"},
+			page:        connectors.Page{Removed: []string{"p1"}},
+			wantPending: []string{"p2"},
+		},
+		{
+			name:        "a hold added and cancelled in the same page",
+			page:        connectors.Page{Transactions: []connectors.Transaction{hold}, Removed: []string{"p1"}},
+			wantPending: []string{},
+		},
+		{
+			name:        "removing an unknown transaction changes nothing",
+			pending:     []string{"p2"},
+			page:        connectors.Page{Removed: []string{"gone"}},
+			wantPending: []string{"p2"},
+		},
+	}
+
+	for _, tt := range tests {
+		pending := map[string]models.PendingBankTransaction{}
+		for _, id := range tt.pending {
+			pending[id] = models.PendingBankTransaction{ID: id}
+		}
+
+		posted := applyPage(pending, &tt.page)
+
+		var gotPending []string
+		for id := range pending {
+			gotPending = append(gotPending, id)
+		}
+		sort.Strings(gotPending)
+		var gotPosted []string
+		for _, tx := range posted {
+			gotPosted = append(gotPosted, tx.ID)
+		}
+
+		if strings.Join(gotPending, ",") != strings.Join(tt.wantPending, ",") || strings.Join(gotPosted, ",") != strings.Join(tt.wantPosted, ",") {
+			t.Errorf("%s: pending %v, posted %v; want %v, %v", tt.name, gotPending, gotPosted, tt.wantPending, tt.wantPosted)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultBillReminderDays is how many days before the due date a reminder is sent
+	DefaultBillReminderDays = 3
+	// MaxBillReminderDays caps how early reminders can be sent
+	MaxBillReminderDays = 60
+	// DefaultUpcomingBillDays is the window of the upcoming bills listing
+	DefaultUpcomingBillDays = 30
+	// MaxUpcomingBillDays caps the window of the upcoming bills listing
+	MaxUpcomingBillDays = 366
+	// maxAutopayCatchUp limits how many missed due dates one autopay run pays per bill
+	maxAutopayCatchUp = 12
+)
+
+// BillReminder delivers the reminder that a bill is due soon
+type BillReminder interface {
+	RemindBill(ctx context.Context, bill models.Bill) error
+}
+
+type BillService struct {
+	collection     *mongo.Collection
+	budgetService  *BudgetService
+	accountService *AccountService
+	reminder       BillReminder
+}
+
+func NewBillService(db *mongo.Database, budgetService *BudgetService, accountService *AccountService, reminder BillReminder) *BillService {
+	collection := db.Collection("bills")
+
+	// Create index on userId and the next due date
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "dueDate", Value: 1},
+		},
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &BillService{
+		collection:     collection,
+		budgetService:  budgetService,
+		accountService: accountService,
+		reminder:       reminder,
+	}
+}
+
+func validateBillRequest(req models.BillRequest) error {
+	if strings.TrimSpace(req.Payee) == "" {
+		return fmt.Errorf("payee is required")
+	}
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if req.DueDate.IsZero() {
+		return fmt.Errorf("due date is required")
+	}
+	if req.ReminderDays != nil && (*req.ReminderDays < 0 || *req.ReminderDays > MaxBillReminderDays) {
+		return fmt.Errorf("reminder days must be between 0 and %d", MaxBillReminderDays)
+	}
+
+	switch req.Recurrence {
+	case models.BillRecurrenceNone, models.BillRecurrenceWeekly, models.BillRecurrenceMonthly,
+		models.BillRecurrenceQuarterly, models.BillRecurrenceYearly:
+	default:
+		return fmt.Errorf("invalid recurrence, must be NONE, WEEKLY, MONTHLY, QUARTERLY or YEARLY")
+	}
+
+	return nil
+}
+
+// CreateBill creates a new bill
+func (bs *BillService) CreateBill(ctx context.Context, userID string, req models.BillRequest) (*models.Bill, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateBillRequest(req); err != nil {
+		return nil, err
+	}
+
+	accountID, err := bs.accountService.ResolveAccountID(ctx, userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderDays := DefaultBillReminderDays
+	if req.ReminderDays != nil {
+		reminderDays = *req.ReminderDays
+	}
+
+	now := time.Now()
+	dueDate := truncateDay(req.DueDate)
+	bill := &models.Bill{
+		ID:           primitive.NewObjectID(),
+		UserID:       objID,
+		Payee:        strings.TrimSpace(req.Payee),
+		Amount:       req.Amount,
+		Estimated:    req.Estimated,
+		Category:     req.Category,
+		AccountID:    accountID,
+		DueDate:      dueDate,
+		DueDay:       dueDate.Day(),
+		Recurrence:   req.Recurrence,
+		Autopay:      req.Autopay,
+		ReminderDays: reminderDays,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if _, err := bs.collection.InsertOne(ctx, bill); err != nil {
+		return nil, err
+	}
+
+	return bill, nil
+}
+
+// GetBills lists the user's bills by next due date, active ones first
+func (bs *BillService) GetBills(ctx context.Context, userID string) ([]models.Bill, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "active", Value: -1}, {Key: "dueDate", Value: 1}})
+	cursor, err := bs.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bills := []models.Bill{}
+	if err := cursor.All(ctx, &bills); err != nil {
+		return nil, err
+	}
+
+	return bills, nil
+}
+
+// GetBillByID retrieves a bill
+func (bs *BillService) GetBillByID(ctx context.Context, userID, billID string) (*models.Bill, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	billObjID, err := primitive.ObjectIDFromHex(billID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bill ID")
+	}
+
+	bill := &models.Bill{}
+	err = bs.collection.FindOne(ctx, bson.M{
+		"_id":    billObjID,
+		"userId": objID,
+	}).Decode(bill)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("bill not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return bill, nil
+}
+
+// UpdateBill updates a bill. Changing the due date re-arms its reminder.
+func (bs *BillService) UpdateBill(ctx context.Context, userID, billID string, req models.BillRequest) (*models.Bill, error) {
+	bill, err := bs.GetBillByID(ctx, userID, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBillRequest(req); err != nil {
+		return nil, err
+	}
+
+	accountID, err := bs.accountService.ResolveAccountID(ctx, userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderDays := bill.ReminderDays
+	if req.ReminderDays != nil {
+		reminderDays = *req.ReminderDays
+	}
+
+	dueDate := truncateDay(req.DueDate)
+	set := bson.M{
+		"payee":        strings.TrimSpace(req.Payee),
+		"amount":       req.Amount,
+		"estimated":    req.Estimated,
+		"category":     req.Category,
+		"accountId":    accountID,
+		"dueDate":      dueDate,
+		"dueDay":       dueDate.Day(),
+		"recurrence":   req.Recurrence,
+		"autopay":      req.Autopay,
+		"reminderDays": reminderDays,
+		"updatedAt":    time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if !dueDate.Equal(bill.DueDate) {
+		set["active"] = true
+		update["$unset"] = bson.M{"remindedFor": ""}
+	}
+
+	err = bs.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": bill.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(bill)
+	if err != nil {
+		return nil, err
+	}
+
+	return bill, nil
+}
+
+// DeleteBill deletes a bill. Expenses created by paying it are kept.
+func (bs *BillService) DeleteBill(ctx context.Context, userID, billID string) error {
+	bill, err := bs.GetBillByID(ctx, userID, billID)
+	if err != nil {
+		return err
+	}
+
+	_, err = bs.collection.DeleteOne(ctx, bson.M{"_id": bill.ID})
+	return err
+}
+
+// GetUpcomingBills lists every occurrence of the user's active bills due within the
+// next days, including occurrences that are already overdue
+func (bs *BillService) GetUpcomingBills(ctx context.Context, userID string, days int) (*models.UpcomingBillsResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	if days < 0 || days > MaxUpcomingBillDays {
+		return nil, fmt.Errorf("days must be between 0 and %d", MaxUpcomingBillDays)
+	}
+
+	today := truncateDay(time.Now())
+	to := today.AddDate(0, 0, days)
+
+	cursor, err := bs.collection.Find(ctx, bson.M{
+		"userId":  objID,
+		"active":  true,
+		"dueDate": bson.M{"$lte": to},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bills []models.Bill
+	if err := cursor.All(ctx, &bills); err != nil {
+		return nil, err
+	}
+
+	response := &models.UpcomingBillsResponse{
+		To:    to,
+		Bills: []models.UpcomingBill{},
+	}
+	for _, bill := range bills {
+		for due := bill.DueDate; !due.After(to); due = nextDueDate(bill, due) {
+			response.Bills = append(response.Bills, models.UpcomingBill{
+				Bill:         bill,
+				DueDate:      due,
+				DaysUntilDue: int(due.Sub(today).Hours() / 24),
+				Overdue:      due.Before(today),
+			})
+			response.Total += bill.Amount
+			if bill.Recurrence == models.BillRecurrenceNone {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(response.Bills, func(i, j int) bool {
+		return response.Bills[i].DueDate.Before(response.Bills[j].DueDate)
+	})
+
+	return response, nil
+}
+
+// PayBill marks the bill's current due date paid and records the payment as an expense
+func (bs *BillService) PayBill(ctx context.Context, userID, billID string, req models.BillPaymentRequest) (*models.BillPaymentResponse, error) {
+	bill, err := bs.GetBillByID(ctx, userID, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := bill.Amount
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			return nil, fmt.Errorf("amount must be positive")
+		}
+		amount = *req.Amount
+	}
+
+	date := truncateDay(time.Now())
+	if req.Date != nil {
+		date = truncateDay(*req.Date)
+	}
+
+	return bs.payBill(ctx, bill, amount, date)
+}
+
+// payBill advances the bill past its current due date, then adds the expense. The bill
+// is advanced first so concurrent payments of the same due date can't both succeed;
+// if the expense can't be written the bill is put back.
+func (bs *BillService) payBill(ctx context.Context, bill *models.Bill, amount float64, date time.Time) (*models.BillPaymentResponse, error) {
+	if !bill.Active {
+		return nil, fmt.Errorf("bill is already paid")
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"lastPaidAt": date,
+		"updatedAt":  now,
+	}
+	if bill.Recurrence == models.BillRecurrenceNone {
+		set["active"] = false
+	} else {
+		set["dueDate"] = nextDueDate(*bill, bill.DueDate)
+	}
+
+	paid := &models.Bill{}
+	err := bs.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": bill.ID, "active": true, "dueDate": bill.DueDate},
+		bson.M{"$set": set, "$unset": bson.M{"remindedFor": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(paid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("bill is already paid")
+		}
+		return nil, err
+	}
+
+	expense := models.Expense{
+		ID:        primitive.NewObjectID(),
+		Title:     bill.Payee,
+		Amount:    amount,
+		Category:  bill.Category,
+		Merchant:  bill.Payee,
+		Date:      &date,
+		AccountID: bill.AccountID,
+		CreatedAt: now,
+	}
+	if _, err := bs.budgetService.AddExpense(ctx, bill.UserID.Hex(), date.Year(), int(date.Month()), expense); err != nil {
+		restore := bson.M{"active": true, "dueDate": bill.DueDate, "updatedAt": now}
+		update := bson.M{"$set": restore}
+		if bill.LastPaidAt != nil {
+			restore["lastPaidAt"] = bill.LastPaidAt
+		} else {
+			update["$unset"] = bson.M{"lastPaidAt": ""}
+		}
+		bs.collection.UpdateOne(ctx, bson.M{"_id": bill.ID}, update)
+		return nil, err
+	}
+
+	return &models.BillPaymentResponse{
+		Bill:    *paid,
+		Expense: expense,
+		Year:    date.Year(),
+		Month:   int(date.Month()),
+	}, nil
+}
+
+// PayAutopayBills pays every autopay bill that has come due, catching up on due
+// dates missed while the server was down
+func (bs *BillService) PayAutopayBills(ctx context.Context) error {
+	today := truncateDay(time.Now())
+	cursor, err := bs.collection.Find(ctx, bson.M{
+		"active":  true,
+		"autopay": true,
+		"dueDate": bson.M{"$lte": today},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var bills []models.Bill
+	if err := cursor.All(ctx, &bills); err != nil {
+		return err
+	}
+
+	for _, bill := range bills {
+		for i := 0; i < maxAutopayCatchUp && bill.Active && !bill.DueDate.After(today); i++ {
+			payment, err := bs.payBill(ctx, &bill, bill.Amount, bill.DueDate)
+			if err != nil {
+				log.Printf("Autopay of bill %s failed: %v", bill.ID.Hex(), err)
+				break
+			}
+			bill = payment.Bill
+		}
+	}
+
+	return nil
+}
+
+// SendBillReminders reminds users of bills coming due within their reminder window.
+// Each due date is reminded once; a failed delivery is retried on the next run.
+func (bs *BillService) SendBillReminders(ctx context.Context) error {
+	today := truncateDay(time.Now())
+	cursor, err := bs.collection.Find(ctx, bson.M{
+		"active":       true,
+		"reminderDays": bson.M{"$gt": 0},
+		"dueDate": bson.M{
+			"$gte": today,
+			"$lte": today.AddDate(0, 0, MaxBillReminderDays),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var bills []models.Bill
+	if err := cursor.All(ctx, &bills); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, bill := range bills {
+		if today.Before(bill.DueDate.AddDate(0, 0, -bill.ReminderDays)) {
+			continue
+		}
+		if bill.RemindedFor != nil && bill.RemindedFor.Equal(bill.DueDate) {
+			continue
+		}
+
+		// Claim the reminder first so several servers don't all send it
+		result, err := bs.collection.UpdateOne(ctx,
+			bson.M{"_id": bill.ID, "dueDate": bill.DueDate, "remindedFor": bson.M{"$ne": bill.DueDate}},
+			bson.M{"$set": bson.M{"remindedFor": bill.DueDate}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		if err := bs.reminder.RemindBill(ctx, bill); err != nil {
+			log.Printf("Reminder for bill %s failed: %v", bill.ID.Hex(), err)
+			bs.collection.UpdateOne(ctx,
+				bson.M{"_id": bill.ID, "remindedFor": bill.DueDate},
+				bson.M{"$unset": bson.M{"remindedFor": ""}},
+			)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d bill reminders failed", failed)
+	}
+	return nil
+}
+
+// nextDueDate returns the due date after due. Monthly, quarterly and yearly bills keep
+// their day of the month, falling back to the month's last day when it is shorter.
+func nextDueDate(bill models.Bill, due time.Time) time.Time {
+	months := 0
+	switch bill.Recurrence {
+	case models.BillRecurrenceWeekly:
+		return due.AddDate(0, 0, 7)
+	case models.BillRecurrenceMonthly:
+		months = 1
+	case models.BillRecurrenceQuarterly:
+		months = 3
+	case models.BillRecurrenceYearly:
+		months = 12
+	default:
+		return due
+	}
+
+	day := bill.DueDay
+	if day == 0 {
+		day = due.Day()
+	}
+
+	first := time.Date(due.Year(), due.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func TestNextDueDate(t *testing.T) {
+	tests := []struct {
+		recurrence models.BillRecurrence
+		dueDay     int
+		due        time.Time
+		want       time.Time
+	}{
+		{models.BillRecurrenceWeekly, 0, day(2026, 12, 28), day(2027, 1, 4)},
+		{models.BillRecurrenceMonthly, 15, day(2026, 1, 15), day(2026, 2, 15)},
+		{models.BillRecurrenceMonthly, 31, day(2026, 1, 31), day(2026, 2, 28)},
+		// The day of the month comes back after a shorter month
+		{models.BillRecurrenceMonthly, 31, day(2026, 2, 28), day(2026, 3, 31)},
+		{models.BillRecurrenceMonthly, 31, day(2028, 1, 31), day(2028, 2, 29)},
+		// Without a day of the month the due date's own day is kept
+		{models.BillRecurrenceMonthly, 0, day(2026, 2, 28), day(2026, 3, 28)},
+		{models.BillRecurrenceMonthly, 10, day(2026, 12, 10), day(2027, 1, 10)},
+		{models.BillRecurrenceQuarterly, 31, day(2026, 11, 30), day(2027, 2, 28)},
+		{models.BillRecurrenceYearly, 29, day(2028, 2, 29), day(2029, 2, 28)},
+		{models.BillRecurrenceYearly, 29, day(2029, 2, 28), day(2030, 2, 28)},
+		{models.BillRecurrenceNone, 0, day(2026, 5, 1), day(2026, 5, 1)},
+	}
+
+	for _, tt := range tests {
+		bill := models.Bill{Recurrence: tt.recurrence, DueDay: tt.dueDay}
+		if got := nextDueDate(bill, tt.due); !got.Equal(tt.want) {
+			t.Errorf("nextDueDate(%s on day %d, %s) = %s, want %s",
+				tt.recurrence, tt.dueDay, tt.due.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/huxxnainali/finance-app/internal/models"
@@ -12,11 +14,19 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// BudgetWatcher is told about every budget changed by SetBaseIncome, AddExpense or
+// UpdateExpense
+type BudgetWatcher interface {
+	BudgetChanged(ctx context.Context, userID string, budget *models.MonthlyBudget)
+}
+
 type BudgetService struct {
-	collection *mongo.Collection
+	collection      *mongo.Collection
+	claimCollection *mongo.Collection
+	watcher         BudgetWatcher
 }
 
-func NewBudgetService(db *mongo.Database) *BudgetService {
+func NewBudgetService(db *mongo.Database, watcher BudgetWatcher) *BudgetService {
 	collection := db.Collection("monthly_budgets")
 
 	// Create unique index on userId, year, month
@@ -30,11 +40,27 @@ func NewBudgetService(db *mongo.Database) *BudgetService {
 	}
 	collection.Indexes().CreateOne(context.Background(), indexModel)
 
-	return &BudgetService{collection: collection}
+	// Create indexes for external ID claims: one claim per ID, removed once expired
+	claimCollection := db.Collection("external_id_claims")
+	claimIndexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "externalId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "claimId", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	claimCollection.Indexes().CreateMany(context.Background(), claimIndexModels)
+
+	return &BudgetService{collection: collection, claimCollection: claimCollection, watcher: watcher}
 }
 
-// GetOrCreateBudget retrieves a budget or creates one if it doesn't exist
-func (bs *BudgetService) GetOrCreateBudget(ctx context.Context, userID string, year, month int) (*models.MonthlyBudget, error) {
+// GetBudget retrieves a budget without writing anything.
+// If the budget doesn't exist an empty, unsaved budget is returned.
+func (bs *BudgetService) GetBudget(ctx context.Context, userID string, year, month int) (*models.MonthlyBudget, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID")
@@ -47,32 +73,60 @@ func (bs *BudgetService) GetOrCreateBudget(ctx context.Context, userID string, y
 		"month":  month,
 	}).Decode(budget)
 
-	// If budget doesn't exist, create it
 	if err == mongo.ErrNoDocuments {
-		budget = &models.MonthlyBudget{
-			ID:         primitive.NewObjectID(),
+		return &models.MonthlyBudget{
 			UserID:     objID,
 			Year:       year,
 			Month:      month,
 			BaseIncome: nil,
 			Expenses:   []models.Expense{},
-			CreatedAt:  time.Now(),
-			UpdatedAt:  time.Now(),
-		}
+		}, nil
+	}
 
-		_, err := bs.collection.InsertOne(ctx, budget)
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// upsertBudget applies an update to a month's budget, creating the budget on first write.
+// defaults holds initial values for the fields the update itself doesn't write.
+// Concurrent upserts of the same month can collide on the unique index, in which case
+// the update is retried against the document the other writer created.
+func (bs *BudgetService) upsertBudget(ctx context.Context, userID primitive.ObjectID, year, month int, update, defaults bson.M) (*models.MonthlyBudget, error) {
+	filter := bson.M{
+		"userId": userID,
+		"year":   year,
+		"month":  month,
+	}
 
-		return budget, nil
+	setOnInsert := bson.M{
+		"userId":    userID,
+		"year":      year,
+		"month":     month,
+		"createdAt": time.Now(),
+	}
+	for field, value := range defaults {
+		setOnInsert[field] = value
 	}
+	update["$setOnInsert"] = setOnInsert
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
 
+	result := &models.MonthlyBudget{}
+	err := bs.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	if mongo.IsDuplicateKeyError(err) {
+		result = &models.MonthlyBudget{}
+		err = bs.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return budget, nil
+	return result, nil
 }
 
 // SetBaseIncome sets or updates the base income for a month
@@ -88,39 +142,20 @@ func (bs *BudgetService) SetBaseIncome(
 		return nil, fmt.Errorf("invalid user ID")
 	}
 
-	now := time.Now()
-
-	filter := bson.M{
-		"userId": objID,
-		"year":   year,
-		"month":  month,
-	}
-
-	update := bson.M{
+	budget, err := bs.upsertBudget(ctx, objID, year, month, bson.M{
 		"$set": bson.M{
 			"baseIncome": amount,
-			"updatedAt":  now,
+			"updatedAt":  time.Now(),
 		},
-		"$setOnInsert": bson.M{
-			"userId":    objID,
-			"year":      year,
-			"month":     month,
-			"expenses":  []models.Expense{},
-			"createdAt": now,
-		},
-	}
-
-	opts := options.FindOneAndUpdate().
-		SetUpsert(true).
-		SetReturnDocument(options.After)
-
-	result := &models.MonthlyBudget{}
-	err = bs.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	}, bson.M{
+		"expenses": []models.Expense{},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return result, nil
+	bs.budgetChanged(ctx, userID, budget)
+	return budget, nil
 }
 
 // AddExpense adds an expense to a budget
@@ -138,41 +173,187 @@ func (bs *BudgetService) AddExpense(ctx context.Context, userID string, year, mo
 		expense.CreatedAt = time.Now()
 	}
 
-	// Get or create budget
-	_, err = bs.GetOrCreateBudget(ctx, userID, year, month)
+	budget, err := bs.upsertBudget(ctx, objID, year, month, bson.M{
+		"$push": bson.M{
+			"expenses": expense,
+		},
+		"$set": bson.M{
+			"updatedAt": time.Now(),
+		},
+	}, bson.M{
+		"baseIncome": nil,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Add expense
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	result := &models.MonthlyBudget{}
-	err = bs.collection.FindOneAndUpdate(ctx,
+	bs.budgetChanged(ctx, userID, budget)
+	return budget, nil
+}
+
+// MonthlyEntries groups expenses and incomes that belong to the same month's budget
+type MonthlyEntries struct {
+	Year     int
+	Month    int
+	Expenses []models.Expense
+	Incomes  []models.Income
+}
+
+// AddEntriesBulk adds many expenses and incomes across months in a single bulk write,
+// creating budgets on first write like AddExpense does
+func (bs *BudgetService) AddEntriesBulk(ctx context.Context, userID string, batches []MonthlyEntries) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(batches))
+	for _, batch := range batches {
+		if len(batch.Expenses) == 0 && len(batch.Incomes) == 0 {
+			continue
+		}
+
+		push := bson.M{}
+		defaults := bson.M{
+			"userId":     objID,
+			"year":       batch.Year,
+			"month":      batch.Month,
+			"baseIncome": nil,
+			"createdAt":  now,
+		}
+
+		if len(batch.Expenses) > 0 {
+			for i := range batch.Expenses {
+				if batch.Expenses[i].ID == primitive.NilObjectID {
+					batch.Expenses[i].ID = primitive.NewObjectID()
+				}
+				if batch.Expenses[i].CreatedAt.IsZero() {
+					batch.Expenses[i].CreatedAt = now
+				}
+			}
+			push["expenses"] = bson.M{"$each": batch.Expenses}
+		} else {
+			defaults["expenses"] = []models.Expense{}
+		}
+
+		if len(batch.Incomes) > 0 {
+			for i := range batch.Incomes {
+				if batch.Incomes[i].ID == primitive.NilObjectID {
+					batch.Incomes[i].ID = primitive.NewObjectID()
+				}
+				if batch.Incomes[i].CreatedAt.IsZero() {
+					batch.Incomes[i].CreatedAt = now
+				}
+			}
+			push["incomes"] = bson.M{"$each": batch.Incomes}
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"userId": objID,
+				"year":   batch.Year,
+				"month":  batch.Month,
+			}).
+			SetUpdate(bson.M{
+				"$push": push,
+				"$set": bson.M{
+					"updatedAt": now,
+				},
+				"$setOnInsert": defaults,
+			}).
+			SetUpsert(true))
+	}
+
+	if len(writes) == 0 {
+		return nil
+	}
+
+	_, err = bs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+
+	// Upserts racing with another writer fail on the unique index; retry just those
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && mongo.IsDuplicateKeyError(err) {
+		retries := make([]mongo.WriteModel, 0, len(bulkErr.WriteErrors))
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				return err
+			}
+			retries = append(retries, writes[writeErr.Index])
+		}
+		_, err = bs.collection.BulkWrite(ctx, retries, options.BulkWrite().SetOrdered(false))
+	}
+
+	return err
+}
+
+// DeleteImportedEntries removes every expense and income created by an import
+func (bs *BudgetService) DeleteImportedEntries(ctx context.Context, userID string, importID primitive.ObjectID) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	_, err = bs.collection.UpdateMany(ctx,
 		bson.M{
 			"userId": objID,
-			"year":   year,
-			"month":  month,
+			"$or": bson.A{
+				bson.M{"expenses.importId": importID},
+				bson.M{"incomes.importId": importID},
+			},
 		},
 		bson.M{
-			"$push": bson.M{
-				"expenses": expense,
+			"$pull": bson.M{
+				"expenses": bson.M{"importId": importID},
+				"incomes":  bson.M{"importId": importID},
 			},
 			"$set": bson.M{
 				"updatedAt": time.Now(),
 			},
 		},
-		opts,
-	).Decode(result)
+	)
 
+	return err
+}
+
+// FindExistingExternalIDs returns which of the given external IDs (e.g. a bank's FITID)
+// already belong to an expense or income of the user
+func (bs *BudgetService) FindExistingExternalIDs(ctx context.Context, userID string, externalIDs []string) (map[string]bool, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid user ID")
 	}
 
-	return result, nil
+	existing := map[string]bool{}
+	if len(externalIDs) == 0 {
+		return existing, nil
+	}
+
+	wanted := make(map[string]bool, len(externalIDs))
+	for _, id := range externalIDs {
+		wanted[id] = true
+	}
+
+	for _, field := range []string{"expenses.externalId", "incomes.externalId"} {
+		values, err := bs.collection.Distinct(ctx, field, bson.M{
+			"userId": objID,
+			field:    bson.M{"$in": externalIDs},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if id, ok := value.(string); ok && wanted[id] {
+				existing[id] = true
+			}
+		}
+	}
+
+	return existing, nil
 }
 
-// UpdateExpense updates an existing expense
-func (bs *BudgetService) UpdateExpense(ctx context.Context, userID, expenseID string, updatedExpense models.Expense) (*models.MonthlyBudget, error) {
+// UpdateExpense updates an existing expense; fields the update leaves out keep their value
+func (bs *BudgetService) UpdateExpense(ctx context.Context, userID, expenseID string, update models.ExpenseUpdate) (*models.MonthlyBudget, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID")
@@ -183,33 +364,61 @@ func (bs *BudgetService) UpdateExpense(ctx context.Context, userID, expenseID st
 		return nil, fmt.Errorf("invalid expense ID")
 	}
 
+	set := bson.M{
+		"expenses.$.title":  update.Title,
+		"expenses.$.amount": update.Amount,
+		"updatedAt":         time.Now(),
+	}
+	if update.Category != nil {
+		set["expenses.$.category"] = *update.Category
+	}
+	if update.Tags != nil {
+		set["expenses.$.tags"] = update.Tags
+	}
+	if update.Merchant != nil {
+		set["expenses.$.merchant"] = *update.Merchant
+	}
+	if update.PaymentMethod != nil {
+		set["expenses.$.paymentMethod"] = *update.PaymentMethod
+	}
+	changes := bson.M{"$set": set}
+	if update.SetAccount {
+		if update.AccountID != nil {
+			set["expenses.$.accountId"] = update.AccountID
+		} else {
+			changes["$unset"] = bson.M{"expenses.$.accountId": ""}
+		}
+	}
+
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	result := &models.MonthlyBudget{}
 	err = bs.collection.FindOneAndUpdate(ctx,
 		bson.M{
-			"userId":       objID,
-			"expenses._id": expenseObjID,
-		},
-		bson.M{
-			"$set": bson.M{
-				"expenses.$.title":  updatedExpense.Title,
-				"expenses.$.amount": updatedExpense.Amount,
-				"updatedAt":         time.Now(),
-			},
+			"userId":   objID,
+			"expenses": unlockedExpense(expenseObjID),
 		},
+		changes,
 		opts,
 	).Decode(result)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("expense not found or doesn't belong to user")
+			return nil, bs.expenseNotFoundError(ctx, objID, expenseObjID)
 		}
 		return nil, err
 	}
 
+	bs.budgetChanged(ctx, userID, result)
 	return result, nil
 }
 
+// budgetChanged tells the watcher, if any, about a changed budget
+func (bs *BudgetService) budgetChanged(ctx context.Context, userID string, budget *models.MonthlyBudget) {
+	if bs.watcher != nil {
+		bs.watcher.BudgetChanged(ctx, userID, budget)
+	}
+}
+
 // DeleteExpense deletes an expense from a budget
 func (bs *BudgetService) DeleteExpense(ctx context.Context, userID, expenseID string) (*models.MonthlyBudget, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
@@ -226,8 +435,8 @@ func (bs *BudgetService) DeleteExpense(ctx context.Context, userID, expenseID st
 	result := &models.MonthlyBudget{}
 	err = bs.collection.FindOneAndUpdate(ctx,
 		bson.M{
-			"userId":       objID,
-			"expenses._id": expenseObjID,
+			"userId":   objID,
+			"expenses": unlockedExpense(expenseObjID),
 		},
 		bson.M{
 			"$pull": bson.M{
@@ -242,7 +451,7 @@ func (bs *BudgetService) DeleteExpense(ctx context.Context, userID, expenseID st
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("expense not found or doesn't belong to user")
+			return nil, bs.expenseNotFoundError(ctx, objID, expenseObjID)
 		}
 		return nil, err
 	}
@@ -250,13 +459,710 @@ func (bs *BudgetService) DeleteExpense(ctx context.Context, userID, expenseID st
 	return result, nil
 }
 
-// CalculateRemaining calculates the remaining balance
-func CalculateRemaining(baseIncome *float64, expenses []models.Expense) *float64 {
-	if baseIncome == nil {
+// unlockedExpense matches an expense that hasn't been locked by a completed reconciliation
+func unlockedExpense(expenseID primitive.ObjectID) bson.M {
+	return bson.M{"$elemMatch": bson.M{
+		"_id":              expenseID,
+		"reconciliationId": bson.M{"$exists": false},
+	}}
+}
+
+// expenseNotFoundError tells a missing expense apart from a locked one
+func (bs *BudgetService) expenseNotFoundError(ctx context.Context, userID, expenseID primitive.ObjectID) error {
+	count, err := bs.collection.CountDocuments(ctx, bson.M{
+		"userId":       userID,
+		"expenses._id": expenseID,
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("expense is reconciled and locked")
+	}
+	return fmt.Errorf("expense not found or doesn't belong to user")
+}
+
+// FindExpense finds the budget holding an expense and the expense itself
+func (bs *BudgetService) FindExpense(ctx context.Context, userID, expenseID string) (*models.MonthlyBudget, *models.Expense, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid user ID")
+	}
+
+	expenseObjID, err := primitive.ObjectIDFromHex(expenseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid expense ID")
+	}
+
+	budget := &models.MonthlyBudget{}
+	err = bs.collection.FindOne(ctx, bson.M{
+		"userId":       objID,
+		"expenses._id": expenseObjID,
+	}).Decode(budget)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, fmt.Errorf("expense not found or doesn't belong to user")
+		}
+		return nil, nil, err
+	}
+
+	for i := range budget.Expenses {
+		if budget.Expenses[i].ID == expenseObjID {
+			return budget, &budget.Expenses[i], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("expense not found or doesn't belong to user")
+}
+
+// CheckExpenseUnlocked verifies that an expense exists and isn't locked by a completed
+// reconciliation
+func (bs *BudgetService) CheckExpenseUnlocked(ctx context.Context, userID string, expenseID primitive.ObjectID) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	count, err := bs.collection.CountDocuments(ctx, bson.M{
+		"userId":   objID,
+		"expenses": unlockedExpense(expenseID),
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return bs.expenseNotFoundError(ctx, objID, expenseID)
+	}
+	return nil
+}
+
+// SetExpenseFields sets the given fields of an unlocked expense, keyed by their bson
+// names, and leaves its other fields as they are
+func (bs *BudgetService) SetExpenseFields(ctx context.Context, userID string, expenseID primitive.ObjectID, fields bson.M) (*models.MonthlyBudget, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	for field, value := range fields {
+		set["expenses.$."+field] = value
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	result := &models.MonthlyBudget{}
+	err = bs.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"userId":   objID,
+			"expenses": unlockedExpense(expenseID),
+		},
+		bson.M{"$set": set},
+		opts,
+	).Decode(result)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, bs.expenseNotFoundError(ctx, objID, expenseID)
+		}
+		return nil, err
+	}
+
+	bs.budgetChanged(ctx, userID, result)
+	return result, nil
+}
+
+// AccountBalances sums the incomes minus the expenses linked to each of a user's accounts
+func (bs *BudgetService) AccountBalances(ctx context.Context, userID string) (map[primitive.ObjectID]float64, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": objID}}},
+		{{Key: "$project", Value: bson.M{
+			"entries": bson.M{"$concatArrays": bson.A{
+				bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$expenses", bson.A{}}},
+					"as":    "e",
+					"in":    bson.M{"accountId": "$$e.accountId", "amount": bson.M{"$multiply": bson.A{"$$e.amount", -1}}},
+				}},
+				bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$incomes", bson.A{}}},
+					"as":    "i",
+					"in":    bson.M{"accountId": "$$i.accountId", "amount": "$$i.amount"},
+				}},
+			}},
+		}}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$match", Value: bson.M{"entries.accountId": bson.M{"$type": "objectId"}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$entries.accountId",
+			"total": bson.M{"$sum": "$entries.amount"},
+		}}},
+	}
+
+	cursor, err := bs.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		AccountID primitive.ObjectID `bson:"_id"`
+		Total     float64            `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[primitive.ObjectID]float64, len(rows))
+	for _, row := range rows {
+		balances[row.AccountID] = row.Total
+	}
+
+	return balances, nil
+}
+
+// FindAccountEntries lists the expenses and incomes linked to an account as signed movements
+func (bs *BudgetService) FindAccountEntries(ctx context.Context, userID string, accountID primitive.ObjectID) ([]models.AccountEntry, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	filter := bson.M{
+		"userId": objID,
+		"$or": bson.A{
+			bson.M{"expenses.accountId": accountID},
+			bson.M{"incomes.accountId": accountID},
+		},
+	}
+
+	var entries []models.AccountEntry
+	err = bs.iterateBudgets(ctx, filter, func(budget *models.MonthlyBudget) error {
+		for _, income := range budget.Incomes {
+			if income.AccountID == nil || *income.AccountID != accountID {
+				continue
+			}
+			entries = append(entries, models.AccountEntry{
+				Kind:        models.AccountEntryIncome,
+				ID:          income.ID.Hex(),
+				Date:        entryDate(budget.Year, budget.Month, income.Date, income.CreatedAt),
+				Year:        budget.Year,
+				Month:       budget.Month,
+				Description: income.Title,
+				Amount:      income.Amount,
+				Cleared:     income.Cleared,
+				Reconciled:  income.ReconciliationID != nil,
+			})
+		}
+		for _, expense := range budget.Expenses {
+			if expense.AccountID == nil || *expense.AccountID != accountID {
+				continue
+			}
+			entries = append(entries, models.AccountEntry{
+				Kind:        models.AccountEntryExpense,
+				ID:          expense.ID.Hex(),
+				Date:        entryDate(budget.Year, budget.Month, expense.Date, expense.CreatedAt),
+				Year:        budget.Year,
+				Month:       budget.Month,
+				Description: expense.Title,
+				Amount:      -expense.Amount,
+				Cleared:     expense.Cleared,
+				Reconciled:  expense.ReconciliationID != nil,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// SetEntriesCleared marks the given expenses and incomes of an account as cleared or
+// uncleared. Entries locked by a completed reconciliation are left alone.
+// It returns how many budgets were changed.
+func (bs *BudgetService) SetEntriesCleared(ctx context.Context, userID string, accountID primitive.ObjectID, entryIDs []primitive.ObjectID, cleared bool) (int64, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID")
+	}
+
+	var modified int64
+	for _, field := range []string{"expenses", "incomes"} {
+		result, err := bs.collection.UpdateMany(ctx,
+			bson.M{
+				"userId":             objID,
+				field + "._id":       bson.M{"$in": entryIDs},
+				field + ".accountId": accountID,
+			},
+			bson.M{
+				"$set": bson.M{
+					field + ".$[entry].cleared": cleared,
+					"updatedAt":                 time.Now(),
+				},
+			},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+				bson.M{
+					"entry._id":              bson.M{"$in": entryIDs},
+					"entry.accountId":        accountID,
+					"entry.reconciliationId": bson.M{"$exists": false},
+				},
+			}}),
+		)
+		if err != nil {
+			return modified, err
+		}
+		modified += result.ModifiedCount
+	}
+
+	return modified, nil
+}
+
+// LockClearedEntries stamps the given expenses and incomes of an account that are
+// cleared and not yet reconciled with a reconciliation, which locks them against changes
+func (bs *BudgetService) LockClearedEntries(ctx context.Context, userID string, accountID, reconciliationID primitive.ObjectID, entryIDs []primitive.ObjectID) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	for _, field := range []string{"expenses", "incomes"} {
+		_, err := bs.collection.UpdateMany(ctx,
+			bson.M{
+				"userId": objID,
+				field: bson.M{"$elemMatch": bson.M{
+					"_id":       bson.M{"$in": entryIDs},
+					"accountId": accountID,
+					"cleared":   true,
+				}},
+			},
+			bson.M{
+				"$set": bson.M{
+					field + ".$[entry].reconciliationId": reconciliationID,
+					"updatedAt":                          time.Now(),
+				},
+			},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+				bson.M{
+					"entry._id":              bson.M{"$in": entryIDs},
+					"entry.accountId":        accountID,
+					"entry.cleared":          true,
+					"entry.reconciliationId": bson.M{"$exists": false},
+				},
+			}}),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasReconciledImportEntries reports whether any entry created by an import is locked by a reconciliation
+func (bs *BudgetService) HasReconciledImportEntries(ctx context.Context, userID string, importID primitive.ObjectID) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, fmt.Errorf("invalid user ID")
+	}
+
+	locked := bson.M{"importId": importID, "reconciliationId": bson.M{"$exists": true}}
+	count, err := bs.collection.CountDocuments(ctx, bson.M{
+		"userId": objID,
+		"$or": bson.A{
+			bson.M{"expenses": bson.M{"$elemMatch": locked}},
+			bson.M{"incomes": bson.M{"$elemMatch": locked}},
+		},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// HasAccountEntries reports whether any expense or income is linked to an account
+func (bs *BudgetService) HasAccountEntries(ctx context.Context, userID string, accountID primitive.ObjectID) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, fmt.Errorf("invalid user ID")
+	}
+
+	count, err := bs.collection.CountDocuments(ctx, bson.M{
+		"userId": objID,
+		"$or": bson.A{
+			bson.M{"expenses.accountId": accountID},
+			bson.M{"incomes.accountId": accountID},
+		},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// IterateBudgets calls fn for every budget of a user, oldest month first,
+// without loading them all into memory at once
+func (bs *BudgetService) IterateBudgets(ctx context.Context, userID string, fn func(budget *models.MonthlyBudget) error) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	return bs.iterateBudgets(ctx, bson.M{"userId": objID}, fn)
+}
+
+// IterateBudgetRange calls fn for every stored budget of a user within a month range, oldest month first
+func (bs *BudgetService) IterateBudgetRange(ctx context.Context, userID string, fromYear, fromMonth, toYear, toMonth int, fn func(budget *models.MonthlyBudget) error) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	return bs.iterateBudgets(ctx, monthRangeFilter(objID, fromYear, fromMonth, toYear, toMonth), fn)
+}
+
+func (bs *BudgetService) iterateBudgets(ctx context.Context, filter bson.M, fn func(budget *models.MonthlyBudget) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "year", Value: 1}, {Key: "month", Value: 1}})
+	cursor, err := bs.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		budget := &models.MonthlyBudget{}
+		if err := cursor.Decode(budget); err != nil {
+			return err
+		}
+		if err := fn(budget); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// UpdateExpenseLabelsBulk writes the title, category and tags of many expenses in one
+// round trip and returns how many were updated. Expenses locked by a reconciliation
+// are left as they are.
+func (bs *BudgetService) UpdateExpenseLabelsBulk(ctx context.Context, userID string, expenses []models.Expense) (int, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID")
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(expenses))
+	for _, expense := range expenses {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"userId":   objID,
+				"expenses": unlockedExpense(expense.ID),
+			}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"expenses.$.title":    expense.Title,
+					"expenses.$.category": expense.Category,
+					"expenses.$.tags":     expense.Tags,
+					"updatedAt":           now,
+				},
+			}))
+	}
+
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	result, err := bs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.MatchedCount), nil
+}
+
+// DeleteEmptyBudgets removes budgets that hold no base income, expenses or incomes.
+// Such budgets carry no information; reads return the same empty budget without them.
+func (bs *BudgetService) DeleteEmptyBudgets(ctx context.Context) (int64, error) {
+	result, err := bs.collection.DeleteMany(ctx, bson.M{
+		"baseIncome": nil,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"expenses": bson.M{"$exists": false}},
+				bson.M{"expenses": bson.M{"$size": 0}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"incomes": bson.M{"$exists": false}},
+				bson.M{"incomes": bson.M{"$size": 0}},
+			}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// UncategorizedCategory is the category reported for expenses without one
+const UncategorizedCategory = "Uncategorized"
+
+// MaxSummaryMonths limits how many months a single summary may span
+const MaxSummaryMonths = 120
+
+// monthIndex converts a year and month into a sequential month number
+func monthIndex(year, month int) int {
+	return year*12 + month - 1
+}
+
+// monthRangeFilter builds a filter matching a user's budgets between two months (inclusive)
+func monthRangeFilter(userID primitive.ObjectID, fromYear, fromMonth, toYear, toMonth int) bson.M {
+	if fromYear == toYear {
+		return bson.M{
+			"userId": userID,
+			"year":   fromYear,
+			"month":  bson.M{"$gte": fromMonth, "$lte": toMonth},
+		}
+	}
+
+	return bson.M{
+		"userId": userID,
+		"$or": bson.A{
+			bson.M{"year": fromYear, "month": bson.M{"$gte": fromMonth}},
+			bson.M{"year": bson.M{"$gt": fromYear, "$lt": toYear}},
+			bson.M{"year": toYear, "month": bson.M{"$lte": toMonth}},
+		},
+	}
+}
+
+// GetBudgetSummary summarizes income and spending for every month in a range (inclusive).
+// It only reads existing budgets; months without a budget are reported as empty.
+func (bs *BudgetService) GetBudgetSummary(ctx context.Context, userID string, fromYear, fromMonth, toYear, toMonth int) (*models.BudgetSummaryResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	from := monthIndex(fromYear, fromMonth)
+	to := monthIndex(toYear, toMonth)
+	if from > to {
+		return nil, fmt.Errorf("start month must not be after end month")
+	}
+	if to-from+1 > MaxSummaryMonths {
+		return nil, fmt.Errorf("summary cannot span more than %d months", MaxSummaryMonths)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: monthRangeFilter(objID, fromYear, fromMonth, toYear, toMonth)}},
+		{{Key: "$addFields", Value: bson.M{
+			"otherIncome": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$incomes.amount", bson.A{}}}},
+			"incomeCount": bson.M{"$size": bson.M{"$ifNull": bson.A{"$incomes", bson.A{}}}},
+		}}},
+		{{Key: "$unwind", Value: bson.M{
+			"path":                       "$expenses",
+			"preserveNullAndEmptyArrays": true,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"year":  "$year",
+				"month": "$month",
+				"category": bson.M{"$cond": bson.A{
+					bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$expenses.category", ""}}, bson.A{""}}},
+					UncategorizedCategory,
+					"$expenses.category",
+				}},
+			},
+			"baseIncome":  bson.M{"$first": "$baseIncome"},
+			"otherIncome": bson.M{"$first": "$otherIncome"},
+			"incomeCount": bson.M{"$first": "$incomeCount"},
+			"total":       bson.M{"$sum": bson.M{"$ifNull": bson.A{"$expenses.amount", 0}}},
+			"count": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$ifNull": bson.A{"$expenses._id", false}}, 1, 0,
+			}}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"year": "$_id.year", "month": "$_id.month"},
+			"baseIncome":  bson.M{"$first": "$baseIncome"},
+			"otherIncome": bson.M{"$first": "$otherIncome"},
+			"incomeCount": bson.M{"$first": "$incomeCount"},
+			"expenses":    bson.M{"$sum": "$total"},
+			"categories": bson.M{"$push": bson.M{
+				"category": "$_id.category",
+				"total":    "$total",
+				"count":    "$count",
+			}},
+		}}},
+	}
+
+	cursor, err := bs.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID struct {
+			Year  int `bson:"year"`
+			Month int `bson:"month"`
+		} `bson:"_id"`
+		BaseIncome  *float64               `bson:"baseIncome"`
+		OtherIncome float64                `bson:"otherIncome"`
+		IncomeCount int                    `bson:"incomeCount"`
+		Expenses    float64                `bson:"expenses"`
+		Categories  []models.CategoryTotal `bson:"categories"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	byMonth := make(map[int]int, len(rows))
+	for i, row := range rows {
+		byMonth[monthIndex(row.ID.Year, row.ID.Month)] = i
+	}
+
+	summary := &models.BudgetSummaryResponse{
+		FromYear:  fromYear,
+		FromMonth: fromMonth,
+		ToYear:    toYear,
+		ToMonth:   toMonth,
+		Months:    make([]models.MonthSummary, 0, to-from+1),
+	}
+	categoryTotals := map[string]*models.CategoryTotal{}
+
+	for idx := from; idx <= to; idx++ {
+		month := models.MonthSummary{
+			Year:       idx / 12,
+			Month:      idx%12 + 1,
+			Categories: []models.CategoryTotal{},
+		}
+
+		if i, ok := byMonth[idx]; ok {
+			row := rows[i]
+			if row.BaseIncome != nil || row.IncomeCount > 0 {
+				income := row.OtherIncome
+				if row.BaseIncome != nil {
+					income += *row.BaseIncome
+				}
+				month.Income = &income
+			}
+			month.Expenses = row.Expenses
+			for _, category := range row.Categories {
+				if category.Count == 0 {
+					continue
+				}
+				month.Categories = append(month.Categories, category)
+
+				total, ok := categoryTotals[category.Category]
+				if !ok {
+					total = &models.CategoryTotal{Category: category.Category}
+					categoryTotals[category.Category] = total
+				}
+				total.Total += category.Total
+				total.Count += category.Count
+			}
+			sortCategoryTotals(month.Categories)
+		}
+
+		if month.Income != nil {
+			remaining := *month.Income - month.Expenses
+			month.Remaining = &remaining
+			month.SavingsRate = savingsRate(*month.Income, remaining)
+			summary.Totals.Income += *month.Income
+		}
+		summary.Totals.Expenses += month.Expenses
+
+		summary.Months = append(summary.Months, month)
+	}
+
+	summary.Totals.Remaining = summary.Totals.Income - summary.Totals.Expenses
+	summary.Totals.SavingsRate = savingsRate(summary.Totals.Income, summary.Totals.Remaining)
+	summary.Totals.Categories = make([]models.CategoryTotal, 0, len(categoryTotals))
+	for _, total := range categoryTotals {
+		summary.Totals.Categories = append(summary.Totals.Categories, *total)
+	}
+	sortCategoryTotals(summary.Totals.Categories)
+
+	return summary, nil
+}
+
+// savingsRate returns the share of income left over, or nil when there is no income
+func savingsRate(income, remaining float64) *float64 {
+	if income <= 0 {
 		return nil
 	}
+	rate := remaining / income
+	return &rate
+}
+
+// sortCategoryTotals orders categories by amount spent, largest first
+func sortCategoryTotals(categories []models.CategoryTotal) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Total != categories[j].Total {
+			return categories[i].Total > categories[j].Total
+		}
+		return categories[i].Category < categories[j].Category
+	})
+}
+
+// entryDate is the day an expense or income happened. Manually entered entries
+// have no date, so their creation time is used if it falls in the budget's month
+// and the first of the month otherwise.
+func entryDate(year, month int, date *time.Time, createdAt time.Time) time.Time {
+	if date != nil {
+		return truncateDay(*date)
+	}
+	if createdAt.Year() == year && int(createdAt.Month()) == month {
+		return truncateDay(createdAt)
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
 
-	total := *baseIncome
+// NewBudgetResponse builds the API representation of a budget, including the remaining balance
+func NewBudgetResponse(budget *models.MonthlyBudget) models.BudgetResponse {
+	incomes := budget.Incomes
+	if incomes == nil {
+		incomes = []models.Income{}
+	}
+	expenses := budget.Expenses
+	if expenses == nil {
+		expenses = []models.Expense{}
+	}
+
+	return models.BudgetResponse{
+		Year:       budget.Year,
+		Month:      budget.Month,
+		BaseIncome: budget.BaseIncome,
+		Incomes:    incomes,
+		Expenses:   expenses,
+		Remaining:  CalculateRemaining(budget.BaseIncome, incomes, expenses),
+	}
+}
+
+// CalculateRemaining calculates the remaining balance.
+// It is nil when the month has neither a base income nor any other income.
+func CalculateRemaining(baseIncome *float64, incomes []models.Income, expenses []models.Expense) *float64 {
+	if baseIncome == nil && len(incomes) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	if baseIncome != nil {
+		total = *baseIncome
+	}
+	for _, income := range incomes {
+		total += income.Amount
+	}
 	for _, expense := range expenses {
 		total -= expense.Amount
 	}
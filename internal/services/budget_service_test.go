@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteEmptyBudgetsKeepsIncomeOnlyMonths(t *testing.T) {
+	db := testDatabase(t)
+	bs := NewBudgetService(db, nil)
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID()
+	now := time.Now()
+	budgets := []interface{}{
+		models.MonthlyBudget{ID: primitive.NewObjectID(), UserID: userID, Year: 2026, Month: 1, Expenses: []models.Expense{}, CreatedAt: now, UpdatedAt: now},
+		models.MonthlyBudget{ID: primitive.NewObjectID(), UserID: userID, Year: 2026, Month: 2, Expenses: []models.Expense{}, CreatedAt: now, UpdatedAt: now,
+			Incomes: []models.Income{{ID: primitive.NewObjectID(), Title: "Salary", Amount: 1000, CreatedAt: now}}},
+		models.MonthlyBudget{ID: primitive.NewObjectID(), UserID: userID, Year: 2026, Month: 3, CreatedAt: now, UpdatedAt: now,
+			Expenses: []models.Expense{{ID: primitive.NewObjectID(), Title: "Rent", Amount: 500, CreatedAt: now}}},
+	}
+	if _, err := bs.collection.InsertMany(ctx, budgets); err != nil {
+		t.Fatalf("insert budgets: %v", err)
+	}
+
+	deleted, err := bs.DeleteEmptyBudgets(ctx)
+	if err != nil {
+		t.Fatalf("DeleteEmptyBudgets: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d budgets, want 1", deleted)
+	}
+
+	remaining, err := bs.collection.CountDocuments(ctx, bson.M{"userId": userID, "month": bson.M{"$in": bson.A{2, 3}}})
+	if err != nil {
+		t.Fatalf("count budgets: %v", err)
+	}
+	if remaining != 2 {
+		t.Errorf("%d of the income and expense months remain, want 2", remaining)
+	}
+}
+
+func TestUpdateExpenseKeepsFieldsLeftOut(t *testing.T) {
+	db := testDatabase(t)
+	bs := NewBudgetService(db, nil)
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID().Hex()
+	accountID := primitive.NewObjectID()
+	expense := models.Expense{
+		ID:            primitive.NewObjectID(),
+		Title:         "Groceries",
+		Amount:        40,
+		Category:      "Food",
+		Tags:          []string{"weekly"},
+		Merchant:      "Market",
+		PaymentMethod: "card",
+		AccountID:     &accountID,
+	}
+	if _, err := bs.AddExpense(ctx, userID, 2026, 1, expense); err != nil {
+		t.Fatalf("AddExpense: %v", err)
+	}
+
+	budget, err := bs.UpdateExpense(ctx, userID, expense.ID.Hex(), models.ExpenseUpdate{Title: "Groceries and drinks", Amount: 45})
+	if err != nil {
+		t.Fatalf("UpdateExpense: %v", err)
+	}
+	updated := budget.Expenses[0]
+	if updated.Title != "Groceries and drinks" || updated.Amount != 45 {
+		t.Errorf("title and amount = %q, %v, want the update's", updated.Title, updated.Amount)
+	}
+	if updated.Category != "Food" || len(updated.Tags) != 1 || updated.Merchant != "Market" || updated.PaymentMethod != "card" {
+		t.Errorf("fields left out changed: %+v", updated)
+	}
+	if updated.AccountID == nil || *updated.AccountID != accountID {
+		t.Errorf("accountId = %v, want %v", updated.AccountID, accountID)
+	}
+
+	budget, err = bs.UpdateExpense(ctx, userID, expense.ID.Hex(), models.ExpenseUpdate{Title: "Groceries", Amount: 45, SetAccount: true})
+	if err != nil {
+		t.Fatalf("UpdateExpense unlinking the account: %v", err)
+	}
+	if budget.Expenses[0].AccountID != nil {
+		t.Errorf("accountId = %v after unlinking, want none", budget.Expenses[0].AccountID)
+	}
+}
+
+func TestUpdateExpenseLabelsBulkSkipsLockedExpenses(t *testing.T) {
+	db := testDatabase(t)
+	bs := NewBudgetService(db, nil)
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID().Hex()
+	reconciliationID := primitive.NewObjectID()
+	open := models.Expense{ID: primitive.NewObjectID(), Title: "STARBUCKS 1234", Amount: 3.5}
+	locked := models.Expense{ID: primitive.NewObjectID(), Title: "STARBUCKS 5678", Amount: 4, ReconciliationID: &reconciliationID}
+	for _, expense := range []models.Expense{open, locked} {
+		if _, err := bs.AddExpense(ctx, userID, 2026, 1, expense); err != nil {
+			t.Fatalf("AddExpense: %v", err)
+		}
+	}
+
+	open.Title, locked.Title = "Coffee", "Coffee"
+	updated, err := bs.UpdateExpenseLabelsBulk(ctx, userID, []models.Expense{open, locked})
+	if err != nil {
+		t.Fatalf("UpdateExpenseLabelsBulk: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("updated %d expenses, want 1", updated)
+	}
+
+	budget, err := bs.GetBudget(ctx, userID, 2026, 1)
+	if err != nil {
+		t.Fatalf("GetBudget: %v", err)
+	}
+	for _, expense := range budget.Expenses {
+		if expense.ID == locked.ID && expense.Title != "STARBUCKS 5678" {
+			t.Errorf("locked expense title = %q, want it unchanged", expense.Title)
+		}
+		if expense.ID == open.ID && expense.Title != "Coffee" {
+			t.Errorf("open expense title = %q, want Coffee", expense.Title)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ContactService struct {
+	collection *mongo.Collection
+	// fundCollection is read to keep the funds linked to a contact in step with it
+	fundCollection *mongo.Collection
+}
+
+func NewContactService(db *mongo.Database) *ContactService {
+	collection := db.Collection("contacts")
+
+	// A user has one contact per name
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "nameKey", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+
+	fundCollection := db.Collection("funds")
+	fundCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "contactId", Value: 1},
+		},
+	})
+
+	return &ContactService{
+		collection:     collection,
+		fundCollection: fundCollection,
+	}
+}
+
+// contactName trims a name and collapses the whitespace inside it
+func contactName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// contactNameKey is what two names must share to belong to the same contact
+func contactNameKey(name string) string {
+	return strings.ToLower(contactName(name))
+}
+
+func validateContactRequest(req models.ContactRequest) error {
+	if contactName(req.Name) == "" {
+		return fmt.Errorf("contact name is required")
+	}
+	if email := strings.TrimSpace(req.Email); email != "" && !strings.Contains(email, "@") {
+		return fmt.Errorf("invalid email address")
+	}
+
+	return nil
+}
+
+// CreateContact creates a new contact
+func (cs *ContactService) CreateContact(ctx context.Context, userID string, req models.ContactRequest) (*models.Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateContactRequest(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	contact := &models.Contact{
+		ID:        primitive.NewObjectID(),
+		UserID:    objID,
+		Name:      contactName(req.Name),
+		NameKey:   contactNameKey(req.Name),
+		Email:     strings.TrimSpace(req.Email),
+		Phone:     strings.TrimSpace(req.Phone),
+		Notes:     req.Notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := cs.collection.InsertOne(ctx, contact); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("a contact with this name already exists")
+		}
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// GetContacts lists the user's contacts by name
+func (cs *ContactService) GetContacts(ctx context.Context, userID string) ([]models.Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "nameKey", Value: 1}})
+	cursor, err := cs.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	contacts := []models.Contact{}
+	if err := cursor.All(ctx, &contacts); err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// GetContactByID retrieves a contact
+func (cs *ContactService) GetContactByID(ctx context.Context, userID, contactID string) (*models.Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	contactObjID, err := primitive.ObjectIDFromHex(contactID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contact ID")
+	}
+
+	contact := &models.Contact{}
+	err = cs.collection.FindOne(ctx, bson.M{
+		"_id":    contactObjID,
+		"userId": objID,
+	}).Decode(contact)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("contact not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// UpdateContact updates a contact. A new name is copied onto the contact's funds.
+func (cs *ContactService) UpdateContact(ctx context.Context, userID, contactID string, req models.ContactRequest) (*models.Contact, error) {
+	contact, err := cs.GetContactByID(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateContactRequest(req); err != nil {
+		return nil, err
+	}
+
+	err = cs.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": contact.ID},
+		bson.M{"$set": bson.M{
+			"name":      contactName(req.Name),
+			"nameKey":   contactNameKey(req.Name),
+			"email":     strings.TrimSpace(req.Email),
+			"phone":     strings.TrimSpace(req.Phone),
+			"notes":     req.Notes,
+			"updatedAt": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(contact)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("a contact with this name already exists")
+		}
+		return nil, err
+	}
+
+	_, err = cs.fundCollection.UpdateMany(ctx,
+		bson.M{"userId": contact.UserID, "contactId": contact.ID},
+		bson.M{"$set": bson.M{"personName": contact.Name}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// DeleteContact deletes a contact that no fund is linked to
+func (cs *ContactService) DeleteContact(ctx context.Context, userID, contactID string) error {
+	contact, err := cs.GetContactByID(ctx, userID, contactID)
+	if err != nil {
+		return err
+	}
+
+	funds, err := cs.fundCollection.CountDocuments(ctx, bson.M{
+		"userId":    contact.UserID,
+		"contactId": contact.ID,
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if funds > 0 {
+		return fmt.Errorf("contact has linked funds")
+	}
+
+	_, err = cs.collection.DeleteOne(ctx, bson.M{"_id": contact.ID})
+	return err
+}
+
+// ResolveContact returns the contact a fund is for: the contact with contactID if
+// one is given, or else the user's contact named name, which is created if missing
+func (cs *ContactService) ResolveContact(ctx context.Context, userID, contactID, name string) (*models.Contact, error) {
+	if contactID != "" {
+		return cs.GetContactByID(ctx, userID, contactID)
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	if contactName(name) == "" {
+		return nil, fmt.Errorf("person name or contact ID is required")
+	}
+
+	return cs.findOrCreateContact(ctx, objID, name)
+}
+
+// findOrCreateContact upserts on the name key, so concurrent calls with the same name
+// end up with one contact
+func (cs *ContactService) findOrCreateContact(ctx context.Context, userID primitive.ObjectID, name string) (*models.Contact, error) {
+	now := time.Now()
+	contact := &models.Contact{}
+	err := cs.collection.FindOneAndUpdate(ctx,
+		bson.M{"userId": userID, "nameKey": contactNameKey(name)},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"name":      contactName(name),
+			"createdAt": now,
+			"updatedAt": now,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(contact)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+// LinkFunds links the funds created before contacts existed to the contact named by
+// their person name, creating the contacts as needed. It only touches unlinked funds,
+// so running it on every start is safe. It returns how many funds were linked.
+func (cs *ContactService) LinkFunds(ctx context.Context) (int, error) {
+	cursor, err := cs.fundCollection.Find(ctx, bson.M{"contactId": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var funds []models.Fund
+	if err := cursor.All(ctx, &funds); err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, fund := range funds {
+		name := fund.PersonName
+		if contactName(name) == "" {
+			name = "Unknown"
+		}
+
+		contact, err := cs.findOrCreateContact(ctx, fund.UserID, name)
+		if err != nil {
+			return linked, err
+		}
+
+		_, err = cs.fundCollection.UpdateOne(ctx,
+			bson.M{"_id": fund.ID, "contactId": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{
+				"contactId":  contact.ID,
+				"personName": contact.Name,
+			}},
+		)
+		if err != nil {
+			return linked, err
+		}
+		linked++
+	}
+
+	return linked, nil
+}
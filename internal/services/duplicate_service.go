@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultDuplicateMaxDays is how many days apart two expenses may be to count as duplicates
+	DefaultDuplicateMaxDays = 3
+	// MaxDuplicateMaxDays caps the date window a client can ask for
+	MaxDuplicateMaxDays = 31
+	// DefaultDuplicateMinConfidence hides weak candidates unless asked for
+	DefaultDuplicateMinConfidence = 0.6
+)
+
+type DuplicateService struct {
+	collection    *mongo.Collection
+	budgetService *BudgetService
+}
+
+func NewDuplicateService(db *mongo.Database, budgetService *BudgetService) *DuplicateService {
+	collection := db.Collection("duplicate_dismissals")
+
+	// Create unique index so a pair is only dismissed once
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "expenseA", Value: 1},
+			{Key: "expenseB", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &DuplicateService{
+		collection:    collection,
+		budgetService: budgetService,
+	}
+}
+
+// duplicateEntry is an expense with the values used to compare it
+type duplicateEntry struct {
+	year    int
+	month   int
+	expense models.Expense
+	date    time.Time
+	title   string
+}
+
+// FindDuplicates lists pairs of the user's expenses that look like the same purchase:
+// the same amount, dates at most maxDays apart and similar titles
+func (ds *DuplicateService) FindDuplicates(ctx context.Context, userID string, maxDays int, minConfidence float64) ([]models.DuplicateCandidate, error) {
+	if maxDays < 0 || maxDays > MaxDuplicateMaxDays {
+		return nil, fmt.Errorf("maxDays must be between 0 and %d", MaxDuplicateMaxDays)
+	}
+	if minConfidence < 0 || minConfidence > 1 {
+		return nil, fmt.Errorf("minConfidence must be between 0 and 1")
+	}
+
+	dismissed, err := ds.dismissedPairs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only expenses with the same amount can be duplicates, so compare within amount groups
+	byAmount := map[int64][]duplicateEntry{}
+	err = ds.budgetService.IterateBudgets(ctx, userID, func(budget *models.MonthlyBudget) error {
+		for _, expense := range budget.Expenses {
+			cents := int64(math.Round(expense.Amount * 100))
+			byAmount[cents] = append(byAmount[cents], duplicateEntry{
+				year:    budget.Year,
+				month:   budget.Month,
+				expense: expense,
+				date:    entryDate(budget.Year, budget.Month, expense.Date, expense.CreatedAt),
+				title:   normalizeTitle(expense.Title),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for _, group := range byAmount {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].date.Before(group[j].date)
+		})
+
+		for i := range group {
+			for j := i + 1; j < len(group); j++ {
+				daysApart := daysBetween(group[i].date, group[j].date)
+				if daysApart > maxDays {
+					break
+				}
+
+				a, b := group[i].expense, group[j].expense
+				if dismissed[pairKey(a.ID, b.ID)] {
+					continue
+				}
+				// Two different bank references are two real transactions
+				if a.ExternalID != "" && b.ExternalID != "" && a.ExternalID != b.ExternalID {
+					continue
+				}
+
+				similarity := titleSimilarity(group[i].title, group[j].title)
+				confidence := duplicateConfidence(daysApart, maxDays, similarity)
+				if a.ExternalID != "" && a.ExternalID == b.ExternalID {
+					confidence = 1
+				}
+				if confidence < minConfidence {
+					continue
+				}
+
+				candidates = append(candidates, models.DuplicateCandidate{
+					Expenses: []models.DuplicateExpense{
+						{Year: group[i].year, Month: group[i].month, Expense: a},
+						{Year: group[j].year, Month: group[j].month, Expense: b},
+					},
+					Confidence:      confidence,
+					DaysApart:       daysApart,
+					TitleSimilarity: math.Round(similarity*100) / 100,
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Expenses[0].Expense.ID.Hex() < candidates[j].Expenses[0].Expense.ID.Hex()
+	})
+
+	return candidates, nil
+}
+
+// MergeDuplicates keeps one expense, fills its missing details from the duplicate
+// and deletes the duplicate. Only the filled fields of the kept expense are written,
+// and neither expense may be locked by a reconciliation.
+func (ds *DuplicateService) MergeDuplicates(ctx context.Context, userID string, req models.DuplicateMergeRequest) (*models.MonthlyBudget, error) {
+	if req.KeepExpenseID == "" || req.DuplicateExpenseID == "" {
+		return nil, fmt.Errorf("keepExpenseId and duplicateExpenseId are required")
+	}
+	if req.KeepExpenseID == req.DuplicateExpenseID {
+		return nil, fmt.Errorf("cannot merge an expense with itself")
+	}
+
+	keepBudget, keep, err := ds.budgetService.FindExpense(ctx, userID, req.KeepExpenseID)
+	if err != nil {
+		return nil, err
+	}
+	duplicateBudget, duplicate, err := ds.budgetService.FindExpense(ctx, userID, req.DuplicateExpenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := mergeExpenses(*keep, *duplicate, keepBudget.Year, keepBudget.Month)
+
+	// Check the kept expense can still be changed so the duplicate isn't deleted for nothing
+	if err := ds.budgetService.CheckExpenseUnlocked(ctx, userID, keep.ID); err != nil {
+		return nil, err
+	}
+
+	// Delete first so a failure can't leave the duplicate's bank reference on both expenses
+	if _, err := ds.budgetService.DeleteExpense(ctx, userID, req.DuplicateExpenseID); err != nil {
+		return nil, err
+	}
+
+	budget, err := ds.budgetService.SetExpenseFields(ctx, userID, keep.ID, fields)
+	if err != nil {
+		// Put the duplicate back so a failed merge loses nothing
+		if _, restoreErr := ds.budgetService.AddExpense(ctx, userID, duplicateBudget.Year, duplicateBudget.Month, *duplicate); restoreErr != nil {
+			log.Printf("Restoring duplicate expense %s failed: %v", duplicate.ID.Hex(), restoreErr)
+		}
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// DismissDuplicate remembers that two expenses are not duplicates so they aren't suggested again
+func (ds *DuplicateService) DismissDuplicate(ctx context.Context, userID string, req models.DuplicateDismissRequest) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	if len(req.ExpenseIDs) != 2 {
+		return fmt.Errorf("exactly two expense IDs are required")
+	}
+
+	ids := make([]primitive.ObjectID, 0, 2)
+	for _, expenseID := range req.ExpenseIDs {
+		if _, _, err := ds.budgetService.FindExpense(ctx, userID, expenseID); err != nil {
+			return err
+		}
+		id, _ := primitive.ObjectIDFromHex(expenseID)
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] {
+		return fmt.Errorf("expense IDs must be different")
+	}
+
+	a, b := orderedPair(ids[0], ids[1])
+	_, err = ds.collection.UpdateOne(ctx,
+		bson.M{
+			"userId":   objID,
+			"expenseA": a,
+			"expenseB": b,
+		},
+		bson.M{
+			"$setOnInsert": bson.M{
+				"createdAt": time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// Dismissed concurrently
+		return nil
+	}
+
+	return err
+}
+
+// dismissedPairs loads the pairs a user has dismissed
+func (ds *DuplicateService) dismissedPairs(ctx context.Context, userID string) (map[string]bool, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	cursor, err := ds.collection.Find(ctx, bson.M{"userId": objID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var dismissals []models.DuplicateDismissal
+	if err := cursor.All(ctx, &dismissals); err != nil {
+		return nil, err
+	}
+
+	dismissed := make(map[string]bool, len(dismissals))
+	for _, dismissal := range dismissals {
+		dismissed[pairKey(dismissal.ExpenseA, dismissal.ExpenseB)] = true
+	}
+
+	return dismissed, nil
+}
+
+// mergeExpenses lists the fields of keep, by their bson names, that are filled from
+// duplicate's details. The kept expense only takes a date in the month of its budget.
+func mergeExpenses(keep, duplicate models.Expense, year, month int) bson.M {
+	fields := bson.M{}
+	if keep.Category == "" && duplicate.Category != "" {
+		fields["category"] = duplicate.Category
+	}
+	if keep.Merchant == "" && duplicate.Merchant != "" {
+		fields["merchant"] = duplicate.Merchant
+	}
+	if keep.PaymentMethod == "" && duplicate.PaymentMethod != "" {
+		fields["paymentMethod"] = duplicate.PaymentMethod
+	}
+	if keep.Date == nil && duplicate.Date != nil &&
+		duplicate.Date.Year() == year && int(duplicate.Date.Month()) == month {
+		fields["date"] = duplicate.Date
+	}
+	if keep.AccountID == nil && duplicate.AccountID != nil {
+		fields["accountId"] = duplicate.AccountID
+	}
+	// Keep the bank reference so the transaction isn't imported again, but not the
+	// import: rolling it back would delete the kept expense with it
+	if keep.ExternalID == "" && duplicate.ExternalID != "" {
+		fields["externalId"] = duplicate.ExternalID
+	}
+
+	tags := append([]string{}, keep.Tags...)
+	for _, tag := range duplicate.Tags {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > len(keep.Tags) {
+		fields["tags"] = tags
+	}
+	return fields
+}
+
+func daysBetween(a, b time.Time) int {
+	days := int(math.Round(b.Sub(a).Hours() / 24))
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// duplicateConfidence scores an equal-amount pair from how close the dates and titles are
+func duplicateConfidence(daysApart, maxDays int, similarity float64) float64 {
+	dateScore := 1 - float64(daysApart)/float64(maxDays+1)
+	confidence := 0.3 + 0.3*dateScore + 0.4*similarity
+	return math.Round(confidence*100) / 100
+}
+
+// normalizeTitle lowercases a title and drops digits and punctuation so card
+// terminal numbers and reference codes don't hide a match
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsDigit(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// titleSimilarity is the Dice coefficient of the character bigrams of two normalized titles
+func titleSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if len([]rune(a)) < 2 || len([]rune(b)) < 2 {
+		return 0
+	}
+
+	bigrams := func(s string) map[string]int {
+		runes := []rune(s)
+		counts := map[string]int{}
+		for i := 0; i < len(runes)-1; i++ {
+			counts[string(runes[i:i+2])]++
+		}
+		return counts
+	}
+
+	countsA, countsB := bigrams(a), bigrams(b)
+	totalA, totalB, shared := 0, 0, 0
+	for bigram, count := range countsA {
+		totalA += count
+		if other, ok := countsB[bigram]; ok {
+			if other < count {
+				shared += other
+			} else {
+				shared += count
+			}
+		}
+	}
+	for _, count := range countsB {
+		totalB += count
+	}
+
+	return 2 * float64(shared) / float64(totalA+totalB)
+}
+
+func orderedPair(a, b primitive.ObjectID) (primitive.ObjectID, primitive.ObjectID) {
+	if b.Hex() < a.Hex() {
+		return b, a
+	}
+	return a, b
+}
+
+func pairKey(a, b primitive.ObjectID) string {
+	a, b = orderedPair(a, b)
+	return a.Hex() + ":" + b.Hex()
+}
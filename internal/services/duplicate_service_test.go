@@ -0,0 +1,125 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMergeExpenses(t *testing.T) {
+	date := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	accountID := primitive.NewObjectID()
+	importID := primitive.NewObjectID()
+
+	keep := models.Expense{Title: "Coffee", Amount: 4, Category: "Food", Tags: []string{"work"}}
+	duplicate := models.Expense{
+		Title:         "COFFEE SHOP 1234",
+		Amount:        4,
+		Category:      "Drinks",
+		Tags:          []string{"work", "morning"},
+		Merchant:      "Coffee Shop",
+		PaymentMethod: "card",
+		Date:          &date,
+		AccountID:     &accountID,
+		ImportID:      &importID,
+		ExternalID:    "FITID-1",
+	}
+
+	fields := mergeExpenses(keep, duplicate, 2026, 1)
+	if _, ok := fields["title"]; ok {
+		t.Errorf("title was merged, want the kept expense's")
+	}
+	if _, ok := fields["category"]; ok {
+		t.Errorf("category was merged, want the kept expense's")
+	}
+	if fields["merchant"] != "Coffee Shop" || fields["paymentMethod"] != "card" || fields["date"] != &date || fields["accountId"] != &accountID {
+		t.Errorf("missing details weren't copied: %+v", fields)
+	}
+	if tags, _ := fields["tags"].([]string); len(tags) != 2 || tags[0] != "work" || tags[1] != "morning" {
+		t.Errorf("tags = %v, want [work morning]", fields["tags"])
+	}
+	if len(keep.Tags) != 1 {
+		t.Errorf("merging changed the kept expense's tags to %v", keep.Tags)
+	}
+	if fields["externalId"] != "FITID-1" {
+		t.Errorf("externalId = %v, want the duplicate's bank reference", fields["externalId"])
+	}
+	if _, ok := fields["importId"]; ok {
+		t.Errorf("importId was merged, want none so rolling back the import keeps the expense")
+	}
+	for _, field := range []string{"cleared", "reconciliationId"} {
+		if _, ok := fields[field]; ok {
+			t.Errorf("%s was merged, want it left to reconciliations", field)
+		}
+	}
+
+	// A date from another month would put the expense outside its budget
+	if _, ok := mergeExpenses(keep, duplicate, 2025, 12)["date"]; ok {
+		t.Errorf("date from January was merged into a December expense")
+	}
+
+	// Nothing is written when the kept expense is already complete
+	if fields := mergeExpenses(duplicate, keep, 2026, 1); len(fields) != 0 {
+		t.Errorf("complete expense got fields %v", fields)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Coffee Shop", "coffee shop"},
+		{"COFFEE-SHOP #1234", "coffee shop"},
+		{"  Amazon.com*AB12CD  ", "amazon com ab cd"},
+		{"Café", "café"},
+		{"1234", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.title); got != tt.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"coffee", "coffee", 1},
+		{"", "", 1},
+		{"a", "ab", 0},
+		{"night", "nacht", 0.25},
+		{"abc", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		if got := titleSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("titleSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateConfidence(t *testing.T) {
+	tests := []struct {
+		daysApart, maxDays int
+		similarity         float64
+		want               float64
+	}{
+		{0, 3, 1, 1},
+		{0, 3, 0, 0.6},
+		{3, 3, 0, 0.38},
+		{1, 3, 0.5, 0.72},
+	}
+
+	for _, tt := range tests {
+		if got := duplicateConfidence(tt.daysApart, tt.maxDays, tt.similarity); got != tt.want {
+			t.Errorf("duplicateConfidence(%d, %d, %v) = %v, want %v", tt.daysApart, tt.maxDays, tt.similarity, got, tt.want)
+		}
+	}
+}
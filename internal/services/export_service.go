@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/huxxnainali/finance-app/internal/exporters"
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+type ExportService struct {
+	budgetService *BudgetService
+}
+
+func NewExportService(budgetService *BudgetService) *ExportService {
+	return &ExportService{
+		budgetService: budgetService,
+	}
+}
+
+// ExportContentType returns the MIME type and file extension of an export format
+func ExportContentType(format models.ExportFormat) (contentType, extension string, err error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return "text/csv; charset=utf-8", "csv", nil
+	case models.ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", nil
+	case models.ExportFormatJSON:
+		return "application/json", "json", nil
+	default:
+		return "", "", fmt.Errorf("unsupported export format, must be CSV, XLSX or JSON")
+	}
+}
+
+// Export validates an export and returns a function that streams it to a writer.
+// Budgets are read from a cursor one month at a time, so the range isn't capped.
+func (es *ExportService) Export(ctx context.Context, userID string, format models.ExportFormat, fromYear, fromMonth, toYear, toMonth int) (func(w io.Writer) error, error) {
+	if _, _, err := ExportContentType(format); err != nil {
+		return nil, err
+	}
+	if monthIndex(fromYear, fromMonth) > monthIndex(toYear, toMonth) {
+		return nil, fmt.Errorf("start month must not be after end month")
+	}
+
+	return func(w io.Writer) error {
+		var exporter exporters.Exporter
+		switch format {
+		case models.ExportFormatCSV:
+			exporter = exporters.NewCSV(w)
+		case models.ExportFormatXLSX:
+			exporter = exporters.NewXLSX(w)
+		case models.ExportFormatJSON:
+			exporter = exporters.NewJSON(w)
+		}
+
+		err := es.budgetService.IterateBudgetRange(ctx, userID, fromYear, fromMonth, toYear, toMonth, func(budget *models.MonthlyBudget) error {
+			return exporter.WriteBudget(NewBudgetResponse(budget))
+		})
+		if err != nil {
+			return err
+		}
+
+		return exporter.Close()
+	}, nil
+}
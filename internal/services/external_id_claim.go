@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// externalIDClaimLifetime is how long a write holds the external IDs it is writing
+// before they can be claimed again, should it never release them
+const externalIDClaimLifetime = 10 * time.Minute
+
+// externalIDClaim is a write's hold on the external IDs it is about to write,
+// taken with claimExternalIDs
+type externalIDClaim struct {
+	bs *BudgetService
+	id primitive.ObjectID
+}
+
+// claimExternalIDs holds external IDs (e.g. a bank's FITID) of the user while they
+// are written, so that two imports or syncs of the same transactions can't both find
+// them missing and both write them. The unique index on userId and externalId lets
+// only one write claim an ID; the other fails and can be retried. A write checks
+// which IDs already exist only once it holds them.
+func (bs *BudgetService) claimExternalIDs(ctx context.Context, userID string, externalIDs []string) (*externalIDClaim, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	claim := &externalIDClaim{bs: bs, id: primitive.NewObjectID()}
+	now := time.Now()
+
+	seen := map[string]bool{}
+	documents := make([]interface{}, 0, len(externalIDs))
+	for _, externalID := range externalIDs {
+		if externalID == "" || seen[externalID] {
+			continue
+		}
+		seen[externalID] = true
+		documents = append(documents, bson.M{
+			"userId":     objID,
+			"externalId": externalID,
+			"claimId":    claim.id,
+			"expiresAt":  now.Add(externalIDClaimLifetime),
+		})
+	}
+	if len(documents) == 0 {
+		return claim, nil
+	}
+
+	// Claims of writes that never released them don't block anyone, even before the
+	// TTL index gets to them
+	_, err = bs.claimCollection.DeleteMany(ctx, bson.M{
+		"userId":    objID,
+		"expiresAt": bson.M{"$lt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = bs.claimCollection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		claim.release()
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("these transactions are being imported by another request, try again")
+		}
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// release gives the claimed external IDs back once the write is done or undone
+func (c *externalIDClaim) release() {
+	c.bs.claimCollection.DeleteMany(context.Background(), bson.M{"claimId": c.id})
+}
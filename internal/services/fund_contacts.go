@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetContactBalances sums every contact's funds into a net position as of a day. A
+// zero asOf means today. Written-off funds aren't expected to be repaid and don't count.
+func (fs *FundService) GetContactBalances(ctx context.Context, userID string, asOf time.Time) ([]models.ContactBalance, error) {
+	contacts, err := fs.contactService.GetContacts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	funds, err := fs.findFundsWithTransactions(ctx, bson.M{"userId": objID})
+	if err != nil {
+		return nil, err
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = truncateDay(asOf)
+
+	balances := make([]models.ContactBalance, 0, len(contacts))
+	index := make(map[string]int, len(contacts))
+	for _, contact := range contacts {
+		index[contact.ID.Hex()] = len(balances)
+		balances = append(balances, models.ContactBalance{Contact: contact, AsOf: asOf})
+	}
+
+	for i := range funds {
+		k, ok := index[funds[i].ContactID.Hex()]
+		if !ok {
+			continue
+		}
+		addContactFund(&balances[k], fundResponse(&funds[i].Fund, funds[i].Transactions, asOf))
+	}
+
+	for i := range balances {
+		roundContactBalance(&balances[i])
+	}
+
+	return balances, nil
+}
+
+// GetContactBalance sums a contact's funds into a net position as of a day and lists
+// the funds. A zero asOf means today.
+func (fs *FundService) GetContactBalance(ctx context.Context, userID, contactID string, asOf time.Time) (*models.ContactBalance, error) {
+	contact, err := fs.contactService.GetContactByID(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	funds, err := fs.findFundsWithTransactions(ctx, bson.M{
+		"userId":    contact.UserID,
+		"contactId": contact.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = truncateDay(asOf)
+
+	balance := &models.ContactBalance{
+		Contact: *contact,
+		AsOf:    asOf,
+		Funds:   []models.FundResponse{},
+	}
+	for i := range funds {
+		response := fundResponse(&funds[i].Fund, funds[i].Transactions, asOf)
+		addContactFund(balance, response)
+		balance.Funds = append(balance.Funds, response)
+	}
+	roundContactBalance(balance)
+
+	return balance, nil
+}
+
+// addContactFund adds a fund's outstanding amount to its contact's balance
+func addContactFund(balance *models.ContactBalance, fund models.FundResponse) {
+	if fund.Status == models.FundStatusWrittenOff || fund.Outstanding <= 0 {
+		return
+	}
+
+	if fund.Type == models.FundTypeGiven {
+		balance.Given += fund.Outstanding
+	} else {
+		balance.Borrowed += fund.Outstanding
+	}
+	balance.OpenFunds++
+}
+
+func roundContactBalance(balance *models.ContactBalance) {
+	balance.Given = roundCents(balance.Given)
+	balance.Borrowed = roundCents(balance.Borrowed)
+	balance.Net = roundCents(balance.Given - balance.Borrowed)
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultFundReminderDays is how many days before a fund or instalment is due a
+	// reminder is sent
+	DefaultFundReminderDays = 3
+	// MaxLateFeeGraceDays caps the grace period of a late fee rule
+	MaxLateFeeGraceDays = 365
+)
+
+// FundReminder delivers the reminder that a fund or one of its instalments is due soon
+type FundReminder interface {
+	RemindFund(ctx context.Context, fund models.Fund, dueDate time.Time, amount float64) error
+}
+
+func validateDueTerms(req models.FundRequest) error {
+	if req.DueDate != nil && !req.StartDate.IsZero() && truncateDay(*req.DueDate).Before(truncateDay(req.StartDate)) {
+		return fmt.Errorf("due date must not be before the start date")
+	}
+
+	if rule := req.LateFee; rule != nil {
+		if rule.Flat < 0 || rule.Percent < 0 || rule.Percent > 100 {
+			return fmt.Errorf("late fee must have a flat amount of at least 0 and a percentage between 0 and 100")
+		}
+		if rule.Flat == 0 && rule.Percent == 0 {
+			return fmt.Errorf("late fee needs a flat amount or a percentage")
+		}
+		if rule.GraceDays < 0 || rule.GraceDays > MaxLateFeeGraceDays {
+			return fmt.Errorf("grace days must be between 0 and %d", MaxLateFeeGraceDays)
+		}
+		switch rule.Frequency {
+		case "", models.LateFeeOnce, models.LateFeeMonthly:
+		default:
+			return fmt.Errorf("invalid late fee frequency, must be ONCE or MONTHLY")
+		}
+	}
+
+	return nil
+}
+
+// dueTerms returns the due date and late fee rule of a fund request with their
+// defaults applied
+func dueTerms(req models.FundRequest) (*time.Time, *models.LateFeeRule) {
+	var dueDate *time.Time
+	if req.DueDate != nil {
+		day := truncateDay(*req.DueDate)
+		dueDate = &day
+	}
+
+	var rule *models.LateFeeRule
+	if req.LateFee != nil {
+		lateFee := *req.LateFee
+		if lateFee.Frequency == "" {
+			lateFee.Frequency = models.LateFeeOnce
+		}
+		rule = &lateFee
+	}
+
+	return dueDate, rule
+}
+
+// lateFeeCheck is a day on which the fund's late fee is charged if it is still behind
+type lateFeeCheck struct {
+	date time.Time
+	// dueThrough is how much must have been paid by then under the repayment plan.
+	// Zero means the whole outstanding amount was due.
+	dueThrough float64
+}
+
+// lateFeeChecks lists the days up to asOf on which a late fee may be charged: the end
+// of the grace period after the due date, or after every instalment's due date, and
+// then monthly for MONTHLY rules
+func lateFeeChecks(fund *models.Fund, asOf time.Time) []lateFeeCheck {
+	if fund.LateFee == nil {
+		return nil
+	}
+
+	type due struct {
+		date    time.Time
+		through float64
+	}
+	var dues []due
+	if fund.RepaymentPlan != nil {
+		through := 0.0
+		for _, instalment := range expectedInstalments(fund, *fund.RepaymentPlan) {
+			through += instalment.Amount
+			dues = append(dues, due{date: instalment.DueDate, through: through})
+		}
+	} else if fund.DueDate != nil {
+		dues = append(dues, due{date: truncateDay(*fund.DueDate)})
+	}
+
+	var checks []lateFeeCheck
+	for _, d := range dues {
+		first := d.date.AddDate(0, 0, fund.LateFee.GraceDays+1)
+		for n := 0; ; n++ {
+			date := addMonths(first, n)
+			if date.After(asOf) {
+				break
+			}
+			checks = append(checks, lateFeeCheck{date: date, dueThrough: d.through})
+			if fund.LateFee.Frequency != models.LateFeeMonthly {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].date.Before(checks[j].date)
+	})
+	return checks
+}
+
+// fundState is where a fund stands as of a day
+type fundState struct {
+	ledger        *fundLedger
+	outstanding   float64
+	status        models.FundStatus
+	nextDueDate   *time.Time
+	nextDueAmount float64
+	overdueSince  *time.Time
+	overdueAmount float64
+	daysLate      int
+}
+
+// evaluateFund works out a fund's balances, due dates and status as of a day. Funds
+// with a repayment plan are due per instalment, others on their due date, if any.
+func evaluateFund(fund *models.Fund, transactions []models.Transaction, asOf time.Time) fundState {
+	asOf = truncateDay(asOf)
+	ledger := computeFundLedger(fund, transactions, asOf)
+	state := fundState{
+		ledger:      ledger,
+		outstanding: roundCents(ledger.outstanding()),
+	}
+
+	if fund.RepaymentPlan != nil {
+		schedule := repaymentSchedule(fund, transactions, asOf)
+		for _, instalment := range schedule.Instalments {
+			if instalment.Status == models.InstalmentPaid {
+				continue
+			}
+			if state.nextDueDate == nil {
+				dueDate := instalment.DueDate
+				state.nextDueDate = &dueDate
+				state.nextDueAmount = instalment.Remaining
+			}
+			if instalment.Status == models.InstalmentOverdue && state.overdueSince == nil {
+				dueDate := instalment.DueDate
+				state.overdueSince = &dueDate
+			}
+		}
+		state.overdueAmount = schedule.OverdueAmount
+	} else if fund.DueDate != nil && state.outstanding > 0 {
+		dueDate := truncateDay(*fund.DueDate)
+		state.nextDueDate = &dueDate
+		state.nextDueAmount = state.outstanding
+		if dueDate.Before(asOf) {
+			state.overdueSince = &dueDate
+			state.overdueAmount = state.outstanding
+		}
+	}
+	if state.overdueSince != nil {
+		state.daysLate = int(asOf.Sub(*state.overdueSince).Hours() / 24)
+	}
+
+	switch {
+	case fund.MarkedStatus == models.FundStatusWrittenOff:
+		state.status = models.FundStatusWrittenOff
+	case state.outstanding <= 0 && ledger.writtenOff > 0:
+		state.status = models.FundStatusWrittenOff
+	case state.outstanding <= 0:
+		state.status = models.FundStatusPaid
+	case fund.MarkedStatus == models.FundStatusDisputed:
+		state.status = models.FundStatusDisputed
+	case state.overdueSince != nil:
+		state.status = models.FundStatusOverdue
+	default:
+		state.status = models.FundStatusOpen
+	}
+
+	return state
+}
+
+// SetFundStatus marks a fund DISPUTED or WRITTEN_OFF, or clears the mark with OPEN
+func (fs *FundService) SetFundStatus(ctx context.Context, userID, fundID string, req models.FundStatusRequest) (*models.Fund, error) {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"updatedAt": time.Now()}}
+	switch req.Status {
+	case models.FundStatusDisputed, models.FundStatusWrittenOff:
+		update["$set"].(bson.M)["markedStatus"] = req.Status
+	case models.FundStatusOpen:
+		update["$unset"] = bson.M{"markedStatus": ""}
+	default:
+		return nil, fmt.Errorf("invalid status, must be DISPUTED, WRITTEN_OFF or OPEN")
+	}
+
+	err = fs.fundCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": fund.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(fund)
+	if err != nil {
+		return nil, err
+	}
+
+	return fund, nil
+}
+
+// GetOverdueFunds lists the user's overdue funds as of a day, latest first. A zero
+// asOf means today. Disputed and written-off funds aren't listed.
+func (fs *FundService) GetOverdueFunds(ctx context.Context, userID string, asOf time.Time) ([]models.OverdueFund, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	funds, err := fs.findFundsWithTransactions(ctx, bson.M{
+		"userId":       objID,
+		"markedStatus": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = truncateDay(asOf)
+
+	overdue := []models.OverdueFund{}
+	for i := range funds {
+		fund, transactions := &funds[i].Fund, funds[i].Transactions
+		state := evaluateFund(fund, transactions, asOf)
+		if state.status != models.FundStatusOverdue {
+			continue
+		}
+
+		overdue = append(overdue, models.OverdueFund{
+			Fund:          fundResponse(fund, transactions, asOf),
+			OverdueSince:  *state.overdueSince,
+			DaysLate:      state.daysLate,
+			OverdueAmount: state.overdueAmount,
+		})
+	}
+
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].DaysLate > overdue[j].DaysLate
+	})
+
+	return overdue, nil
+}
+
+// SendDueReminders reminds users of funds and instalments coming due within
+// DefaultFundReminderDays. The reminder deduplicates per due date, so running it
+// repeatedly is safe.
+func (fs *FundService) SendDueReminders(ctx context.Context) error {
+	today := truncateDay(time.Now())
+	until := today.AddDate(0, 0, DefaultFundReminderDays)
+
+	cursor, err := fs.fundCollection.Find(ctx, bson.M{
+		"markedStatus": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"dueDate": bson.M{"$gte": today, "$lte": until}},
+			bson.M{"repaymentPlan": bson.M{"$exists": true}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var funds []models.Fund
+	if err := cursor.All(ctx, &funds); err != nil {
+		return err
+	}
+
+	for i := range funds {
+		fund := &funds[i]
+		transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
+		if err != nil {
+			return err
+		}
+
+		state := evaluateFund(fund, transactions, today)
+		if state.nextDueDate == nil || state.nextDueDate.Before(today) || state.nextDueDate.After(until) {
+			continue
+		}
+
+		if err := fs.reminder.RemindFund(ctx, *fund, *state.nextDueDate, state.nextDueAmount); err != nil {
+			log.Printf("Reminder for fund %s failed: %v", fund.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLateFeeChecks(t *testing.T) {
+	dueDate := day(2026, 1, 25)
+	plan := &models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentMonthly, Count: 3, FirstDueDate: day(2026, 2, 1)}
+
+	tests := []struct {
+		name string
+		fund models.Fund
+		asOf time.Time
+		want []lateFeeCheck
+	}{
+		{
+			name: "no late fee rule",
+			fund: models.Fund{PrincipalAmount: 1000, DueDate: &dueDate},
+			asOf: day(2026, 6, 1),
+		},
+		{
+			name: "no due date",
+			fund: models.Fund{PrincipalAmount: 1000, LateFee: &models.LateFeeRule{Flat: 10, Frequency: models.LateFeeOnce}},
+			asOf: day(2026, 6, 1),
+		},
+		{
+			name: "still in the grace period",
+			fund: models.Fund{PrincipalAmount: 1000, DueDate: &dueDate, LateFee: &models.LateFeeRule{Flat: 10, GraceDays: 5, Frequency: models.LateFeeOnce}},
+			asOf: day(2026, 1, 30),
+		},
+		{
+			name: "once, the day after the grace period",
+			fund: models.Fund{PrincipalAmount: 1000, DueDate: &dueDate, LateFee: &models.LateFeeRule{Flat: 10, GraceDays: 5, Frequency: models.LateFeeOnce}},
+			asOf: day(2026, 6, 1),
+			want: []lateFeeCheck{{date: day(2026, 1, 31)}},
+		},
+		{
+			name: "monthly keeps the end of the month",
+			fund: models.Fund{PrincipalAmount: 1000, DueDate: &dueDate, LateFee: &models.LateFeeRule{Flat: 10, GraceDays: 5, Frequency: models.LateFeeMonthly}},
+			asOf: day(2026, 3, 31),
+			want: []lateFeeCheck{{date: day(2026, 1, 31)}, {date: day(2026, 2, 28)}, {date: day(2026, 3, 31)}},
+		},
+		{
+			name: "once per instalment, due through the instalments so far",
+			fund: models.Fund{PrincipalAmount: 1000, RepaymentPlan: plan, LateFee: &models.LateFeeRule{Flat: 10, Frequency: models.LateFeeOnce}},
+			asOf: day(2026, 3, 15),
+			want: []lateFeeCheck{{date: day(2026, 2, 2), dueThrough: 333.33}, {date: day(2026, 3, 2), dueThrough: 666.66}},
+		},
+		{
+			name: "monthly per instalment, in date order",
+			fund: models.Fund{PrincipalAmount: 1000, RepaymentPlan: plan, LateFee: &models.LateFeeRule{Flat: 10, Frequency: models.LateFeeMonthly}},
+			asOf: day(2026, 3, 2),
+			want: []lateFeeCheck{{date: day(2026, 2, 2), dueThrough: 333.33}, {date: day(2026, 3, 2), dueThrough: 333.33}, {date: day(2026, 3, 2), dueThrough: 666.66}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := lateFeeChecks(&tt.fund, tt.asOf)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d checks, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !got[i].date.Equal(want.date) || roundCents(got[i].dueThrough) != want.dueThrough {
+				t.Errorf("%s: check %d = %s through %.2f, want %s through %.2f", tt.name, i+1,
+					got[i].date.Format("2006-01-02"), got[i].dueThrough, want.date.Format("2006-01-02"), want.dueThrough)
+			}
+		}
+	}
+}
+
+func TestEvaluateFund(t *testing.T) {
+	dueDate := day(2026, 3, 1)
+	dated := models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1), DueDate: &dueDate}
+	disputed := dated
+	disputed.MarkedStatus = models.FundStatusDisputed
+	markedWrittenOff := dated
+	markedWrittenOff.MarkedStatus = models.FundStatusWrittenOff
+	planned := models.Fund{
+		PrincipalAmount: 1000,
+		StartDate:       day(2026, 1, 1),
+		RepaymentPlan:   &models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentMonthly, Count: 3, FirstDueDate: day(2026, 2, 1)},
+	}
+	secondInstalment := day(2026, 3, 1)
+
+	repayment := func(amount float64, date time.Time) models.Transaction {
+		return models.Transaction{ID: primitive.NewObjectID(), Type: models.TransactionRepayment, Amount: amount, Date: date}
+	}
+
+	tests := []struct {
+		name          string
+		fund          models.Fund
+		transactions  []models.Transaction
+		asOf          time.Time
+		outstanding   float64
+		status        models.FundStatus
+		nextDueDate   *time.Time
+		nextDueAmount float64
+		overdueAmount float64
+		daysLate      int
+	}{
+		{
+			name:          "open before the due date",
+			fund:          dated,
+			asOf:          day(2026, 2, 15),
+			outstanding:   1000,
+			status:        models.FundStatusOpen,
+			nextDueDate:   &dueDate,
+			nextDueAmount: 1000,
+		},
+		{
+			name:          "due today isn't late yet",
+			fund:          dated,
+			asOf:          day(2026, 3, 1),
+			outstanding:   1000,
+			status:        models.FundStatusOpen,
+			nextDueDate:   &dueDate,
+			nextDueAmount: 1000,
+		},
+		{
+			name:          "overdue after a partial repayment",
+			fund:          dated,
+			transactions:  []models.Transaction{repayment(400, day(2026, 2, 1))},
+			asOf:          day(2026, 3, 11),
+			outstanding:   600,
+			status:        models.FundStatusOverdue,
+			nextDueDate:   &dueDate,
+			nextDueAmount: 600,
+			overdueAmount: 600,
+			daysLate:      10,
+		},
+		{
+			name:         "paid",
+			fund:         dated,
+			transactions: []models.Transaction{repayment(1000, day(2026, 2, 1))},
+			asOf:         day(2026, 3, 11),
+			status:       models.FundStatusPaid,
+		},
+		{
+			name:         "written off by a write-off",
+			fund:         dated,
+			transactions: []models.Transaction{repayment(400, day(2026, 2, 1)), {ID: primitive.NewObjectID(), Type: models.TransactionWriteOff, Amount: 600, Date: day(2026, 3, 5)}},
+			asOf:         day(2026, 3, 11),
+			status:       models.FundStatusWrittenOff,
+		},
+		{
+			name:          "disputed while overdue",
+			fund:          disputed,
+			asOf:          day(2026, 3, 11),
+			outstanding:   1000,
+			status:        models.FundStatusDisputed,
+			nextDueDate:   &dueDate,
+			nextDueAmount: 1000,
+			overdueAmount: 1000,
+			daysLate:      10,
+		},
+		{
+			name:          "marked written off",
+			fund:          markedWrittenOff,
+			asOf:          day(2026, 2, 15),
+			outstanding:   1000,
+			status:        models.FundStatusWrittenOff,
+			nextDueDate:   &dueDate,
+			nextDueAmount: 1000,
+		},
+		{
+			name:        "no due date is never overdue",
+			fund:        models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1)},
+			asOf:        day(2027, 1, 1),
+			outstanding: 1000,
+			status:      models.FundStatusOpen,
+		},
+		{
+			name:          "behind on an instalment",
+			fund:          planned,
+			transactions:  []models.Transaction{repayment(400, day(2026, 2, 1))},
+			asOf:          day(2026, 3, 15),
+			outstanding:   600,
+			status:        models.FundStatusOverdue,
+			nextDueDate:   &secondInstalment,
+			nextDueAmount: 266.66,
+			overdueAmount: 266.66,
+			daysLate:      14,
+		},
+	}
+
+	for _, tt := range tests {
+		got := evaluateFund(&tt.fund, tt.transactions, tt.asOf)
+		if got.outstanding != tt.outstanding || got.status != tt.status {
+			t.Errorf("%s: %.2f outstanding and %s, want %.2f and %s", tt.name, got.outstanding, got.status, tt.outstanding, tt.status)
+		}
+		if (got.nextDueDate == nil) != (tt.nextDueDate == nil) || (got.nextDueDate != nil && !got.nextDueDate.Equal(*tt.nextDueDate)) {
+			t.Errorf("%s: nextDueDate = %v, want %v", tt.name, got.nextDueDate, tt.nextDueDate)
+		}
+		if roundCents(got.nextDueAmount) != tt.nextDueAmount || roundCents(got.overdueAmount) != tt.overdueAmount || got.daysLate != tt.daysLate {
+			t.Errorf("%s: %.2f due next, %.2f overdue, %d days late; want %.2f, %.2f, %d", tt.name,
+				got.nextDueAmount, got.overdueAmount, got.daysLate, tt.nextDueAmount, tt.overdueAmount, tt.daysLate)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+// paymentTolerance absorbs rounding when checking payments against what is owed
+const paymentTolerance = 0.005
+
+func validateFundTerms(req models.FundRequest) error {
+	switch req.InterestType {
+	case "", models.InterestTypeNone:
+		if req.InterestRate != 0 {
+			return fmt.Errorf("interest rate requires an interest type of SIMPLE or COMPOUND")
+		}
+		return nil
+	case models.InterestTypeSimple:
+		if req.CompoundingPeriod != "" {
+			return fmt.Errorf("compounding period only applies to COMPOUND interest")
+		}
+	case models.InterestTypeCompound:
+		switch req.CompoundingPeriod {
+		case models.CompoundingDaily, models.CompoundingMonthly, models.CompoundingQuarterly, models.CompoundingAnnually:
+		default:
+			return fmt.Errorf("invalid compounding period, must be DAILY, MONTHLY, QUARTERLY or ANNUALLY")
+		}
+	default:
+		return fmt.Errorf("invalid interest type, must be NONE, SIMPLE or COMPOUND")
+	}
+
+	if req.InterestRate <= 0 || req.InterestRate > 1000 {
+		return fmt.Errorf("interest rate must be greater than 0 and at most 1000 percent")
+	}
+
+	switch req.DayCount {
+	case "", models.DayCountActual365, models.DayCountActual360, models.DayCount30360:
+	default:
+		return fmt.Errorf("invalid day count convention, must be ACT_365, ACT_360 or 30_360")
+	}
+
+	return nil
+}
+
+// fundTerms returns the interest fields of a fund request with their defaults applied
+func fundTerms(req models.FundRequest) (models.InterestType, float64, models.CompoundingPeriod, models.DayCountConvention) {
+	if req.InterestType == "" || req.InterestType == models.InterestTypeNone {
+		return "", 0, "", ""
+	}
+
+	dayCount := req.DayCount
+	if dayCount == "" {
+		dayCount = models.DayCountActual365
+	}
+	return req.InterestType, req.InterestRate, req.CompoundingPeriod, dayCount
+}
+
+// hasInterest reports whether interest accrues on a fund
+func hasInterest(fund *models.Fund) bool {
+	return fund.InterestRate > 0 &&
+		(fund.InterestType == models.InterestTypeSimple || fund.InterestType == models.InterestTypeCompound)
+}
+
+// fundStartDate is the day interest starts accruing
+func fundStartDate(fund *models.Fund) time.Time {
+	if fund.StartDate.IsZero() {
+		return truncateDay(fund.CreatedAt)
+	}
+	return truncateDay(fund.StartDate)
+}
+
+// yearFraction counts the fraction of a year between two days under a day count convention
+func yearFraction(dayCount models.DayCountConvention, from, to time.Time) float64 {
+	switch dayCount {
+	case models.DayCountActual360:
+		return to.Sub(from).Hours() / 24 / 360
+	case models.DayCount30360:
+		d1, d2 := from.Day(), to.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		days := 360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1
+		return float64(days) / 360
+	default:
+		return to.Sub(from).Hours() / 24 / 365
+	}
+}
+
+// addPeriods returns the date n compounding periods after start
+func addPeriods(start time.Time, period models.CompoundingPeriod, n int) time.Time {
+	switch period {
+	case models.CompoundingMonthly:
+		return addMonths(start, n)
+	case models.CompoundingQuarterly:
+		return addMonths(start, 3*n)
+	case models.CompoundingAnnually:
+		return addMonths(start, 12*n)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// addMonths returns the date months after start, keeping the day of the month and
+// falling back to the month's last day when it is shorter
+func addMonths(start time.Time, months int) time.Time {
+	first := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	day := start.Day()
+	if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// fundLedger is a fund's balance worked out from its terms and transactions up to a
+// day. Repayments settle fees first, then interest and then principal.
+type fundLedger struct {
+	fund *models.Fund
+	rate float64
+
+	at           time.Time
+	periods      int
+	nextCompound time.Time
+
+	principal float64
+	// compounded is interest that was added to the balance and earns interest itself;
+	// pending has accrued since the last compounding
+	compounded float64
+	pending    float64
+
+	// fees are the late fees and FEE charges not paid yet
+	fees            float64
+	lateFeesCharged float64
+	feesCharged     float64
+
+	disbursed       float64
+	interestCharged float64
+	adjustments     float64
+
+	principalPaid float64
+	interestPaid  float64
+	feesPaid      float64
+	// writtenOff is what write-offs took off the fund without it being paid
+	writtenOff         float64
+	interestWrittenOff float64
+	feesWrittenOff     float64
+
+	// transactions are the transactions up to the ledger's day, repayments with their split
+	transactions []models.Transaction
+	// overpaid is the first repayment or write-off that exceeded what was owed on its
+	// day, or adjustment that took the principal below zero
+	overpaid *models.Transaction
+}
+
+func newFundLedger(fund *models.Fund) *fundLedger {
+	ledger := &fundLedger{
+		fund:         fund,
+		at:           fundStartDate(fund),
+		principal:    fund.PrincipalAmount,
+		transactions: []models.Transaction{},
+	}
+	if hasInterest(fund) {
+		ledger.rate = fund.InterestRate / 100
+		if fund.InterestType == models.InterestTypeCompound {
+			ledger.periods = 1
+			ledger.nextCompound = addPeriods(ledger.at, fund.CompoundingPeriod, 1)
+		}
+	}
+	return ledger
+}
+
+// computeFundLedger replays a fund's transactions and late fees up to and including asOf
+func computeFundLedger(fund *models.Fund, transactions []models.Transaction, asOf time.Time) *fundLedger {
+	sorted := make([]models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	asOf = truncateDay(asOf)
+	checks := lateFeeChecks(fund, asOf)
+	ledger := newFundLedger(fund)
+	for _, transaction := range sorted {
+		date := truncateDay(transaction.Date)
+		if date.After(asOf) {
+			break
+		}
+		// A payment on the day a fee is checked is already late
+		for len(checks) > 0 && !checks[0].date.After(date) {
+			ledger.checkLateFee(checks[0])
+			checks = checks[1:]
+		}
+		ledger.apply(transaction)
+	}
+	for _, check := range checks {
+		ledger.checkLateFee(check)
+	}
+	ledger.accrue(asOf)
+
+	return ledger
+}
+
+// accrue adds the interest owed up to a day
+func (l *fundLedger) accrue(to time.Time) {
+	if l.rate == 0 || !to.After(l.at) {
+		return
+	}
+
+	if l.fund.InterestType == models.InterestTypeCompound {
+		for !l.nextCompound.After(to) {
+			l.pending += (l.principal + l.compounded) * l.rate * yearFraction(l.fund.DayCount, l.at, l.nextCompound)
+			l.compounded += l.pending
+			l.pending = 0
+			l.at = l.nextCompound
+			l.periods++
+			l.nextCompound = addPeriods(fundStartDate(l.fund), l.fund.CompoundingPeriod, l.periods)
+		}
+	}
+
+	l.pending += (l.principal + l.compounded) * l.rate * yearFraction(l.fund.DayCount, l.at, to)
+	l.at = to
+}
+
+// transactionType is the type of a transaction, REPAYMENT for ones stored without
+func transactionType(transaction models.Transaction) models.TransactionType {
+	if transaction.Type == "" {
+		return models.TransactionRepayment
+	}
+	return transaction.Type
+}
+
+// apply records a transaction on its day
+func (l *fundLedger) apply(transaction models.Transaction) {
+	transaction.Type = transactionType(transaction)
+	switch {
+	case transaction.Type == models.TransactionRepayment:
+		l.pay(transaction)
+		return
+	case transaction.Type == models.TransactionWriteOff && transaction.ReversedAt == nil:
+		l.writeOff(transaction)
+		return
+	case transaction.Type == models.TransactionWriteOff:
+		// A reversed write-off is only listed
+		l.transactions = append(l.transactions, transaction)
+		return
+	}
+
+	l.accrue(truncateDay(transaction.Date))
+	switch transaction.Type {
+	case models.TransactionDisbursement:
+		l.principal += transaction.Amount
+		l.disbursed += transaction.Amount
+	case models.TransactionFee:
+		l.fees += transaction.Amount
+		l.feesCharged += transaction.Amount
+	case models.TransactionInterest:
+		l.pending += transaction.Amount
+		l.interestCharged += transaction.Amount
+	case models.TransactionAdjustment:
+		if -transaction.Amount > l.principal+paymentTolerance && l.overpaid == nil {
+			overpaid := transaction
+			l.overpaid = &overpaid
+		}
+		l.principal += transaction.Amount
+		l.adjustments += transaction.Amount
+	}
+	l.transactions = append(l.transactions, transaction)
+}
+
+// pay applies a repayment on its day: fees first, then interest, then principal
+func (l *fundLedger) pay(transaction models.Transaction) {
+	fees, interest, principal := l.settle(transaction)
+	l.feesPaid += fees
+	l.interestPaid += interest
+	l.principalPaid += principal
+}
+
+// writeOff applies a write-off on its day, split like a repayment without counting
+// as paid
+func (l *fundLedger) writeOff(transaction models.Transaction) {
+	fees, interest, principal := l.settle(transaction)
+	l.writtenOff += fees + interest + principal
+	l.interestWrittenOff += interest
+	l.feesWrittenOff += fees
+}
+
+// settle takes a transaction's amount off what is owed on its day, fees first, then
+// interest, then principal, and lists it with that split
+func (l *fundLedger) settle(transaction models.Transaction) (fees, interest, principal float64) {
+	l.accrue(truncateDay(transaction.Date))
+
+	owed := l.outstanding()
+	if transaction.Amount > owed+paymentTolerance && l.overpaid == nil {
+		overpaid := transaction
+		l.overpaid = &overpaid
+	}
+
+	fees = math.Min(transaction.Amount, l.fees)
+	l.fees -= fees
+
+	interest = math.Min(transaction.Amount-fees, l.interest())
+	fromPending := math.Min(interest, l.pending)
+	l.pending -= fromPending
+	l.compounded -= interest - fromPending
+
+	principal = transaction.Amount - fees - interest
+	l.principal -= principal
+
+	transaction.FeePortion = roundCents(fees)
+	transaction.InterestPortion = roundCents(interest)
+	transaction.PrincipalPortion = roundCents(principal)
+	l.transactions = append(l.transactions, transaction)
+	return fees, interest, principal
+}
+
+// checkLateFee charges the fund's late fee when it is behind on the check's day
+func (l *fundLedger) checkLateFee(check lateFeeCheck) {
+	l.accrue(check.date)
+
+	overdue := l.outstanding()
+	if check.dueThrough > 0 {
+		overdue = math.Min(overdue, check.dueThrough-l.totalPaid()-l.writtenOff)
+	}
+	if overdue <= paymentTolerance {
+		return
+	}
+
+	rule := l.fund.LateFee
+	fee := roundCents(rule.Flat + overdue*rule.Percent/100)
+	l.fees += fee
+	l.lateFeesCharged += fee
+}
+
+// interest is the accrued, unpaid interest
+func (l *fundLedger) interest() float64 {
+	return l.compounded + l.pending
+}
+
+// outstanding is the unpaid principal plus the accrued, unpaid interest and fees
+func (l *fundLedger) outstanding() float64 {
+	outstanding := l.principal + l.interest() + l.fees
+	if outstanding < 0 {
+		return 0
+	}
+	return outstanding
+}
+
+// charged is the interest and fees charged so far, whether paid, written off or owed
+func (l *fundLedger) charged() (interest, fees float64) {
+	return l.interestPaid + l.interestWrittenOff + l.interest(), l.feesPaid + l.feesWrittenOff + l.fees
+}
+
+// totalPaid is the sum of the repayments in the ledger
+func (l *fundLedger) totalPaid() float64 {
+	return l.principalPaid + l.interestPaid + l.feesPaid
+}
+
+// maxPayment finds the largest payment on a day that doesn't overpay the fund, given
+// its other payments. Paying earlier saves interest, so it is searched for rather
+// than subtracted.
+func maxPayment(fund *models.Fund, others []models.Transaction, date time.Time) float64 {
+	last := date
+	for _, transaction := range others {
+		if transaction.Date.After(last) {
+			last = transaction.Date
+		}
+	}
+
+	fits := func(amount float64) bool {
+		transactions := append([]models.Transaction{{Type: models.TransactionRepayment, Amount: amount, Date: date}}, others...)
+		return computeFundLedger(fund, transactions, last).overpaid == nil
+	}
+
+	high := computeFundLedger(fund, others, date).outstanding()
+	if fits(high) {
+		return math.Floor(high*100) / 100
+	}
+
+	low := 0.0
+	for i := 0; i < 50 && high-low > 0.001; i++ {
+		mid := (low + high) / 2
+		if fits(mid) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return math.Floor(low*100) / 100
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestYearFraction(t *testing.T) {
+	tests := []struct {
+		dayCount models.DayCountConvention
+		from, to time.Time
+		want     float64
+	}{
+		{models.DayCountActual365, day(2026, 1, 1), day(2027, 1, 1), 1},
+		{models.DayCountActual365, day(2026, 1, 1), day(2026, 1, 1), 0},
+		{models.DayCountActual360, day(2026, 1, 1), day(2026, 7, 1), 181.0 / 360},
+		{models.DayCount30360, day(2026, 1, 1), day(2026, 2, 1), 30.0 / 360},
+		{models.DayCount30360, day(2026, 1, 31), day(2026, 3, 31), 60.0 / 360},
+		{models.DayCount30360, day(2026, 1, 15), day(2026, 3, 31), 76.0 / 360},
+		{models.DayCount30360, day(2026, 2, 28), day(2027, 2, 28), 1},
+		{"", day(2028, 1, 1), day(2029, 1, 1), 366.0 / 365},
+	}
+
+	for _, tt := range tests {
+		if got := yearFraction(tt.dayCount, tt.from, tt.to); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("yearFraction(%q, %s, %s) = %v, want %v",
+				tt.dayCount, tt.from.Format("2006-01-02"), tt.to.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestAddPeriods(t *testing.T) {
+	tests := []struct {
+		start  time.Time
+		period models.CompoundingPeriod
+		n      int
+		want   time.Time
+	}{
+		{day(2026, 1, 31), models.CompoundingMonthly, 1, day(2026, 2, 28)},
+		{day(2028, 1, 31), models.CompoundingMonthly, 1, day(2028, 2, 29)},
+		{day(2026, 1, 31), models.CompoundingMonthly, 2, day(2026, 3, 31)},
+		{day(2026, 11, 30), models.CompoundingQuarterly, 1, day(2027, 2, 28)},
+		{day(2028, 2, 29), models.CompoundingAnnually, 1, day(2029, 2, 28)},
+		{day(2026, 12, 31), models.CompoundingDaily, 1, day(2027, 1, 1)},
+	}
+
+	for _, tt := range tests {
+		if got := addPeriods(tt.start, tt.period, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addPeriods(%s, %s, %d) = %s, want %s",
+				tt.start.Format("2006-01-02"), tt.period, tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestComputeFundLedger(t *testing.T) {
+	start := day(2026, 1, 1)
+	noInterest := models.Fund{PrincipalAmount: 1000, StartDate: start}
+	simple := models.Fund{PrincipalAmount: 1000, StartDate: start, InterestType: models.InterestTypeSimple, InterestRate: 12}
+	reversed := time.Now()
+
+	tests := []struct {
+		name         string
+		fund         models.Fund
+		transactions []models.Transaction
+		asOf         time.Time
+		outstanding  float64
+		interest     float64
+		totalPaid    float64
+		writtenOff   float64
+		overpaid     bool
+	}{
+		{
+			name:         "repayment without interest",
+			fund:         noInterest,
+			transactions: []models.Transaction{{Amount: 300, Date: day(2026, 2, 1)}},
+			asOf:         day(2026, 6, 1),
+			outstanding:  700,
+			totalPaid:    300,
+		},
+		{
+			name:        "simple interest over a year",
+			fund:        simple,
+			asOf:        day(2027, 1, 1),
+			outstanding: 1120,
+			interest:    120,
+		},
+		{
+			name:         "simple interest after a repayment that pays interest first",
+			fund:         simple,
+			transactions: []models.Transaction{{Amount: 500, Date: day(2026, 7, 2)}},
+			asOf:         day(2027, 1, 1),
+			outstanding:  593.52,
+			interest:     33.68,
+			totalPaid:    500,
+		},
+		{
+			name: "simple interest with ACT/360",
+			fund: models.Fund{PrincipalAmount: 1000, StartDate: start, InterestType: models.InterestTypeSimple, InterestRate: 12,
+				DayCount: models.DayCountActual360},
+			asOf:        day(2026, 7, 1),
+			outstanding: 1060.33,
+			interest:    60.33,
+		},
+		{
+			name: "monthly compounding",
+			fund: models.Fund{PrincipalAmount: 1000, StartDate: start, InterestType: models.InterestTypeCompound, InterestRate: 12,
+				CompoundingPeriod: models.CompoundingMonthly, DayCount: models.DayCount30360},
+			asOf:        day(2026, 4, 1),
+			outstanding: 1030.30,
+			interest:    30.30,
+		},
+		{
+			name: "disbursement adds to the principal",
+			fund: noInterest,
+			transactions: []models.Transaction{
+				{Type: models.TransactionDisbursement, Amount: 200, Date: day(2026, 3, 1)},
+				{Type: models.TransactionRepayment, Amount: 100, Date: day(2026, 4, 1)},
+			},
+			asOf:        day(2026, 6, 1),
+			outstanding: 1100,
+			totalPaid:   100,
+		},
+		{
+			name: "fees are paid before principal",
+			fund: noInterest,
+			transactions: []models.Transaction{
+				{Type: models.TransactionFee, Amount: 30, Date: day(2026, 2, 1)},
+				{Amount: 50, Date: day(2026, 3, 1)},
+			},
+			asOf:        day(2026, 6, 1),
+			outstanding: 980,
+			totalPaid:   50,
+		},
+		{
+			name: "write-offs reduce what is owed without counting as paid",
+			fund: noInterest,
+			transactions: []models.Transaction{
+				{Type: models.TransactionWriteOff, Amount: 400, Date: day(2026, 2, 1)},
+				{Type: models.TransactionWriteOff, Amount: 100, Date: day(2026, 3, 1), ReversedAt: &reversed},
+			},
+			asOf:        day(2026, 6, 1),
+			outstanding: 600,
+			writtenOff:  400,
+		},
+		{
+			name:         "transactions after the day don't count",
+			fund:         noInterest,
+			transactions: []models.Transaction{{Amount: 300, Date: day(2026, 7, 1)}},
+			asOf:         day(2026, 6, 30),
+			outstanding:  1000,
+		},
+		{
+			name:         "overpayment",
+			fund:         noInterest,
+			transactions: []models.Transaction{{Amount: 1200, Date: day(2026, 2, 1)}},
+			asOf:         day(2026, 6, 1),
+			totalPaid:    1200,
+			overpaid:     true,
+		},
+		{
+			name:         "adjustment below zero",
+			fund:         noInterest,
+			transactions: []models.Transaction{{Type: models.TransactionAdjustment, Amount: -1100, Date: day(2026, 2, 1)}},
+			asOf:         day(2026, 6, 1),
+			overpaid:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		ledger := computeFundLedger(&tt.fund, tt.transactions, tt.asOf)
+		got := []float64{roundCents(ledger.outstanding()), roundCents(ledger.interest()), roundCents(ledger.totalPaid()), roundCents(ledger.writtenOff)}
+		want := []float64{tt.outstanding, tt.interest, tt.totalPaid, tt.writtenOff}
+		for i, name := range []string{"outstanding", "interest", "totalPaid", "writtenOff"} {
+			if got[i] != want[i] {
+				t.Errorf("%s: %s = %.2f, want %.2f", tt.name, name, got[i], want[i])
+			}
+		}
+		if (ledger.overpaid != nil) != tt.overpaid {
+			t.Errorf("%s: overpaid = %v, want %v", tt.name, ledger.overpaid != nil, tt.overpaid)
+		}
+	}
+}
+
+func TestComputeFundLedgerSplitsRepayments(t *testing.T) {
+	fund := models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1), InterestType: models.InterestTypeSimple, InterestRate: 12}
+	transactions := []models.Transaction{
+		{Type: models.TransactionFee, Amount: 25, Date: day(2026, 7, 2)},
+		{Amount: 500, Date: day(2026, 7, 2)},
+	}
+
+	ledger := computeFundLedger(&fund, transactions, day(2026, 12, 31))
+	repayment := ledger.transactions[1]
+	if repayment.FeePortion != 25 || repayment.InterestPortion != 59.84 || repayment.PrincipalPortion != 415.16 {
+		t.Errorf("split = %.2f fees, %.2f interest, %.2f principal; want 25, 59.84, 415.16",
+			repayment.FeePortion, repayment.InterestPortion, repayment.PrincipalPortion)
+	}
+}
+
+func TestMaxPayment(t *testing.T) {
+	tests := []struct {
+		name   string
+		fund   models.Fund
+		others []models.Transaction
+		date   time.Time
+		want   float64
+	}{
+		{
+			name: "everything outstanding",
+			fund: models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1)},
+			date: day(2026, 3, 1),
+			want: 1000,
+		},
+		{
+			name:   "less what other payments cover",
+			fund:   models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1)},
+			others: []models.Transaction{{Amount: 300, Date: day(2026, 6, 1)}},
+			date:   day(2026, 3, 1),
+			want:   700,
+		},
+		{
+			name: "with the interest accrued by the day",
+			fund: models.Fund{PrincipalAmount: 1000, StartDate: day(2026, 1, 1), InterestType: models.InterestTypeSimple, InterestRate: 12},
+			date: day(2026, 7, 2),
+			want: 1059.83,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := maxPayment(&tt.fund, tt.others, tt.date); got != tt.want {
+			t.Errorf("%s: maxPayment = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
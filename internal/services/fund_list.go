@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxFundListLimit caps how many funds one page of the fund listing holds
+const MaxFundListLimit = 200
+
+// FundListOptions filters, sorts and pages the fund listing. Page starts at 1;
+// zero values of the others mean no filter, sorting by creation oldest first, and
+// every fund on one page.
+type FundListOptions struct {
+	Type      models.FundType
+	Status    models.FundStatus
+	ContactID string
+	// Person matches funds whose person name contains it, ignoring case
+	Person string
+	AsOf   time.Time
+	// Sort is createdAt, startDate, personName, principalAmount, outstanding or daysLate
+	Sort                string
+	Desc                bool
+	Page                int
+	Limit               int
+	IncludeTransactions bool
+}
+
+// fundWithTransactions is a fund loaded together with its transactions
+type fundWithTransactions struct {
+	models.Fund  `bson:",inline"`
+	Transactions []models.Transaction `bson:"transactions"`
+}
+
+// findFundsWithTransactions loads the funds matching a filter with their
+// transactions in one aggregation, in creation order
+func (fs *FundService) findFundsWithTransactions(ctx context.Context, filter bson.M) ([]fundWithTransactions, error) {
+	return fs.findFundPage(ctx, filter, "", false, time.Time{}, 0, 0)
+}
+
+// findFundPage loads one page of the funds matching a filter, sorted by a stored
+// field, with their transactions in one aggregation. Only the funds on the page
+// have their transactions looked up, except when sorting by principal, which
+// takes in the disbursements made by asOf. A zero limit loads every fund.
+func (fs *FundService) findFundPage(ctx context.Context, filter bson.M, field string, desc bool, asOf time.Time, skip, limit int64) ([]fundWithTransactions, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+	lookup := bson.D{{Key: "$lookup", Value: bson.M{
+		"from":         fs.transactionCollection.Name(),
+		"localField":   "_id",
+		"foreignField": "fundId",
+		"as":           "transactions",
+	}}}
+	lookedUp := false
+
+	order := 1
+	if desc {
+		order = -1
+	}
+	// Ties keep their creation order, as with the sorting done in memory
+	created := 1
+	sort := bson.D{}
+	switch field {
+	case "startDate":
+		sort = append(sort, bson.E{Key: "startDate", Value: order})
+	case "personName":
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
+			"personNameKey": bson.M{"$toLower": "$personName"},
+		}}})
+		sort = append(sort, bson.E{Key: "personNameKey", Value: order})
+	case "principalAmount":
+		// The principal listed includes the disbursements made by asOf, or all of
+		// them when asOf is left to default to the latest transaction
+		disbursed := bson.A{bson.M{"$eq": bson.A{"$$transaction.type", models.TransactionDisbursement}}}
+		if !asOf.IsZero() {
+			disbursed = append(disbursed, bson.M{"$lt": bson.A{"$$transaction.date", truncateDay(asOf).AddDate(0, 0, 1)}})
+		}
+		pipeline = append(pipeline, lookup, bson.D{{Key: "$addFields", Value: bson.M{
+			"principalKey": bson.M{"$round": bson.A{bson.M{"$add": bson.A{"$principalAmount", bson.M{"$sum": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": "$transactions",
+					"as":    "transaction",
+					"cond":  bson.M{"$and": disbursed},
+				}},
+				"as": "transaction",
+				"in": "$$transaction.amount",
+			}}}}}, 2}},
+		}}})
+		lookedUp = true
+		sort = append(sort, bson.E{Key: "principalKey", Value: order})
+	default:
+		created = order
+	}
+	sort = append(sort, bson.E{Key: "createdAt", Value: created}, bson.E{Key: "_id", Value: 1})
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	if !lookedUp {
+		pipeline = append(pipeline, lookup)
+	}
+
+	cursor, err := fs.fundCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	funds := []fundWithTransactions{}
+	if err := cursor.All(ctx, &funds); err != nil {
+		return nil, err
+	}
+
+	return funds, nil
+}
+
+func validateFundListOptions(opts FundListOptions) error {
+	switch opts.Type {
+	case "", models.FundTypeBorrowed, models.FundTypeGiven:
+	default:
+		return fmt.Errorf("invalid type, must be BORROWED or GIVEN")
+	}
+
+	switch opts.Status {
+	case "", models.FundStatusOpen, models.FundStatusPaid, models.FundStatusOverdue, models.FundStatusDisputed, models.FundStatusWrittenOff:
+	default:
+		return fmt.Errorf("invalid status, must be OPEN, PAID, OVERDUE, DISPUTED or WRITTEN_OFF")
+	}
+
+	switch opts.Sort {
+	case "", "createdAt", "startDate", "personName", "principalAmount", "outstanding", "daysLate":
+	default:
+		return fmt.Errorf("invalid sort, must be createdAt, startDate, personName, principalAmount, outstanding or daysLate")
+	}
+
+	if opts.Limit < 0 || opts.Limit > MaxFundListLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxFundListLimit)
+	}
+	if opts.Page < 1 {
+		return fmt.Errorf("page must be at least 1")
+	}
+
+	return nil
+}
+
+// storedFundSorts are the sorts on fields stored with the fund, which the database
+// can sort and page on. The other sorts depend on balances worked out from the
+// transactions.
+var storedFundSorts = map[string]bool{"": true, "createdAt": true, "startDate": true, "personName": true, "principalAmount": true}
+
+// ListFunds lists the user's funds with their balances as of a day, filtered, sorted
+// and paged. It returns the page and the number of funds matching the filters.
+// Filters, sorts and paging on stored fields are done by the database, which loads
+// only the page's funds with their transactions in a single query. The status and
+// the balances are worked out from the transactions, so filtering on status or
+// sorting on a balance loads every matching fund and is done here.
+func (fs *FundService) ListFunds(ctx context.Context, userID string, opts FundListOptions) ([]models.FundResponse, int, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateFundListOptions(opts); err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"userId": objID}
+	if opts.Type != "" {
+		filter["type"] = opts.Type
+	}
+	if opts.ContactID != "" {
+		contactObjID, err := primitive.ObjectIDFromHex(opts.ContactID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid contact ID")
+		}
+		filter["contactId"] = contactObjID
+	}
+	if person := strings.TrimSpace(opts.Person); person != "" {
+		filter["personName"] = bson.M{"$regex": regexp.QuoteMeta(person), "$options": "i"}
+	}
+
+	if opts.Status == "" && storedFundSorts[opts.Sort] {
+		return fs.listFundPage(ctx, filter, opts)
+	}
+
+	funds, err := fs.findFundsWithTransactions(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]models.FundResponse, 0, len(funds))
+	for i := range funds {
+		response := fundResponse(&funds[i].Fund, funds[i].Transactions, opts.AsOf)
+		if opts.Status != "" && response.Status != opts.Status {
+			continue
+		}
+		responses = append(responses, response)
+	}
+
+	sortFundResponses(responses, opts.Sort, opts.Desc)
+
+	total := len(responses)
+	if opts.Limit > 0 {
+		page := opts.Page
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * opts.Limit
+		if start > total {
+			start = total
+		}
+		end := start + opts.Limit
+		if end > total {
+			end = total
+		}
+		responses = responses[start:end]
+	}
+
+	if !opts.IncludeTransactions {
+		for i := range responses {
+			responses[i].Transactions = nil
+		}
+	}
+
+	return responses, total, nil
+}
+
+// listFundPage lists a page of funds sorted on a stored field, leaving the
+// filtering, sorting and paging to the database
+func (fs *FundService) listFundPage(ctx context.Context, filter bson.M, opts FundListOptions) ([]models.FundResponse, int, error) {
+	total, err := fs.fundCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var skip int64
+	if opts.Limit > 0 && opts.Page > 1 {
+		skip = int64(opts.Page-1) * int64(opts.Limit)
+	}
+	funds, err := fs.findFundPage(ctx, filter, opts.Sort, opts.Desc, opts.AsOf, skip, int64(opts.Limit))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]models.FundResponse, 0, len(funds))
+	for i := range funds {
+		response := fundResponse(&funds[i].Fund, funds[i].Transactions, opts.AsOf)
+		if !opts.IncludeTransactions {
+			response.Transactions = nil
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, int(total), nil
+}
+
+// sortFundResponses orders funds by a field; ties keep their creation order
+func sortFundResponses(funds []models.FundResponse, field string, desc bool) {
+	less := func(a, b *models.FundResponse) bool {
+		switch field {
+		case "startDate":
+			return a.StartDate.Before(b.StartDate)
+		case "personName":
+			return strings.ToLower(a.PersonName) < strings.ToLower(b.PersonName)
+		case "principalAmount":
+			return a.PrincipalAmount < b.PrincipalAmount
+		case "outstanding":
+			return a.Outstanding < b.Outstanding
+		case "daysLate":
+			return a.DaysLate < b.DaysLate
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	}
+
+	sort.SliceStable(funds, func(i, j int) bool {
+		if desc {
+			return less(&funds[j], &funds[i])
+		}
+		return less(&funds[i], &funds[j])
+	})
+}
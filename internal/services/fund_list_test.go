@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListFundsPagesInTheDatabase(t *testing.T) {
+	db := testDatabase(t)
+	fs := NewFundService(db, NewContactService(db), nil, nil)
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID().Hex()
+	var dana *models.Fund
+	for i, name := range []string{"dana", "Bilal", "erin", "Ali", "carl"} {
+		fund, err := fs.CreateFund(ctx, userID, models.FundRequest{
+			PersonName:      name,
+			Type:            models.FundTypeGiven,
+			PrincipalAmount: float64(100 * (i + 1)),
+			StartDate:       time.Date(2026, 1, i+1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("CreateFund %s: %v", name, err)
+		}
+		if name == "dana" {
+			dana = fund
+		}
+	}
+	// Lending dana more puts her principal between Bilal's and erin's from March
+	_, err := fs.AddTransaction(ctx, userID, dana.ID.Hex(), models.TransactionRequest{
+		Type:   models.TransactionDisbursement,
+		Amount: 150,
+		Date:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  FundListOptions
+		want  []string
+		total int
+	}{
+		{"person name page", FundListOptions{Sort: "personName", Page: 2, Limit: 2}, []string{"carl", "dana"}, 5},
+		{"person name descending", FundListOptions{Sort: "personName", Desc: true, Page: 1, Limit: 2}, []string{"erin", "dana"}, 5},
+		{"start date descending", FundListOptions{Sort: "startDate", Desc: true, Page: 3, Limit: 2}, []string{"dana"}, 5},
+		{"creation order", FundListOptions{Page: 1, Limit: 3}, []string{"dana", "Bilal", "erin"}, 5},
+		{"person filter", FundListOptions{Person: "a", Sort: "personName", Page: 1}, []string{"Ali", "Bilal", "carl", "dana"}, 4},
+		{"principal with disbursements", FundListOptions{Sort: "principalAmount", Page: 1, Limit: 3}, []string{"Bilal", "dana", "erin"}, 5},
+		{"principal before the disbursement", FundListOptions{Sort: "principalAmount", AsOf: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Page: 1, Limit: 2}, []string{"dana", "Bilal"}, 5},
+		{"balance sort in memory", FundListOptions{Sort: "outstanding", Desc: true, Page: 2, Limit: 2}, []string{"erin", "dana"}, 5},
+		{"past the last page", FundListOptions{Page: 4, Limit: 2}, []string{}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funds, total, err := fs.ListFunds(ctx, userID, tt.opts)
+			if err != nil {
+				t.Fatalf("ListFunds: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+			got := make([]string, len(funds))
+			for i, fund := range funds {
+				got[i] = fund.PersonName
+				if fund.Transactions != nil {
+					t.Errorf("%s has transactions listed without IncludeTransactions", fund.PersonName)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("funds = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("funds = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFundListOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FundListOptions
+		wantErr bool
+	}{
+		{"first page", FundListOptions{Page: 1}, false},
+		{"principal sort", FundListOptions{Sort: "principalAmount", Page: 1}, false},
+		{"page zero", FundListOptions{Page: 0, Limit: 20}, true},
+		{"negative page", FundListOptions{Page: -1}, true},
+		{"limit over the cap", FundListOptions{Page: 1, Limit: MaxFundListLimit + 1}, true},
+		{"unknown sort", FundListOptions{Sort: "amount", Page: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFundListOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFundListOptions() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
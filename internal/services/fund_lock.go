@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// fundLease is how long a write holds a fund before another write may take over
+	fundLease = 30 * time.Second
+	// fundLockWait is how long a write waits for another write on the same fund
+	fundLockWait = 5 * time.Second
+	// fundLockRetry is the pause between two attempts to take a fund's lease
+	fundLockRetry = 20 * time.Millisecond
+)
+
+// fundLeases keeps the leases writes hold on funds. fundCollectionLeases keeps
+// them on the funds themselves; the fence and undo logic only needs this much of
+// it, so it can be checked without a database.
+type fundLeases interface {
+	// renew moves a lease that runs until held to until, and reports false when
+	// the lease no longer runs until held because another write took it over
+	renew(ctx context.Context, fundID primitive.ObjectID, held, until time.Time) (bool, error)
+	// free ends a lease that runs until held
+	free(ctx context.Context, fundID primitive.ObjectID, held time.Time)
+}
+
+// fundCollectionLeases keeps each fund's lease in its lockedUntil field
+type fundCollectionLeases struct {
+	collection *mongo.Collection
+}
+
+func (fl fundCollectionLeases) renew(ctx context.Context, fundID primitive.ObjectID, held, until time.Time) (bool, error) {
+	result, err := fl.collection.UpdateOne(ctx,
+		bson.M{"_id": fundID, "lockedUntil": held},
+		bson.M{"$set": bson.M{"lockedUntil": until}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (fl fundCollectionLeases) free(ctx context.Context, fundID primitive.ObjectID, held time.Time) {
+	fl.collection.UpdateOne(ctx,
+		bson.M{"_id": fundID, "lockedUntil": held},
+		bson.M{"$unset": bson.M{"lockedUntil": ""}},
+	)
+}
+
+// fundLock is a write's lease on a fund, taken with lockFund.
+//
+// MongoDB runs standalone here, so a check and the write it allows can't share a
+// transaction. Keeping a running totalPaid on the fund and updating it only when
+// the new total fits would not do either: what a fund allows depends on interest,
+// fees and late charges replayed over every transaction's date, so no single
+// counter can be compared against it. Writes to a fund instead take turns through
+// its lease, and fence their writes with it in case they stall past it.
+//
+// Only DeleteFund writes to more than one collection without undoing on failure:
+// it deletes the fund first and leaves any transactions a failure leaves behind to
+// the PurgeOrphanedTransactions job.
+type fundLock struct {
+	leases fundLeases
+	fundID primitive.ObjectID
+	// until is the fund's lockedUntil while the lease is held
+	until time.Time
+}
+
+// lockFund takes the fund's lease, so that its payments can be checked and written
+// without another write to the same fund in between, and reloads the fund into
+// fund. Every write that checks payments against the fund's terms holds the lease
+// and fences its writes with it.
+func (fs *FundService) lockFund(ctx context.Context, fund *models.Fund) (*fundLock, error) {
+	deadline := time.Now().Add(fundLockWait)
+	for {
+		now := time.Now()
+		err := fs.fundCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":    fund.ID,
+				"userId": fund.UserID,
+				"$or": bson.A{
+					bson.M{"lockedUntil": bson.M{"$exists": false}},
+					bson.M{"lockedUntil": bson.M{"$lt": now}},
+				},
+			},
+			bson.M{"$set": bson.M{"lockedUntil": leaseEnd(now)}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(fund)
+		if err == nil {
+			break
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		// Either another write holds the lease or the fund is gone
+		exists, err := fs.fundCollection.CountDocuments(ctx, bson.M{"_id": fund.ID, "userId": fund.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, fmt.Errorf("fund not found or doesn't belong to user")
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("fund is being updated by another request, try again")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(fundLockRetry):
+		}
+	}
+
+	return &fundLock{
+		leases: fundCollectionLeases{fs.fundCollection},
+		fundID: fund.ID,
+		until:  *fund.LockedUntil,
+	}, nil
+}
+
+// leaseEnd is when a lease taken at now runs out, at the millisecond precision
+// MongoDB stores it with, so the lease can be matched exactly later on
+func leaseEnd(now time.Time) time.Time {
+	return now.Add(fundLease).Truncate(time.Millisecond)
+}
+
+// fence checks that the lease is still held and renews it. A write that stalls
+// past its lease may find that another write has taken the fund over and read its
+// payments in the meantime, so writes fence right before and right after writing,
+// and undo what they wrote when the lease was lost in between.
+func (l *fundLock) fence(ctx context.Context) error {
+	until := leaseEnd(time.Now())
+	held, err := l.leases.renew(ctx, l.fundID, l.until, until)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return fmt.Errorf("fund is being updated by another request, try again")
+	}
+	l.until = until
+	return nil
+}
+
+// write runs write fenced by the lease, and undo when the lease was lost while
+// writing. An error from write itself is returned as is, with nothing undone.
+func (l *fundLock) write(ctx context.Context, write func() error, undo func()) error {
+	return fencedWrite(ctx, []*fundLock{l}, write, undo)
+}
+
+// fencedWrite is write for a write that holds the leases of several funds
+func fencedWrite(ctx context.Context, locks []*fundLock, write func() error, undo func()) error {
+	if err := fenceFunds(ctx, locks); err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if err := fenceFunds(ctx, locks); err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+// fenceFunds fences every lease in locks
+func fenceFunds(ctx context.Context, locks []*fundLock) error {
+	for _, lock := range locks {
+		if err := lock.fence(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release gives the lease back, unless it ran out and was taken over
+func (l *fundLock) release() {
+	l.leases.free(context.Background(), l.fundID, l.until)
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestFund(t *testing.T, principal float64) (*FundService, string, *models.Fund) {
+	t.Helper()
+
+	db := testDatabase(t)
+	fs := NewFundService(db, NewContactService(db), nil, nil)
+	userID := primitive.NewObjectID().Hex()
+
+	fund, err := fs.CreateFund(context.Background(), userID, models.FundRequest{
+		PersonName:      "Ali",
+		Type:            models.FundTypeGiven,
+		PrincipalAmount: principal,
+		StartDate:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("CreateFund: %v", err)
+	}
+
+	return fs, userID, fund
+}
+
+func TestAddTransactionConcurrentPaymentsCannotOverpay(t *testing.T) {
+	fs, userID, fund := newTestFund(t, 100)
+
+	const payments = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, payments)
+	for i := 0; i < payments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fs.AddTransaction(context.Background(), userID, fund.ID.Hex(), models.TransactionRequest{
+				Amount: 100,
+				Date:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded > 1 {
+		t.Errorf("%d payments of the whole principal succeeded, want at most 1", succeeded)
+	}
+
+	totalPaid, err := fs.CalculateTotalPaid(context.Background(), fund.ID)
+	if err != nil {
+		t.Fatalf("CalculateTotalPaid: %v", err)
+	}
+	if totalPaid > fund.PrincipalAmount {
+		t.Errorf("totalPaid = %.2f, want at most %.2f", totalPaid, fund.PrincipalAmount)
+	}
+}
+
+func TestFundLockFenceFailsAfterTakeover(t *testing.T) {
+	fs, _, fund := newTestFund(t, 100)
+	ctx := context.Background()
+
+	stalled, err := fs.lockFund(ctx, &models.Fund{ID: fund.ID, UserID: fund.UserID})
+	if err != nil {
+		t.Fatalf("lockFund: %v", err)
+	}
+
+	// Let the lease run out, as if its holder stalled, so another write takes over
+	expired := time.Now().Add(-time.Second).Truncate(time.Millisecond)
+	if _, err := fs.fundCollection.UpdateOne(ctx, bson.M{"_id": fund.ID}, bson.M{"$set": bson.M{"lockedUntil": expired}}); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	stalled.until = expired
+
+	other, err := fs.lockFund(ctx, &models.Fund{ID: fund.ID, UserID: fund.UserID})
+	if err != nil {
+		t.Fatalf("lockFund after expiry: %v", err)
+	}
+	defer other.release()
+
+	if err := stalled.fence(ctx); err == nil {
+		t.Error("fence succeeded for a lease that was taken over")
+	}
+	if err := other.fence(ctx); err != nil {
+		t.Errorf("fence failed for the current lease: %v", err)
+	}
+}
+
+// memoryFundLeases keeps leases the way the funds collection keeps lockedUntil
+type memoryFundLeases struct {
+	mu     sync.Mutex
+	leases map[primitive.ObjectID]time.Time
+	// expired is the last lease end expire rewound to
+	expired time.Time
+}
+
+func newMemoryFundLeases() *memoryFundLeases {
+	return &memoryFundLeases{leases: map[primitive.ObjectID]time.Time{}}
+}
+
+// lock takes the fund's lease like lockFund, reporting false while another write
+// holds it
+func (m *memoryFundLeases) lock(fundID primitive.ObjectID) (*fundLock, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := m.leases[fundID]; ok && !until.Before(now) {
+		return nil, false
+	}
+	until := leaseEnd(now)
+	m.leases[fundID] = until
+	return &fundLock{leases: m, fundID: fundID, until: until}, true
+}
+
+// expire lets the lease of lock run out, as if its holder stalled past it, by
+// moving it into the past
+func (m *memoryFundLeases) expire(lock *fundLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases[lock.fundID] != lock.until {
+		return
+	}
+	// Every lease that runs out ends at a different time, so that a stalled holder
+	// never matches a lease taken after its own
+	expired := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	if !expired.After(m.expired) {
+		expired = m.expired.Add(time.Millisecond)
+	}
+	m.expired = expired
+	m.leases[lock.fundID] = expired
+	lock.until = expired
+}
+
+func (m *memoryFundLeases) renew(ctx context.Context, fundID primitive.ObjectID, held, until time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[fundID]; !ok || !current.Equal(held) {
+		return false, nil
+	}
+	m.leases[fundID] = until
+	return true, nil
+}
+
+func (m *memoryFundLeases) free(ctx context.Context, fundID primitive.ObjectID, held time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[fundID]; ok && current.Equal(held) {
+		delete(m.leases, fundID)
+	}
+}
+
+func TestFundLockWrite(t *testing.T) {
+	failed := errors.New("insert failed")
+
+	tests := []struct {
+		name string
+		// before and during run before the write and while writing
+		before, during func(leases *memoryFundLeases, lock *fundLock)
+		writeErr       error
+		wantWritten    bool
+		wantUndone     bool
+		wantErr        bool
+	}{
+		{
+			name:        "lease held throughout",
+			wantWritten: true,
+		},
+		{
+			name: "taken over before writing",
+			before: func(leases *memoryFundLeases, lock *fundLock) {
+				leases.expire(lock)
+				leases.lock(lock.fundID)
+			},
+			wantErr: true,
+		},
+		{
+			name: "taken over while writing",
+			during: func(leases *memoryFundLeases, lock *fundLock) {
+				leases.expire(lock)
+				leases.lock(lock.fundID)
+			},
+			wantWritten: true,
+			wantUndone:  true,
+			wantErr:     true,
+		},
+		{
+			name: "ran out while writing without a takeover",
+			during: func(leases *memoryFundLeases, lock *fundLock) {
+				leases.expire(lock)
+			},
+			wantWritten: true,
+		},
+		{
+			name:        "write fails",
+			writeErr:    failed,
+			wantWritten: true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := newMemoryFundLeases()
+			fundID := primitive.NewObjectID()
+			lock, ok := leases.lock(fundID)
+			if !ok {
+				t.Fatal("lock failed on a free fund")
+			}
+
+			if tt.before != nil {
+				tt.before(leases, lock)
+			}
+			written, undone := false, false
+			err := lock.write(context.Background(), func() error {
+				written = true
+				if tt.during != nil {
+					tt.during(leases, lock)
+				}
+				return tt.writeErr
+			}, func() {
+				undone = true
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.writeErr != nil && err != tt.writeErr {
+				t.Errorf("err = %v, want the write's error", err)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("written = %v, want %v", written, tt.wantWritten)
+			}
+			if undone != tt.wantUndone {
+				t.Errorf("undone = %v, want %v", undone, tt.wantUndone)
+			}
+		})
+	}
+}
+
+func TestFundLockReleaseKeepsTakenOverLease(t *testing.T) {
+	leases := newMemoryFundLeases()
+	fundID := primitive.NewObjectID()
+
+	stalled, _ := leases.lock(fundID)
+	leases.expire(stalled)
+	other, ok := leases.lock(fundID)
+	if !ok {
+		t.Fatal("lock failed on an expired lease")
+	}
+
+	stalled.release()
+	if _, ok := leases.lock(fundID); ok {
+		t.Error("release freed a lease that was taken over")
+	}
+	if err := other.fence(context.Background()); err != nil {
+		t.Errorf("fence failed for the current lease: %v", err)
+	}
+}
+
+// TestFundLockStalledRepaymentsCannotOverpay runs repayments the way AddTransaction
+// does, with writers stalling past their lease before or while writing so that
+// others take the fund over, and checks that what is kept never overpays the fund
+// and matches the repayments that succeeded
+func TestFundLockStalledRepaymentsCannotOverpay(t *testing.T) {
+	fund := &models.Fund{
+		ID:              primitive.NewObjectID(),
+		Type:            models.FundTypeGiven,
+		PrincipalAmount: 100,
+		StartDate:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	leases := newMemoryFundLeases()
+
+	var mu sync.Mutex
+	var stored []models.Transaction
+	snapshot := func() []models.Transaction {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]models.Transaction(nil), stored...)
+	}
+	stall := func(lock *fundLock) {
+		leases.expire(lock)
+		time.Sleep(time.Millisecond)
+	}
+
+	const writers = 40
+	var wg sync.WaitGroup
+	succeeded := make(chan models.Transaction, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(int64(i)))
+
+			var lock *fundLock
+			for {
+				var ok bool
+				if lock, ok = leases.lock(fund.ID); ok {
+					break
+				}
+				time.Sleep(50 * time.Microsecond)
+			}
+			defer lock.release()
+
+			transaction := models.Transaction{
+				ID:     primitive.NewObjectID(),
+				FundID: fund.ID,
+				Type:   models.TransactionRepayment,
+				Amount: 30,
+				Date:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			}
+			if checkTransaction(fund, snapshot(), transaction) != nil {
+				return
+			}
+
+			// Stall before the first fence, after it, or after writing, or not at all
+			stalls := random.Intn(4)
+			if stalls == 1 {
+				stall(lock)
+			}
+			err := lock.write(context.Background(), func() error {
+				if stalls == 2 {
+					stall(lock)
+				}
+				mu.Lock()
+				stored = append(stored, transaction)
+				mu.Unlock()
+				if stalls == 3 {
+					stall(lock)
+				}
+				return nil
+			}, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				for j := range stored {
+					if stored[j].ID == transaction.ID {
+						stored = append(stored[:j], stored[j+1:]...)
+						break
+					}
+				}
+			})
+			if err == nil {
+				succeeded <- transaction
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(succeeded)
+
+	kept := map[primitive.ObjectID]bool{}
+	totalPaid := 0.0
+	for _, transaction := range snapshot() {
+		kept[transaction.ID] = true
+		totalPaid += transaction.Amount
+	}
+	if totalPaid > fund.PrincipalAmount {
+		t.Errorf("totalPaid = %.2f, want at most %.2f", totalPaid, fund.PrincipalAmount)
+	}
+	count := 0
+	for transaction := range succeeded {
+		count++
+		if !kept[transaction.ID] {
+			t.Errorf("repayment %s succeeded but wasn't kept", transaction.ID.Hex())
+		}
+	}
+	if count != len(kept) {
+		t.Errorf("%d repayments kept, want the %d that succeeded", len(kept), count)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxInstalments caps the number of instalments of a repayment plan
+const MaxInstalments = 600
+
+func validateRepaymentPlan(fund *models.Fund, plan models.RepaymentPlan) error {
+	switch plan.Type {
+	case models.RepaymentPlanEqual, models.RepaymentPlanAmortizing:
+		switch plan.Frequency {
+		case models.RepaymentWeekly, models.RepaymentMonthly, models.RepaymentQuarterly:
+		default:
+			return fmt.Errorf("invalid frequency, must be WEEKLY, MONTHLY or QUARTERLY")
+		}
+		if plan.Count < 1 || plan.Count > MaxInstalments {
+			return fmt.Errorf("count must be between 1 and %d", MaxInstalments)
+		}
+		if plan.FirstDueDate.IsZero() {
+			return fmt.Errorf("first due date is required")
+		}
+		if truncateDay(plan.FirstDueDate).Before(fundStartDate(fund)) {
+			return fmt.Errorf("first due date must not be before the fund's start date")
+		}
+		if len(plan.Instalments) > 0 {
+			return fmt.Errorf("instalments can only be listed for CUSTOM plans")
+		}
+		if plan.Type == models.RepaymentPlanAmortizing && !hasInterest(fund) {
+			return fmt.Errorf("amortizing plans need a fund with interest")
+		}
+	case models.RepaymentPlanCustom:
+		if len(plan.Instalments) == 0 || len(plan.Instalments) > MaxInstalments {
+			return fmt.Errorf("custom plans need between 1 and %d instalments", MaxInstalments)
+		}
+		total := 0.0
+		for _, instalment := range plan.Instalments {
+			if instalment.DueDate.IsZero() {
+				return fmt.Errorf("every instalment needs a due date")
+			}
+			if instalment.Amount <= 0 {
+				return fmt.Errorf("instalment amounts must be greater than 0")
+			}
+			total += instalment.Amount
+		}
+		if total < fund.PrincipalAmount-paymentTolerance {
+			return fmt.Errorf("instalments must add up to at least the principal amount (%.2f)", fund.PrincipalAmount)
+		}
+	default:
+		return fmt.Errorf("invalid plan type, must be EQUAL, CUSTOM or AMORTIZING")
+	}
+
+	return nil
+}
+
+// normalizeRepaymentPlan drops the fields a plan's type doesn't use and orders custom
+// instalments by due date
+func normalizeRepaymentPlan(plan models.RepaymentPlan) models.RepaymentPlan {
+	if plan.Type != models.RepaymentPlanCustom {
+		plan.FirstDueDate = truncateDay(plan.FirstDueDate)
+		return plan
+	}
+
+	instalments := make([]models.PlannedInstalment, 0, len(plan.Instalments))
+	for _, instalment := range plan.Instalments {
+		instalments = append(instalments, models.PlannedInstalment{
+			DueDate: truncateDay(instalment.DueDate),
+			Amount:  roundCents(instalment.Amount),
+		})
+	}
+	sort.SliceStable(instalments, func(i, j int) bool {
+		return instalments[i].DueDate.Before(instalments[j].DueDate)
+	})
+
+	return models.RepaymentPlan{
+		Type:        models.RepaymentPlanCustom,
+		Instalments: instalments,
+	}
+}
+
+// SetRepaymentPlan attaches a repayment plan to a fund, replacing any previous one
+func (fs *FundService) SetRepaymentPlan(ctx context.Context, userID, fundID string, plan models.RepaymentPlan) (*models.Fund, error) {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRepaymentPlan(fund, plan); err != nil {
+		return nil, err
+	}
+	plan = normalizeRepaymentPlan(plan)
+
+	err = fs.fundCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": fund.ID},
+		bson.M{"$set": bson.M{
+			"repaymentPlan": plan,
+			"updatedAt":     time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(fund)
+	if err != nil {
+		return nil, err
+	}
+
+	return fund, nil
+}
+
+// DeleteRepaymentPlan removes a fund's repayment plan
+func (fs *FundService) DeleteRepaymentPlan(ctx context.Context, userID, fundID string) error {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return err
+	}
+	if fund.RepaymentPlan == nil {
+		return fmt.Errorf("fund has no repayment plan")
+	}
+
+	_, err = fs.fundCollection.UpdateOne(ctx,
+		bson.M{"_id": fund.ID},
+		bson.M{
+			"$unset": bson.M{"repaymentPlan": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
+
+// GetRepaymentSchedule works out a fund's instalments and how far each is paid as of
+// a day. A zero asOf means today.
+func (fs *FundService) GetRepaymentSchedule(ctx context.Context, userID, fundID string, asOf time.Time) (*models.RepaymentScheduleResponse, error) {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return nil, err
+	}
+	if fund.RepaymentPlan == nil {
+		return nil, fmt.Errorf("fund has no repayment plan")
+	}
+
+	transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return repaymentSchedule(fund, transactions, asOf), nil
+}
+
+// repaymentSchedule matches a fund's repayments and write-offs up to asOf to its
+// instalments, oldest instalment first. A repayment larger than an instalment carries
+// over to the next ones.
+func repaymentSchedule(fund *models.Fund, transactions []models.Transaction, asOf time.Time) *models.RepaymentScheduleResponse {
+	asOf = truncateDay(asOf)
+	instalments := expectedInstalments(fund, *fund.RepaymentPlan)
+
+	sorted := make([]models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	next := 0
+	for _, transaction := range sorted {
+		if truncateDay(transaction.Date).After(asOf) {
+			break
+		}
+		if !coversInstalments(transaction) {
+			continue
+		}
+
+		left := transaction.Amount
+		for left > paymentTolerance && next < len(instalments) {
+			instalment := &instalments[next]
+			applied := math.Min(left, instalment.Amount-instalment.Paid)
+			instalment.Paid += applied
+			instalment.TransactionIDs = append(instalment.TransactionIDs, transaction.ID.Hex())
+			left -= applied
+
+			if instalment.Paid >= instalment.Amount-paymentTolerance {
+				paidOn := truncateDay(transaction.Date)
+				instalment.PaidOn = &paidOn
+				next++
+			}
+		}
+	}
+
+	response := &models.RepaymentScheduleResponse{
+		FundID:      fund.ID.Hex(),
+		Plan:        *fund.RepaymentPlan,
+		AsOf:        asOf,
+		Instalments: instalments,
+	}
+	for i := range instalments {
+		instalment := &instalments[i]
+		instalment.Paid = roundCents(instalment.Paid)
+		instalment.Remaining = roundCents(instalment.Amount - instalment.Paid)
+
+		switch {
+		case instalment.Remaining <= 0:
+			instalment.Remaining = 0
+			instalment.Status = models.InstalmentPaid
+		case instalment.DueDate.Before(asOf):
+			instalment.Status = models.InstalmentOverdue
+			response.OverdueAmount += instalment.Remaining
+		case instalment.Paid > 0:
+			instalment.Status = models.InstalmentPartial
+		default:
+			instalment.Status = models.InstalmentDue
+		}
+
+		if instalment.Status != models.InstalmentPaid && response.NextDueDate == nil {
+			dueDate := instalment.DueDate
+			response.NextDueDate = &dueDate
+		}
+		response.TotalDue += instalment.Amount
+		response.TotalPaid += instalment.Paid
+	}
+	response.TotalDue = roundCents(response.TotalDue)
+	response.TotalPaid = roundCents(response.TotalPaid)
+	response.OverdueAmount = roundCents(response.OverdueAmount)
+
+	return response
+}
+
+// coversInstalments reports whether a transaction counts towards a fund's instalments:
+// repayments do, and so do write-offs until they are reversed
+func coversInstalments(transaction models.Transaction) bool {
+	switch transactionType(transaction) {
+	case models.TransactionRepayment:
+		return true
+	case models.TransactionWriteOff:
+		return transaction.ReversedAt == nil
+	}
+	return false
+}
+
+// expectedInstalments generates the instalments of a plan. EQUAL plans split the
+// principal evenly, AMORTIZING plans charge the same amount every time with the
+// interest on the remaining principal taken first.
+func expectedInstalments(fund *models.Fund, plan models.RepaymentPlan) []models.Instalment {
+	instalments := []models.Instalment{}
+	newInstalment := func(dueDate time.Time, principal, interest float64) {
+		instalments = append(instalments, models.Instalment{
+			Number:         len(instalments) + 1,
+			DueDate:        dueDate,
+			Amount:         roundCents(principal + interest),
+			Principal:      roundCents(principal),
+			Interest:       roundCents(interest),
+			TransactionIDs: []string{},
+		})
+	}
+
+	if plan.Type == models.RepaymentPlanCustom {
+		for _, planned := range plan.Instalments {
+			newInstalment(planned.DueDate, planned.Amount, 0)
+		}
+		return instalments
+	}
+
+	rate := 0.0
+	if plan.Type == models.RepaymentPlanAmortizing && hasInterest(fund) {
+		rate = fund.InterestRate / 100 / instalmentsPerYear(plan.Frequency)
+	}
+
+	balance := fund.PrincipalAmount
+	payment := math.Floor(balance/float64(plan.Count)*100) / 100
+	if rate > 0 {
+		payment = roundCents(balance * rate / (1 - math.Pow(1+rate, -float64(plan.Count))))
+	}
+
+	for k := 0; k < plan.Count; k++ {
+		interest := roundCents(balance * rate)
+		principal := payment - interest
+		if k == plan.Count-1 || principal > balance {
+			principal = balance
+		}
+		newInstalment(instalmentDueDate(plan, k), principal, interest)
+		balance = roundCents(balance - principal)
+	}
+
+	return instalments
+}
+
+// instalmentDueDate returns the due date of the k-th (zero based) instalment of a plan
+func instalmentDueDate(plan models.RepaymentPlan, k int) time.Time {
+	switch plan.Frequency {
+	case models.RepaymentWeekly:
+		return plan.FirstDueDate.AddDate(0, 0, 7*k)
+	case models.RepaymentQuarterly:
+		return addMonths(plan.FirstDueDate, 3*k)
+	default:
+		return addMonths(plan.FirstDueDate, k)
+	}
+}
+
+func instalmentsPerYear(frequency models.RepaymentFrequency) float64 {
+	switch frequency {
+	case models.RepaymentWeekly:
+		return 52
+	case models.RepaymentQuarterly:
+		return 4
+	default:
+		return 12
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExpectedInstalments(t *testing.T) {
+	type instalment struct {
+		dueDate   time.Time
+		principal float64
+		interest  float64
+		amount    float64
+	}
+
+	tests := []struct {
+		name string
+		fund models.Fund
+		plan models.RepaymentPlan
+		want []instalment
+	}{
+		{
+			name: "equal monthly, the last instalment takes the rounding",
+			fund: models.Fund{PrincipalAmount: 1000},
+			plan: models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentMonthly, Count: 3, FirstDueDate: day(2026, 1, 31)},
+			want: []instalment{
+				{day(2026, 1, 31), 333.33, 0, 333.33},
+				{day(2026, 2, 28), 333.33, 0, 333.33},
+				{day(2026, 3, 31), 333.34, 0, 333.34},
+			},
+		},
+		{
+			name: "equal weekly",
+			fund: models.Fund{PrincipalAmount: 100},
+			plan: models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentWeekly, Count: 2, FirstDueDate: day(2026, 12, 28)},
+			want: []instalment{
+				{day(2026, 12, 28), 50, 0, 50},
+				{day(2027, 1, 4), 50, 0, 50},
+			},
+		},
+		{
+			name: "equal quarterly ignores interest",
+			fund: models.Fund{PrincipalAmount: 90, InterestType: models.InterestTypeSimple, InterestRate: 12},
+			plan: models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentQuarterly, Count: 2, FirstDueDate: day(2026, 11, 30)},
+			want: []instalment{
+				{day(2026, 11, 30), 45, 0, 45},
+				{day(2027, 2, 28), 45, 0, 45},
+			},
+		},
+		{
+			name: "amortizing monthly",
+			fund: models.Fund{PrincipalAmount: 1000, InterestType: models.InterestTypeCompound, InterestRate: 12},
+			plan: models.RepaymentPlan{Type: models.RepaymentPlanAmortizing, Frequency: models.RepaymentMonthly, Count: 3, FirstDueDate: day(2026, 2, 1)},
+			want: []instalment{
+				{day(2026, 2, 1), 330.02, 10, 340.02},
+				{day(2026, 3, 1), 333.32, 6.70, 340.02},
+				{day(2026, 4, 1), 336.66, 3.37, 340.03},
+			},
+		},
+		{
+			name: "custom",
+			fund: models.Fund{PrincipalAmount: 300},
+			plan: models.RepaymentPlan{Type: models.RepaymentPlanCustom, Instalments: []models.PlannedInstalment{
+				{DueDate: day(2026, 2, 1), Amount: 100},
+				{DueDate: day(2026, 5, 1), Amount: 250},
+			}},
+			want: []instalment{
+				{day(2026, 2, 1), 100, 0, 100},
+				{day(2026, 5, 1), 250, 0, 250},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := expectedInstalments(&tt.fund, tt.plan)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d instalments, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			g := got[i]
+			if g.Number != i+1 || !g.DueDate.Equal(want.dueDate) || g.Principal != want.principal || g.Interest != want.interest || g.Amount != want.amount {
+				t.Errorf("%s: instalment %d = #%d %s %.2f + %.2f = %.2f, want %s %.2f + %.2f = %.2f",
+					tt.name, i+1, g.Number, g.DueDate.Format("2006-01-02"), g.Principal, g.Interest, g.Amount,
+					want.dueDate.Format("2006-01-02"), want.principal, want.interest, want.amount)
+			}
+		}
+	}
+}
+
+func TestRepaymentSchedule(t *testing.T) {
+	reversed := time.Now()
+	first := models.Transaction{ID: primitive.NewObjectID(), Amount: 400, Date: day(2026, 2, 1)}
+	second := models.Transaction{ID: primitive.NewObjectID(), Amount: 100, Date: day(2026, 3, 10)}
+	fund := models.Fund{
+		PrincipalAmount: 1000,
+		StartDate:       day(2026, 1, 1),
+		RepaymentPlan:   &models.RepaymentPlan{Type: models.RepaymentPlanEqual, Frequency: models.RepaymentMonthly, Count: 3, FirstDueDate: day(2026, 2, 1)},
+	}
+	transactions := []models.Transaction{
+		second,
+		first,
+		// Neither charges nor reversed write-offs pay instalments
+		{ID: primitive.NewObjectID(), Type: models.TransactionDisbursement, Amount: 500, Date: day(2026, 2, 15)},
+		{ID: primitive.NewObjectID(), Type: models.TransactionWriteOff, Amount: 500, Date: day(2026, 2, 20), ReversedAt: &reversed},
+	}
+
+	type instalment struct {
+		paid   float64
+		status models.InstalmentStatus
+		ids    int
+	}
+	tests := []struct {
+		name      string
+		asOf      time.Time
+		want      []instalment
+		totalPaid float64
+		overdue   float64
+		nextDue   time.Time
+	}{
+		{
+			name: "before the first payment",
+			asOf: day(2026, 1, 15),
+			want: []instalment{
+				{0, models.InstalmentDue, 0},
+				{0, models.InstalmentDue, 0},
+				{0, models.InstalmentDue, 0},
+			},
+			nextDue: day(2026, 2, 1),
+		},
+		{
+			name: "a larger payment carries over",
+			asOf: day(2026, 2, 15),
+			want: []instalment{
+				{333.33, models.InstalmentPaid, 1},
+				{66.67, models.InstalmentPartial, 1},
+				{0, models.InstalmentDue, 0},
+			},
+			totalPaid: 400,
+			nextDue:   day(2026, 3, 1),
+		},
+		{
+			name: "behind on an instalment",
+			asOf: day(2026, 3, 15),
+			want: []instalment{
+				{333.33, models.InstalmentPaid, 1},
+				{166.67, models.InstalmentOverdue, 2},
+				{0, models.InstalmentDue, 0},
+			},
+			totalPaid: 500,
+			overdue:   166.66,
+			nextDue:   day(2026, 3, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		schedule := repaymentSchedule(&fund, transactions, tt.asOf)
+		for i, want := range tt.want {
+			got := schedule.Instalments[i]
+			if got.Paid != want.paid || got.Status != want.status || len(got.TransactionIDs) != want.ids {
+				t.Errorf("%s: instalment %d = %.2f paid, %s, %d transactions; want %.2f, %s, %d",
+					tt.name, i+1, got.Paid, got.Status, len(got.TransactionIDs), want.paid, want.status, want.ids)
+			}
+		}
+		if schedule.TotalDue != 1000 || schedule.TotalPaid != tt.totalPaid || schedule.OverdueAmount != tt.overdue {
+			t.Errorf("%s: totals = %.2f due, %.2f paid, %.2f overdue; want 1000, %.2f, %.2f",
+				tt.name, schedule.TotalDue, schedule.TotalPaid, schedule.OverdueAmount, tt.totalPaid, tt.overdue)
+		}
+		if schedule.NextDueDate == nil || !schedule.NextDueDate.Equal(tt.nextDue) {
+			t.Errorf("%s: nextDueDate = %v, want %s", tt.name, schedule.NextDueDate, tt.nextDue.Format("2006-01-02"))
+		}
+	}
+
+	paidOff := repaymentSchedule(&fund, append(transactions, models.Transaction{ID: primitive.NewObjectID(), Amount: 500, Date: day(2026, 4, 1)}), day(2026, 4, 1))
+	for _, instalment := range paidOff.Instalments {
+		if instalment.Status != models.InstalmentPaid || instalment.Remaining != 0 || instalment.PaidOn == nil {
+			t.Errorf("instalment %d = %s with %.2f remaining after paying everything", instalment.Number, instalment.Status, instalment.Remaining)
+		}
+	}
+	if paidOff.NextDueDate != nil {
+		t.Errorf("nextDueDate = %v once everything is paid, want none", paidOff.NextDueDate)
+	}
+}
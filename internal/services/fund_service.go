@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/huxxnainali/finance-app/internal/models"
@@ -15,9 +16,13 @@ import (
 type FundService struct {
 	fundCollection        *mongo.Collection
 	transactionCollection *mongo.Collection
+	settlementCollection  *mongo.Collection
+	contactService        *ContactService
+	budgetService         *BudgetService
+	reminder              FundReminder
 }
 
-func NewFundService(db *mongo.Database) *FundService {
+func NewFundService(db *mongo.Database, contactService *ContactService, budgetService *BudgetService, reminder FundReminder) *FundService {
 	fundCollection := db.Collection("funds")
 	transactionCollection := db.Collection("transactions")
 
@@ -37,16 +42,31 @@ func NewFundService(db *mongo.Database) *FundService {
 	}
 	transactionCollection.Indexes().CreateOne(context.Background(), transactionIndexModel)
 
+	settlementCollection := db.Collection("fund_settlements")
+	settlementCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "contactId", Value: 1},
+		},
+	})
+
 	return &FundService{
 		fundCollection:        fundCollection,
 		transactionCollection: transactionCollection,
+		settlementCollection:  settlementCollection,
+		contactService:        contactService,
+		budgetService:         budgetService,
+		reminder:              reminder,
 	}
 }
 
-// CalculateTotalPaid calculates the total amount paid from all transactions
+// CalculateTotalPaid calculates the total amount paid from all repayments
 func (fs *FundService) CalculateTotalPaid(ctx context.Context, fundID primitive.ObjectID) (float64, error) {
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"fundId": fundID}}},
+		{{Key: "$match", Value: bson.M{
+			"fundId": fundID,
+			"type":   bson.M{"$in": bson.A{nil, models.TransactionRepayment}},
+		}}},
 		{{Key: "$group", Value: bson.M{
 			"_id":   nil,
 			"total": bson.M{"$sum": "$amount"},
@@ -73,32 +93,160 @@ func (fs *FundService) CalculateTotalPaid(ctx context.Context, fundID primitive.
 	return 0, nil
 }
 
-// CalculateOutstanding calculates outstanding amount (principalAmount - totalPaid)
+// CalculateOutstanding calculates the outstanding amount as of today: the unpaid
+// principal plus any accrued, unpaid interest
 func (fs *FundService) CalculateOutstanding(ctx context.Context, fund *models.Fund) (float64, error) {
-	totalPaid, err := fs.CalculateTotalPaid(ctx, fund.ID)
+	transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
 	if err != nil {
 		return 0, err
 	}
 
-	outstanding := fund.PrincipalAmount - totalPaid
-	if outstanding < 0 {
-		outstanding = 0
-	}
-
-	return outstanding, nil
+	ledger := computeFundLedger(fund, transactions, defaultAsOf(transactions))
+	return roundCents(ledger.outstanding()), nil
 }
 
-// GetFundStatus returns "OPEN" if outstanding > 0, "PAID" if outstanding === 0
+// GetFundStatus returns the fund's status as of today: OPEN, PAID, OVERDUE, or the
+// DISPUTED or WRITTEN_OFF status set by the user
 func (fs *FundService) GetFundStatus(ctx context.Context, fund *models.Fund) (string, error) {
-	outstanding, err := fs.CalculateOutstanding(ctx, fund)
+	transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
 	if err != nil {
 		return "", err
 	}
 
-	if outstanding > 0 {
-		return "OPEN", nil
+	return string(evaluateFund(fund, transactions, defaultAsOf(transactions)).status), nil
+}
+
+// defaultAsOf is the day balances are reported for when none is asked for: today, or
+// the last payment's day when payments were recorded ahead of time
+func defaultAsOf(transactions []models.Transaction) time.Time {
+	asOf := truncateDay(time.Now())
+	for _, transaction := range transactions {
+		if transaction.Date.After(asOf) {
+			asOf = truncateDay(transaction.Date)
+		}
 	}
-	return "PAID", nil
+	return asOf
+}
+
+// NewFundResponse builds the API representation of a fund with its balances as of a
+// day. A zero asOf uses defaultAsOf.
+func (fs *FundService) NewFundResponse(ctx context.Context, fund *models.Fund, asOf time.Time) (*models.FundResponse, error) {
+	transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := fundResponse(fund, transactions, asOf)
+	return &response, nil
+}
+
+// fundResponse builds the API representation of a fund from its transactions. Every
+// transaction is listed with its split, including ones after asOf.
+func fundResponse(fund *models.Fund, transactions []models.Transaction, asOf time.Time) models.FundResponse {
+	if asOf.IsZero() {
+		asOf = defaultAsOf(transactions)
+	}
+	asOf = truncateDay(asOf)
+
+	state := evaluateFund(fund, transactions, asOf)
+	ledger := state.ledger
+	contactID := ""
+	if !fund.ContactID.IsZero() {
+		contactID = fund.ContactID.Hex()
+	}
+	split := ledger.transactions
+	if len(split) < len(transactions) {
+		split = computeFundLedger(fund, transactions, defaultAsOf(transactions)).transactions
+	}
+
+	return models.FundResponse{
+		ID:                   fund.ID.Hex(),
+		ContactID:            contactID,
+		PersonName:           fund.PersonName,
+		Type:                 fund.Type,
+		PrincipalAmount:      roundCents(fund.PrincipalAmount + ledger.disbursed),
+		InitialPrincipal:     fund.PrincipalAmount,
+		Disbursed:            roundCents(ledger.disbursed),
+		StartDate:            fund.StartDate,
+		Notes:                fund.Notes,
+		InterestRate:         fund.InterestRate,
+		InterestType:         fund.InterestType,
+		CompoundingPeriod:    fund.CompoundingPeriod,
+		DayCount:             fund.DayCount,
+		AsOf:                 asOf,
+		TotalPaid:            roundCents(ledger.totalPaid()),
+		PrincipalPaid:        roundCents(ledger.principalPaid),
+		InterestPaid:         roundCents(ledger.interestPaid),
+		PrincipalOutstanding: roundCents(math.Max(ledger.principal, 0)),
+		AccruedInterest:      roundCents(ledger.interest()),
+		InterestCharged:      roundCents(ledger.interestCharged),
+		FeesCharged:          roundCents(ledger.feesCharged),
+		LateFeesCharged:      roundCents(ledger.lateFeesCharged),
+		LateFees:             roundCents(ledger.fees),
+		Adjustments:          roundCents(ledger.adjustments),
+		WrittenOff:           roundCents(ledger.writtenOff),
+		Outstanding:          state.outstanding,
+		DueDate:              fund.DueDate,
+		NextDueDate:          state.nextDueDate,
+		DaysLate:             state.daysLate,
+		Status:               state.status,
+		MarkedStatus:         fund.MarkedStatus,
+		Transactions:         split,
+		CreatedAt:            fund.CreatedAt,
+		UpdatedAt:            fund.UpdatedAt,
+	}
+}
+
+func validateTransactionRequest(fund *models.Fund, req models.TransactionRequest) error {
+	switch req.Type {
+	case "", models.TransactionRepayment, models.TransactionFee, models.TransactionInterest:
+	case models.TransactionDisbursement:
+		if truncateDay(req.Date).Before(fundStartDate(fund)) {
+			return fmt.Errorf("disbursement date must not be before the fund's start date")
+		}
+	case models.TransactionAdjustment:
+		if req.Amount == 0 {
+			return fmt.Errorf("adjustment amount must not be 0")
+		}
+		return nil
+	case models.TransactionWriteOff:
+		return fmt.Errorf("write-offs are made with POST /funds/:fundId/write-offs")
+	default:
+		return fmt.Errorf("invalid transaction type, must be REPAYMENT, DISBURSEMENT, FEE, INTEREST or ADJUSTMENT")
+	}
+
+	if req.Amount <= 0 {
+		return fmt.Errorf("transaction amount must be greater than 0")
+	}
+	return nil
+}
+
+// checkTransaction verifies that a transaction doesn't overpay a fund given its other
+// transactions
+func checkTransaction(fund *models.Fund, others []models.Transaction, transaction models.Transaction) error {
+	transactions := append([]models.Transaction{transaction}, others...)
+	overpaid := computeFundLedger(fund, transactions, defaultAsOf(transactions)).overpaid
+	if overpaid == nil {
+		return nil
+	}
+	if transactionType(transaction) == models.TransactionAdjustment {
+		return fmt.Errorf("adjustment would take the principal below zero")
+	}
+	if transactionType(transaction) == models.TransactionWriteOff {
+		return fmt.Errorf("write-off amount would exceed outstanding amount")
+	}
+	if transactionType(*overpaid) == models.TransactionAdjustment {
+		return fmt.Errorf("transaction would leave an adjustment taking the principal below zero")
+	}
+	if transactionType(transaction) != models.TransactionRepayment {
+		return fmt.Errorf("transaction would leave later payments exceeding the outstanding amount")
+	}
+
+	maximum := maxPayment(fund, others, transaction.Date)
+	if !hasInterest(fund) {
+		return fmt.Errorf("transaction amount would exceed principal amount. Maximum allowed: %.2f", maximum)
+	}
+	return fmt.Errorf("transaction amount would exceed outstanding amount. Maximum allowed: %.2f", maximum)
 }
 
 // GetAllFunds retrieves all funds for a user
@@ -167,17 +315,38 @@ func (fs *FundService) CreateFund(ctx context.Context, userID string, req models
 		return nil, fmt.Errorf("principal amount must be greater than 0")
 	}
 
+	if err := validateFundTerms(req); err != nil {
+		return nil, err
+	}
+	if err := validateDueTerms(req); err != nil {
+		return nil, err
+	}
+	interestType, interestRate, compoundingPeriod, dayCount := fundTerms(req)
+	dueDate, lateFee := dueTerms(req)
+
+	contact, err := fs.contactService.ResolveContact(ctx, userID, req.ContactID, req.PersonName)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	fund := &models.Fund{
-		ID:              primitive.NewObjectID(),
-		UserID:          objID,
-		PersonName:      req.PersonName,
-		Type:            req.Type,
-		PrincipalAmount: req.PrincipalAmount,
-		StartDate:       req.StartDate,
-		Notes:           req.Notes,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+		ID:                primitive.NewObjectID(),
+		UserID:            objID,
+		ContactID:         contact.ID,
+		PersonName:        contact.Name,
+		Type:              req.Type,
+		PrincipalAmount:   req.PrincipalAmount,
+		StartDate:         req.StartDate,
+		Notes:             req.Notes,
+		InterestRate:      interestRate,
+		InterestType:      interestType,
+		CompoundingPeriod: compoundingPeriod,
+		DayCount:          dayCount,
+		DueDate:           dueDate,
+		LateFee:           lateFee,
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
 	_, err = fs.fundCollection.InsertOne(ctx, fund)
@@ -201,7 +370,7 @@ func (fs *FundService) UpdateFund(ctx context.Context, userID, fundID string, re
 	}
 
 	// Verify fund exists and belongs to user
-	_, err = fs.GetFundByID(ctx, userID, fundID)
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
 	if err != nil {
 		return nil, err
 	}
@@ -211,38 +380,83 @@ func (fs *FundService) UpdateFund(ctx context.Context, userID, fundID string, re
 		return nil, fmt.Errorf("invalid fund type, must be BORROWED or GIVEN")
 	}
 
-	// Calculate current total paid
-	totalPaid, err := fs.CalculateTotalPaid(ctx, fundObjID)
+	// Validate principal amount
+	if req.PrincipalAmount <= 0 {
+		return nil, fmt.Errorf("principal amount must be greater than 0")
+	}
+
+	if err := validateFundTerms(req); err != nil {
+		return nil, err
+	}
+	if err := validateDueTerms(req); err != nil {
+		return nil, err
+	}
+	interestType, interestRate, compoundingPeriod, dayCount := fundTerms(req)
+	dueDate, lateFee := dueTerms(req)
+
+	contact, err := fs.contactService.ResolveContact(ctx, userID, req.ContactID, req.PersonName)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate: principalAmount MUST be >= totalPaid
-	if req.PrincipalAmount < totalPaid {
-		return nil, fmt.Errorf("principal amount cannot be less than total paid (%.2f)", totalPaid)
+	lock, err := fs.lockFund(ctx, fund)
+	if err != nil {
+		return nil, err
 	}
+	defer lock.release()
 
-	// Validate principal amount
-	if req.PrincipalAmount <= 0 {
-		return nil, fmt.Errorf("principal amount must be greater than 0")
+	transactions, err := fs.GetTransactionsByFundID(ctx, fundObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Once the fund has transactions its principal is part of their history: more is
+	// lent or borrowed with a disbursement and corrections are adjustments
+	if req.PrincipalAmount != fund.PrincipalAmount && len(transactions) > 0 {
+		return nil, fmt.Errorf("principal amount can't be changed once the fund has transactions, add a DISBURSEMENT or ADJUSTMENT instead")
+	}
+
+	// Validate: the payments made so far must not overpay the fund under its new terms
+	updated := *fund
+	updated.PrincipalAmount = req.PrincipalAmount
+	updated.StartDate = req.StartDate
+	updated.InterestRate = interestRate
+	updated.InterestType = interestType
+	updated.CompoundingPeriod = compoundingPeriod
+	updated.DayCount = dayCount
+	updated.DueDate = dueDate
+	updated.LateFee = lateFee
+	if computeFundLedger(&updated, transactions, defaultAsOf(transactions)).overpaid != nil {
+		return nil, fmt.Errorf("payments made so far would exceed the outstanding amount under these terms")
 	}
 
-	// Update fund
+	// Update fund, only while the lease is still held
+	if err := lock.fence(ctx); err != nil {
+		return nil, err
+	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	result := &models.Fund{}
 	err = fs.fundCollection.FindOneAndUpdate(ctx,
 		bson.M{
-			"_id":    fundObjID,
-			"userId": userObjID,
+			"_id":         fundObjID,
+			"userId":      userObjID,
+			"lockedUntil": lock.until,
 		},
 		bson.M{
 			"$set": bson.M{
-				"personName":      req.PersonName,
-				"type":            req.Type,
-				"principalAmount": req.PrincipalAmount,
-				"startDate":       req.StartDate,
-				"notes":           req.Notes,
-				"updatedAt":       time.Now(),
+				"contactId":         contact.ID,
+				"personName":        contact.Name,
+				"type":              req.Type,
+				"principalAmount":   req.PrincipalAmount,
+				"startDate":         req.StartDate,
+				"notes":             req.Notes,
+				"interestRate":      interestRate,
+				"interestType":      interestType,
+				"compoundingPeriod": compoundingPeriod,
+				"dayCount":          dayCount,
+				"dueDate":           dueDate,
+				"lateFee":           lateFee,
+				"updatedAt":         time.Now(),
 			},
 		},
 		opts,
@@ -250,7 +464,7 @@ func (fs *FundService) UpdateFund(ctx context.Context, userID, fundID string, re
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("fund not found or doesn't belong to user")
+			return nil, fmt.Errorf("fund is being updated by another request, try again")
 		}
 		return nil, err
 	}
@@ -271,29 +485,85 @@ func (fs *FundService) DeleteFund(ctx context.Context, userID, fundID string) er
 	}
 
 	// Verify fund belongs to user
-	_, err = fs.GetFundByID(ctx, userID, fundID)
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
 	if err != nil {
 		return err
 	}
 
-	// Delete all transactions for this fund
-	_, err = fs.transactionCollection.DeleteMany(ctx, bson.M{"fundId": fundObjID})
+	// Holding the lease keeps payments from being added while the fund goes away
+	lock, err := fs.lockFund(ctx, fund)
 	if err != nil {
 		return err
 	}
+	defer lock.release()
 
-	// Delete fund
-	_, err = fs.fundCollection.DeleteOne(ctx, bson.M{
-		"_id":    fundObjID,
-		"userId": userObjID,
+	// Delete the fund first, only while the lease is still held: once it is gone no
+	// payment can be added to it, and transactions left behind by a failure below
+	// belong to no fund and are removed by PurgeOrphanedTransactions
+	if err := lock.fence(ctx); err != nil {
+		return err
+	}
+	result, err := fs.fundCollection.DeleteOne(ctx, bson.M{
+		"_id":         fundObjID,
+		"userId":      userObjID,
+		"lockedUntil": lock.until,
 	})
 	if err != nil {
 		return err
 	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("fund is being updated by another request, try again")
+	}
+
+	// Delete all transactions for this fund
+	_, err = fs.transactionCollection.DeleteMany(ctx, bson.M{"fundId": fundObjID})
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// PurgeOrphanedTransactions deletes the transactions whose fund is gone, left behind
+// when deleting a fund failed halfway, and returns how many were deleted
+func (fs *FundService) PurgeOrphanedTransactions(ctx context.Context) (int, error) {
+	// Funds are listed after their transactions, so a fund created in between
+	// has no transactions in the first list
+	fundIDs, err := fs.transactionCollection.Distinct(ctx, "fundId", bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	if len(fundIDs) == 0 {
+		return 0, nil
+	}
+
+	existing, err := fs.fundCollection.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": fundIDs}})
+	if err != nil {
+		return 0, err
+	}
+	found := make(map[interface{}]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+
+	var orphaned bson.A
+	for _, id := range fundIDs {
+		if !found[id] {
+			orphaned = append(orphaned, id)
+		}
+	}
+	if len(orphaned) == 0 {
+		return 0, nil
+	}
+
+	result, err := fs.transactionCollection.DeleteMany(ctx, bson.M{"fundId": bson.M{"$in": orphaned}})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.DeletedCount), nil
+}
+
 // GetTransactionsByFundID retrieves all transactions for a fund
 func (fs *FundService) GetTransactionsByFundID(ctx context.Context, fundID primitive.ObjectID) ([]models.Transaction, error) {
 	cursor, err := fs.transactionCollection.Find(ctx, bson.M{"fundId": fundID}, options.Find().SetSort(bson.M{"date": 1}))
@@ -323,33 +593,46 @@ func (fs *FundService) AddTransaction(ctx context.Context, userID, fundID string
 		return nil, err
 	}
 
-	// Validate transaction amount
-	if req.Amount <= 0 {
-		return nil, fmt.Errorf("transaction amount must be greater than 0")
+	// Validate transaction type and amount
+	if err := validateTransactionRequest(fund, req); err != nil {
+		return nil, err
 	}
 
-	// Calculate current total paid
-	totalPaid, err := fs.CalculateTotalPaid(ctx, fundObjID)
+	// Hold the fund's lease from the check until the insert, so that concurrent
+	// payments are checked one after the other
+	lock, err := fs.lockFund(ctx, fund)
 	if err != nil {
 		return nil, err
 	}
-
-	// Validate: sum(transactions.amount) <= principalAmount
-	if totalPaid+req.Amount > fund.PrincipalAmount {
-		return nil, fmt.Errorf("transaction amount would exceed principal amount. Maximum allowed: %.2f", fund.PrincipalAmount-totalPaid)
-	}
+	defer lock.release()
 
 	// Create transaction
 	transaction := &models.Transaction{
 		ID:        primitive.NewObjectID(),
 		FundID:    fundObjID,
+		Type:      transactionType(models.Transaction{Type: req.Type}),
 		Amount:    req.Amount,
 		Date:      req.Date,
 		Note:      req.Note,
 		CreatedAt: time.Now(),
 	}
 
-	_, err = fs.transactionCollection.InsertOne(ctx, transaction)
+	// Validate: a repayment must not exceed what is owed, interest included, and
+	// no other transaction may leave later repayments doing so
+	transactions, err := fs.GetTransactionsByFundID(ctx, fundObjID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransaction(fund, transactions, *transaction); err != nil {
+		return nil, err
+	}
+
+	err = lock.write(ctx, func() error {
+		_, err := fs.transactionCollection.InsertOne(ctx, transaction)
+		return err
+	}, func() {
+		fs.transactionCollection.DeleteOne(ctx, bson.M{"_id": transaction.ID})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -375,6 +658,12 @@ func (fs *FundService) UpdateTransaction(ctx context.Context, userID, fundID, tr
 		return nil, err
 	}
 
+	lock, err := fs.lockFund(ctx, fund)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
 	// Verify transaction belongs to fund
 	existingTransaction := &models.Transaction{}
 	err = fs.transactionCollection.FindOne(ctx, bson.M{
@@ -388,41 +677,65 @@ func (fs *FundService) UpdateTransaction(ctx context.Context, userID, fundID, tr
 		}
 		return nil, err
 	}
+	if existingTransaction.SettlementID != nil {
+		return nil, fmt.Errorf("transaction is part of a settlement")
+	}
+	if existingTransaction.Type == models.TransactionWriteOff {
+		return nil, fmt.Errorf("write-offs can only be reversed")
+	}
 
-	// Validate transaction amount
-	if req.Amount <= 0 {
-		return nil, fmt.Errorf("transaction amount must be greater than 0")
+	// Validate transaction type and amount
+	if err := validateTransactionRequest(fund, req); err != nil {
+		return nil, err
 	}
+	entryType := transactionType(models.Transaction{Type: req.Type})
 
-	// Calculate current total paid (excluding the transaction being updated)
-	totalPaid, err := fs.CalculateTotalPaid(ctx, fundObjID)
+	// Validate: the transaction must not overpay the fund, excluding the transaction being updated
+	transactions, err := fs.GetTransactionsByFundID(ctx, fundObjID)
 	if err != nil {
 		return nil, err
 	}
-	totalPaidWithoutThis := totalPaid - existingTransaction.Amount
-
-	// Validate: sum(transactions.amount) <= principalAmount
-	if totalPaidWithoutThis+req.Amount > fund.PrincipalAmount {
-		return nil, fmt.Errorf("transaction amount would exceed principal amount. Maximum allowed: %.2f", fund.PrincipalAmount-totalPaidWithoutThis)
+	others := make([]models.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if transaction.ID != existingTransaction.ID {
+			others = append(others, transaction)
+		}
+	}
+	updated := models.Transaction{Type: entryType, Amount: req.Amount, Date: req.Date}
+	if err := checkTransaction(fund, others, updated); err != nil {
+		return nil, err
 	}
 
 	// Update transaction
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	result := &models.Transaction{}
-	err = fs.transactionCollection.FindOneAndUpdate(ctx,
-		bson.M{
-			"_id":    transactionObjID,
-			"fundId": fundObjID,
-		},
-		bson.M{
-			"$set": bson.M{
-				"amount": req.Amount,
-				"date":   req.Date,
-				"note":   req.Note,
+	err = lock.write(ctx, func() error {
+		return fs.transactionCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"_id":    transactionObjID,
+				"fundId": fundObjID,
 			},
-		},
-		opts,
-	).Decode(result)
+			bson.M{
+				"$set": bson.M{
+					"type":   entryType,
+					"amount": req.Amount,
+					"date":   req.Date,
+					"note":   req.Note,
+				},
+			},
+			opts,
+		).Decode(result)
+	}, func() {
+		fs.transactionCollection.UpdateOne(ctx,
+			bson.M{"_id": existingTransaction.ID},
+			bson.M{"$set": bson.M{
+				"type":   transactionType(*existingTransaction),
+				"amount": existingTransaction.Amount,
+				"date":   existingTransaction.Date,
+				"note":   existingTransaction.Note,
+			}},
+		)
+	})
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -447,11 +760,17 @@ func (fs *FundService) DeleteTransaction(ctx context.Context, userID, fundID, tr
 	}
 
 	// Verify fund belongs to user
-	_, err = fs.GetFundByID(ctx, userID, fundID)
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
 	if err != nil {
 		return err
 	}
 
+	lock, err := fs.lockFund(ctx, fund)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
 	// Verify transaction belongs to fund
 	var transaction models.Transaction
 	err = fs.transactionCollection.FindOne(ctx, bson.M{
@@ -465,16 +784,37 @@ func (fs *FundService) DeleteTransaction(ctx context.Context, userID, fundID, tr
 		}
 		return err
 	}
+	if transaction.SettlementID != nil {
+		return fmt.Errorf("transaction is part of a settlement")
+	}
+	if transaction.Type == models.TransactionWriteOff {
+		return fmt.Errorf("write-offs can only be reversed")
+	}
 
-	// Delete transaction
-	_, err = fs.transactionCollection.DeleteOne(ctx, bson.M{
-		"_id":    transactionObjID,
-		"fundId": fundObjID,
-	})
+	// Removing a disbursement or charge, or a repayment that saved interest, leaves
+	// less to pay back, which later repayments must not end up exceeding
+	transactions, err := fs.GetTransactionsByFundID(ctx, fundObjID)
 	if err != nil {
 		return err
 	}
+	others := make([]models.Transaction, 0, len(transactions))
+	for _, other := range transactions {
+		if other.ID != transaction.ID {
+			others = append(others, other)
+		}
+	}
+	if computeFundLedger(fund, others, defaultAsOf(others)).overpaid != nil {
+		return fmt.Errorf("deleting this transaction would leave later payments exceeding the outstanding amount")
+	}
 
-	return nil
+	// Delete transaction
+	return lock.write(ctx, func() error {
+		_, err := fs.transactionCollection.DeleteOne(ctx, bson.M{
+			"_id":    transactionObjID,
+			"fundId": fundObjID,
+		})
+		return err
+	}, func() {
+		fs.transactionCollection.InsertOne(ctx, transaction)
+	})
 }
-
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+func TestUpdateFundKeepsPrincipalOnceItHasTransactions(t *testing.T) {
+	fs, userID, fund := newTestFund(t, 100)
+	ctx := context.Background()
+
+	update := models.FundRequest{
+		PersonName:      fund.PersonName,
+		Type:            fund.Type,
+		PrincipalAmount: 300,
+		StartDate:       fund.StartDate,
+	}
+
+	// Without transactions the principal is still just a term of the fund
+	updated, err := fs.UpdateFund(ctx, userID, fund.ID.Hex(), update)
+	if err != nil {
+		t.Fatalf("UpdateFund without transactions: %v", err)
+	}
+	if updated.PrincipalAmount != 300 {
+		t.Fatalf("principalAmount = %.2f, want 300", updated.PrincipalAmount)
+	}
+
+	_, err = fs.AddTransaction(ctx, userID, fund.ID.Hex(), models.TransactionRequest{
+		Amount: 50,
+		Date:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+
+	update.PrincipalAmount = 500
+	if _, err := fs.UpdateFund(ctx, userID, fund.ID.Hex(), update); err == nil {
+		t.Fatal("UpdateFund changed the principal of a fund with transactions")
+	}
+
+	// Other fields can still be changed
+	update.PrincipalAmount = 300
+	update.Notes = "Paid in cash"
+	updated, err = fs.UpdateFund(ctx, userID, fund.ID.Hex(), update)
+	if err != nil {
+		t.Fatalf("UpdateFund keeping the principal: %v", err)
+	}
+	if updated.Notes != "Paid in cash" {
+		t.Errorf("notes = %q, want %q", updated.Notes, "Paid in cash")
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SettleContact pays off all of a contact's open funds as of a day with one
+// transaction each and records the settlement. Disputed and written-off funds are
+// left out. Either every transaction and the settlement are recorded or none are.
+func (fs *FundService) SettleContact(ctx context.Context, userID, contactID string, req models.SettlementRequest) (*models.Settlement, error) {
+	contact, err := fs.contactService.GetContactByID(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := truncateDay(time.Now())
+	if req.Date != nil {
+		date = truncateDay(*req.Date)
+	}
+
+	cursor, err := fs.fundCollection.Find(ctx, bson.M{
+		"userId":       contact.UserID,
+		"contactId":    contact.ID,
+		"markedStatus": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var funds []models.Fund
+	if err := cursor.All(ctx, &funds); err != nil {
+		return nil, err
+	}
+
+	// Take every fund's lease, always in the same order so that two settlements
+	// don't wait on each other, and keep them until the transactions are written
+	sort.Slice(funds, func(i, j int) bool {
+		return funds[i].ID.Hex() < funds[j].ID.Hex()
+	})
+	locks := make([]*fundLock, 0, len(funds))
+	for i := range funds {
+		lock, err := fs.lockFund(ctx, &funds[i])
+		if err != nil {
+			return nil, err
+		}
+		defer lock.release()
+		locks = append(locks, lock)
+	}
+
+	now := time.Now()
+	settlement := &models.Settlement{
+		ID:        primitive.NewObjectID(),
+		UserID:    contact.UserID,
+		ContactID: contact.ID,
+		Date:      date,
+		Note:      req.Note,
+		Funds:     []models.SettledFund{},
+		CreatedAt: now,
+	}
+	settling := make([]fundWithTransactions, 0, len(funds))
+	for _, fund := range funds {
+		existing, err := fs.GetTransactionsByFundID(ctx, fund.ID)
+		if err != nil {
+			return nil, err
+		}
+		settling = append(settling, fundWithTransactions{Fund: fund, Transactions: existing})
+	}
+	repayments, err := settleFunds(settlement, settling)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]interface{}, len(repayments))
+	for i := range repayments {
+		transactions[i] = repayments[i]
+	}
+
+	// The settlement is recorded first so no transaction exists without it, and the
+	// transactions go in with one insert; whatever was written is undone on failure
+	undo := func() {
+		fs.transactionCollection.DeleteMany(ctx, bson.M{"settlementId": settlement.ID})
+		fs.settlementCollection.DeleteOne(ctx, bson.M{"_id": settlement.ID})
+	}
+	err = fencedWrite(ctx, locks, func() error {
+		if _, err := fs.settlementCollection.InsertOne(ctx, settlement); err != nil {
+			return err
+		}
+		if _, err := fs.transactionCollection.InsertMany(ctx, transactions); err != nil {
+			undo()
+			return err
+		}
+		return nil
+	}, undo)
+	if err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// settleFunds adds a repayment of each fund's outstanding amount as of the
+// settlement's date to the settlement, with the totals given, borrowed and net,
+// and returns the repayments. Disputed and written-off funds and funds with
+// nothing outstanding are left out.
+func settleFunds(settlement *models.Settlement, funds []fundWithTransactions) ([]models.Transaction, error) {
+	var repayments []models.Transaction
+	for i := range funds {
+		fund := &funds[i].Fund
+		if fund.MarkedStatus != "" {
+			continue
+		}
+		existing := funds[i].Transactions
+
+		amount := roundCents(computeFundLedger(fund, existing, settlement.Date).outstanding())
+		if amount <= 0 {
+			continue
+		}
+		for _, transaction := range existing {
+			if truncateDay(transaction.Date).After(settlement.Date) {
+				return nil, fmt.Errorf("fund for %s has payments after the settlement date", fund.PersonName)
+			}
+		}
+
+		repayment := models.Transaction{
+			ID:           primitive.NewObjectID(),
+			FundID:       fund.ID,
+			Type:         models.TransactionRepayment,
+			Amount:       amount,
+			Date:         settlement.Date,
+			Note:         "Settlement",
+			SettlementID: &settlement.ID,
+			CreatedAt:    settlement.CreatedAt,
+		}
+		repayments = append(repayments, repayment)
+
+		settlement.Funds = append(settlement.Funds, models.SettledFund{
+			FundID:        fund.ID,
+			TransactionID: repayment.ID,
+			Type:          fund.Type,
+			Amount:        amount,
+		})
+		if fund.Type == models.FundTypeGiven {
+			settlement.Given += amount
+		} else {
+			settlement.Borrowed += amount
+		}
+	}
+	if len(settlement.Funds) == 0 {
+		return nil, fmt.Errorf("contact has no open funds to settle")
+	}
+	settlement.Given = roundCents(settlement.Given)
+	settlement.Borrowed = roundCents(settlement.Borrowed)
+	settlement.Net = roundCents(settlement.Given - settlement.Borrowed)
+
+	return repayments, nil
+}
+
+// GetSettlements lists a contact's settlements, latest first
+func (fs *FundService) GetSettlements(ctx context.Context, userID, contactID string) ([]models.Settlement, error) {
+	contact, err := fs.contactService.GetContactByID(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "createdAt", Value: -1}})
+	cursor, err := fs.settlementCollection.Find(ctx, bson.M{
+		"userId":    contact.UserID,
+		"contactId": contact.ID,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	settlements := []models.Settlement{}
+	if err := cursor.All(ctx, &settlements); err != nil {
+		return nil, err
+	}
+
+	return settlements, nil
+}
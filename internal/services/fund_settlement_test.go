@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSettleFunds(t *testing.T) {
+	fund := func(name string, fundType models.FundType, principal float64, marked models.FundStatus, payments ...models.Transaction) fundWithTransactions {
+		return fundWithTransactions{
+			Fund: models.Fund{
+				ID:              primitive.NewObjectID(),
+				PersonName:      name,
+				Type:            fundType,
+				PrincipalAmount: principal,
+				StartDate:       day(2026, 1, 1),
+				MarkedStatus:    marked,
+			},
+			Transactions: payments,
+		}
+	}
+	repayment := func(amount float64, date int) models.Transaction {
+		return models.Transaction{Type: models.TransactionRepayment, Amount: amount, Date: day(2026, 3, date)}
+	}
+
+	tests := []struct {
+		name  string
+		funds []fundWithTransactions
+		// amounts are the settled funds' amounts, in the order of funds
+		amounts              []float64
+		given, borrowed, net float64
+		wantErr              bool
+	}{
+		{
+			name: "nets given against borrowed",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 100, "", repayment(30.25, 1)),
+				fund("Ali", models.FundTypeBorrowed, 50, ""),
+				fund("Ali", models.FundTypeGiven, 20, ""),
+			},
+			amounts: []float64{69.75, 50, 20},
+			given:   89.75, borrowed: 50, net: 39.75,
+		},
+		{
+			name: "owing more than owed",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 40, ""),
+				fund("Ali", models.FundTypeBorrowed, 100, ""),
+			},
+			amounts: []float64{40, 100},
+			given:   40, borrowed: 100, net: -60,
+		},
+		{
+			name: "written-off and disputed funds left out",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 200, models.FundStatusWrittenOff),
+				fund("Ali", models.FundTypeBorrowed, 80, models.FundStatusDisputed),
+				fund("Ali", models.FundTypeGiven, 100, ""),
+			},
+			amounts: []float64{100},
+			given:   100, net: 100,
+		},
+		{
+			name: "paid off funds left out",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 100, "", repayment(100, 1)),
+				fund("Ali", models.FundTypeBorrowed, 40, ""),
+			},
+			amounts:  []float64{40},
+			borrowed: 40, net: -40,
+		},
+		{
+			name: "payments after the settlement date",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 100, "", repayment(30, 20)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "marked funds only",
+			funds: []fundWithTransactions{
+				fund("Ali", models.FundTypeGiven, 100, models.FundStatusDisputed, repayment(30, 20)),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settlement := &models.Settlement{
+				ID:    primitive.NewObjectID(),
+				Date:  day(2026, 3, 15),
+				Funds: []models.SettledFund{},
+			}
+			repayments, err := settleFunds(settlement, tt.funds)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("settleFunds succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("settleFunds: %v", err)
+			}
+
+			if len(settlement.Funds) != len(tt.amounts) || len(repayments) != len(tt.amounts) {
+				t.Fatalf("%d funds settled with %d repayments, want %d", len(settlement.Funds), len(repayments), len(tt.amounts))
+			}
+			for i, settled := range settlement.Funds {
+				if settled.Amount != tt.amounts[i] {
+					t.Errorf("fund %d amount = %.2f, want %.2f", i, settled.Amount, tt.amounts[i])
+				}
+				repayment := repayments[i]
+				if repayment.ID != settled.TransactionID || repayment.FundID != settled.FundID || repayment.Amount != settled.Amount {
+					t.Errorf("fund %d repayment = %+v, doesn't match %+v", i, repayment, settled)
+				}
+				if repayment.Type != models.TransactionRepayment || !repayment.Date.Equal(settlement.Date) {
+					t.Errorf("fund %d repayment is a %s on %s, want a repayment on the settlement date", i, repayment.Type, repayment.Date)
+				}
+				if repayment.SettlementID == nil || *repayment.SettlementID != settlement.ID {
+					t.Errorf("fund %d repayment isn't linked to the settlement", i)
+				}
+			}
+			if settlement.Given != tt.given || settlement.Borrowed != tt.borrowed || settlement.Net != tt.net {
+				t.Errorf("given, borrowed, net = %.2f, %.2f, %.2f, want %.2f, %.2f, %.2f",
+					settlement.Given, settlement.Borrowed, settlement.Net, tt.given, tt.borrowed, tt.net)
+			}
+		})
+	}
+}
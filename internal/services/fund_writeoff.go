@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultWriteOffCategory is the expense category of written-off funds
+const DefaultWriteOffCategory = "Bad Debt"
+
+// WriteOff writes off part or all of what is owed on a fund as of a day. The
+// write-off settles fees, interest and principal like a repayment, but isn't counted
+// as paid. A written-off GIVEN fund can be recorded as an expense.
+func (fs *FundService) WriteOff(ctx context.Context, userID, fundID string, req models.WriteOffRequest) (*models.Transaction, error) {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if req.RecordExpense && fund.Type != models.FundTypeGiven {
+		return nil, fmt.Errorf("only write-offs of GIVEN funds can be recorded as an expense")
+	}
+
+	date := truncateDay(time.Now())
+	if req.Date != nil {
+		date = truncateDay(*req.Date)
+	}
+
+	lock, err := fs.lockFund(ctx, fund)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	transactions, err := fs.GetTransactionsByFundID(ctx, fund.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := roundCents(computeFundLedger(fund, transactions, date).outstanding())
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount <= 0 {
+		if req.Amount == nil {
+			return nil, fmt.Errorf("nothing is outstanding to write off")
+		}
+		return nil, fmt.Errorf("write-off amount must be greater than 0")
+	}
+
+	now := time.Now()
+	transaction := &models.Transaction{
+		ID:        primitive.NewObjectID(),
+		FundID:    fund.ID,
+		Type:      models.TransactionWriteOff,
+		Amount:    amount,
+		Date:      date,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if err := checkTransaction(fund, transactions, *transaction); err != nil {
+		return nil, err
+	}
+
+	var expense *models.Expense
+	if req.RecordExpense {
+		category := strings.TrimSpace(req.Category)
+		if category == "" {
+			category = DefaultWriteOffCategory
+		}
+		expense = &models.Expense{
+			ID:        primitive.NewObjectID(),
+			Title:     "Write-off: " + fund.PersonName,
+			Amount:    amount,
+			Category:  category,
+			Merchant:  fund.PersonName,
+			Date:      &date,
+			CreatedAt: now,
+		}
+		transaction.ExpenseID = &expense.ID
+	}
+
+	err = lock.write(ctx, func() error {
+		_, err := fs.transactionCollection.InsertOne(ctx, transaction)
+		return err
+	}, func() {
+		fs.transactionCollection.DeleteOne(ctx, bson.M{"_id": transaction.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expense != nil {
+		if _, err := fs.budgetService.AddExpense(ctx, userID, date.Year(), int(date.Month()), *expense); err != nil {
+			fs.transactionCollection.DeleteOne(ctx, bson.M{"_id": transaction.ID})
+			return nil, err
+		}
+	}
+
+	return transaction, nil
+}
+
+// ReverseWriteOff undoes a write-off, putting back what it took off the fund and
+// deleting the expense it recorded. The write-off stays listed with when it was reversed.
+func (fs *FundService) ReverseWriteOff(ctx context.Context, userID, fundID, transactionID string) (*models.Transaction, error) {
+	fund, err := fs.GetFundByID(ctx, userID, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionObjID, err := primitive.ObjectIDFromHex(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID")
+	}
+
+	lock, err := fs.lockFund(ctx, fund)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	writeOff := &models.Transaction{}
+	err = fs.transactionCollection.FindOne(ctx, bson.M{
+		"_id":    transactionObjID,
+		"fundId": fund.ID,
+		"type":   models.TransactionWriteOff,
+	}).Decode(writeOff)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("write-off not found or doesn't belong to fund")
+		}
+		return nil, err
+	}
+	if writeOff.ReversedAt != nil {
+		return nil, fmt.Errorf("write-off is already reversed")
+	}
+
+	// Reversing only adds to what is owed, so it can't make payments overpay and
+	// needs no undoing when the lease is lost after it
+	if err := lock.fence(ctx); err != nil {
+		return nil, err
+	}
+
+	// An expense the user already deleted has nothing left to undo
+	if writeOff.ExpenseID != nil {
+		_, err := fs.budgetService.DeleteExpense(ctx, userID, writeOff.ExpenseID.Hex())
+		if err != nil && err.Error() != "expense not found or doesn't belong to user" {
+			return nil, err
+		}
+	}
+
+	err = fs.transactionCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": writeOff.ID, "reversedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"reversedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(writeOff)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("write-off is already reversed")
+		}
+		return nil, err
+	}
+
+	return writeOff, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWriteOffAndReverse(t *testing.T) {
+	db := testDatabase(t)
+	budgetService := NewBudgetService(db, nil)
+	fs := NewFundService(db, NewContactService(db), budgetService, nil)
+	userID := primitive.NewObjectID().Hex()
+	ctx := context.Background()
+
+	fund, err := fs.CreateFund(ctx, userID, models.FundRequest{
+		PersonName:      "Ali",
+		Type:            models.FundTypeGiven,
+		PrincipalAmount: 100,
+		StartDate:       day(2026, 1, 1),
+	})
+	if err != nil {
+		t.Fatalf("CreateFund: %v", err)
+	}
+	if _, err := fs.AddTransaction(ctx, userID, fund.ID.Hex(), models.TransactionRequest{Amount: 30, Date: day(2026, 2, 1)}); err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+
+	date := day(2026, 3, 10)
+	tooMuch := 80.0
+	rejected := []struct {
+		name string
+		req  models.WriteOffRequest
+	}{
+		{"without a reason", models.WriteOffRequest{Date: &date}},
+		{"more than is outstanding", models.WriteOffRequest{Amount: &tooMuch, Date: &date, Reason: "Moved away"}},
+	}
+	for _, tt := range rejected {
+		if _, err := fs.WriteOff(ctx, userID, fund.ID.Hex(), tt.req); err == nil {
+			t.Errorf("WriteOff %s succeeded", tt.name)
+		}
+	}
+
+	writeOff, err := fs.WriteOff(ctx, userID, fund.ID.Hex(), models.WriteOffRequest{Date: &date, Reason: "Moved away", RecordExpense: true})
+	if err != nil {
+		t.Fatalf("WriteOff: %v", err)
+	}
+	if writeOff.Amount != 70 || writeOff.ExpenseID == nil {
+		t.Fatalf("write-off = %.2f with expense %v, want the outstanding 70 with an expense", writeOff.Amount, writeOff.ExpenseID)
+	}
+
+	response, err := fs.NewFundResponse(ctx, fund, day(2026, 4, 1))
+	if err != nil {
+		t.Fatalf("NewFundResponse: %v", err)
+	}
+	if response.Outstanding != 0 || response.WrittenOff != 70 || response.TotalPaid != 30 || response.Status != models.FundStatusWrittenOff {
+		t.Errorf("after the write-off: %.2f outstanding, %.2f written off, %.2f paid, %s; want 0, 70, 30, WRITTEN_OFF",
+			response.Outstanding, response.WrittenOff, response.TotalPaid, response.Status)
+	}
+
+	_, expense, err := budgetService.FindExpense(ctx, userID, writeOff.ExpenseID.Hex())
+	if err != nil {
+		t.Fatalf("FindExpense: %v", err)
+	}
+	if expense.Amount != 70 || expense.Category != DefaultWriteOffCategory || expense.Title != "Write-off: Ali" {
+		t.Errorf("expense = %q, %.2f, %q; want %q, 70, %q", expense.Title, expense.Amount, expense.Category, "Write-off: Ali", DefaultWriteOffCategory)
+	}
+
+	reversed, err := fs.ReverseWriteOff(ctx, userID, fund.ID.Hex(), writeOff.ID.Hex())
+	if err != nil {
+		t.Fatalf("ReverseWriteOff: %v", err)
+	}
+	if reversed.ReversedAt == nil {
+		t.Error("reversed write-off has no reversedAt")
+	}
+	if _, _, err := budgetService.FindExpense(ctx, userID, writeOff.ExpenseID.Hex()); err == nil {
+		t.Error("the write-off's expense is still there after reversing it")
+	}
+
+	response, err = fs.NewFundResponse(ctx, fund, day(2026, 4, 1))
+	if err != nil {
+		t.Fatalf("NewFundResponse: %v", err)
+	}
+	if response.Outstanding != 70 || response.WrittenOff != 0 || response.Status != models.FundStatusOpen {
+		t.Errorf("after reversing: %.2f outstanding, %.2f written off, %s; want 70, 0, OPEN",
+			response.Outstanding, response.WrittenOff, response.Status)
+	}
+
+	if _, err := fs.ReverseWriteOff(ctx, userID, fund.ID.Hex(), writeOff.ID.Hex()); err == nil {
+		t.Error("reversing a write-off twice succeeded")
+	}
+}
+
+func TestWriteOffRecordsExpensesForGivenFundsOnly(t *testing.T) {
+	db := testDatabase(t)
+	fs := NewFundService(db, NewContactService(db), NewBudgetService(db, nil), nil)
+	userID := primitive.NewObjectID().Hex()
+	ctx := context.Background()
+
+	fund, err := fs.CreateFund(ctx, userID, models.FundRequest{
+		PersonName:      "Sara",
+		Type:            models.FundTypeBorrowed,
+		PrincipalAmount: 100,
+		StartDate:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("CreateFund: %v", err)
+	}
+
+	if _, err := fs.WriteOff(ctx, userID, fund.ID.Hex(), models.WriteOffRequest{Reason: "Forgiven", RecordExpense: true}); err == nil {
+		t.Error("WriteOff recorded an expense for a BORROWED fund")
+	}
+	if _, err := fs.WriteOff(ctx, userID, fund.ID.Hex(), models.WriteOffRequest{Reason: "Forgiven"}); err != nil {
+		t.Errorf("WriteOff of a BORROWED fund: %v", err)
+	}
+}
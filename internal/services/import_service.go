@@ -0,0 +1,582 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/importers"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxImportFileSize is the largest statement file that can be uploaded
+const MaxImportFileSize = 4 * 1024 * 1024
+
+type ImportService struct {
+	collection     *mongo.Collection
+	budgetService  *BudgetService
+	ruleService    *RuleService
+	accountService *AccountService
+}
+
+func NewImportService(db *mongo.Database, budgetService *BudgetService, ruleService *RuleService, accountService *AccountService) *ImportService {
+	collection := db.Collection("imports")
+
+	// Create index on userId for imports
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "createdAt", Value: -1},
+		},
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &ImportService{
+		collection:     collection,
+		budgetService:  budgetService,
+		ruleService:    ruleService,
+		accountService: accountService,
+	}
+}
+
+// CreateImport stores an uploaded file so it can be previewed and committed
+func (is *ImportService) CreateImport(ctx context.Context, userID string, format models.ImportFormat, fileName string, content []byte) (*models.Import, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if len(content) > MaxImportFileSize {
+		return nil, fmt.Errorf("file is too large, maximum size is %d bytes", MaxImportFileSize)
+	}
+
+	imp := &models.Import{
+		ID:        primitive.NewObjectID(),
+		UserID:    objID,
+		Format:    format,
+		FileName:  fileName,
+		Content:   content,
+		Status:    models.ImportStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	switch format {
+	case models.ImportFormatCSV:
+		// Assume a header row until a mapping says otherwise
+		headers, sample, err := importers.SampleCSV(content, "", true)
+		if err != nil {
+			return nil, err
+		}
+		imp.Headers = headers
+		imp.SampleRows = sample
+	case models.ImportFormatOFX:
+		if _, err := importers.ParseOFX(content); err != nil {
+			return nil, err
+		}
+	case models.ImportFormatQIF:
+		if _, err := importers.ParseQIF(content, "", ""); err != nil {
+			return nil, err
+		}
+	case models.ImportFormatCAMT053:
+		if _, err := importers.ParseCAMT053(content); err != nil {
+			return nil, err
+		}
+	case models.ImportFormatMT940:
+		if _, err := importers.ParseMT940(content); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported import format")
+	}
+
+	_, err = is.collection.InsertOne(ctx, imp)
+	if err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// GetImports retrieves all imports for a user, newest first
+func (is *ImportService) GetImports(ctx context.Context, userID string) ([]models.Import, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetProjection(bson.M{"content": 0})
+	cursor, err := is.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	imports := []models.Import{}
+	if err := cursor.All(ctx, &imports); err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+// GetImportByID retrieves an import by ID
+func (is *ImportService) GetImportByID(ctx context.Context, userID, importID string) (*models.Import, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	importObjID, err := primitive.ObjectIDFromHex(importID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid import ID")
+	}
+
+	imp := &models.Import{}
+	err = is.collection.FindOne(ctx, bson.M{
+		"_id":    importObjID,
+		"userId": userObjID,
+	}).Decode(imp)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("import not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// PreviewImport parses an import with the given mapping and remembers the mapping
+func (is *ImportService) PreviewImport(ctx context.Context, userID, importID string, mapping *models.ImportMapping) (*models.ImportPreviewResponse, error) {
+	imp, err := is.GetImportByID(ctx, userID, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping == nil {
+		mapping = imp.Mapping
+	}
+
+	rows, err := is.parseImport(ctx, imp, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping != nil && imp.Status == models.ImportStatusPending {
+		_, err = is.collection.UpdateOne(ctx,
+			bson.M{"_id": imp.ID, "status": models.ImportStatusPending},
+			bson.M{"$set": bson.M{"mapping": mapping}},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildImportPreview(imp.ID, rows), nil
+}
+
+// CommitImport turns every valid row of an import into expenses and incomes in the matching monthly budgets
+func (is *ImportService) CommitImport(ctx context.Context, userID, importID string, req models.ImportCommitRequest) (*models.Import, error) {
+	imp, err := is.GetImportByID(ctx, userID, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.Status != models.ImportStatusPending {
+		return nil, fmt.Errorf("import has already been committed or rolled back")
+	}
+
+	mapping := req.Mapping
+	if mapping == nil {
+		mapping = imp.Mapping
+	}
+
+	rows, err := is.parseImport(ctx, imp, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := buildImportPreview(imp.ID, rows)
+	if preview.Invalid > 0 && !req.SkipInvalid {
+		return nil, fmt.Errorf("import has %d invalid rows, fix the mapping or commit with skipInvalid", preview.Invalid)
+	}
+
+	accountID, err := is.accountService.ResolveAccountID(ctx, userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold the rows' external IDs while writing them, then check again which were
+	// imported by another import or sync since the rows were parsed
+	externalIDs := importableExternalIDs(rows)
+	claim, err := is.budgetService.claimExternalIDs(ctx, userID, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer claim.release()
+
+	existing, err := is.budgetService.FindExistingExternalIDs(ctx, userID, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+	skipImportedRows(rows, existing)
+	preview = buildImportPreview(imp.ID, rows)
+
+	batches := importBatches(imp.ID, accountID, rows)
+
+	// Claim the import so concurrent commits can't import it twice
+	err = is.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": imp.ID, "status": models.ImportStatusPending},
+		bson.M{"$set": bson.M{"status": models.ImportStatusCommitting, "mapping": mapping, "accountId": accountID}},
+	).Err()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("import has already been committed or rolled back")
+		}
+		return nil, err
+	}
+
+	if err := is.budgetService.AddEntriesBulk(ctx, userID, batches); err != nil {
+		is.releaseFailedCommit(ctx, userID, imp.ID)
+		return nil, err
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"content": 0})
+	result := &models.Import{}
+	err = is.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": imp.ID},
+		bson.M{"$set": bson.M{
+			"status":        models.ImportStatusCommitted,
+			"importedCount": preview.Valid,
+			"committedAt":   now,
+		}},
+		opts,
+	).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// releaseFailedCommit undoes whatever part of a failed commit's bulk write went
+// through and makes the import pending again. When the entries can't be removed the
+// import is marked committed instead, so that rolling it back can remove them later;
+// committing it again would import them twice.
+func (is *ImportService) releaseFailedCommit(ctx context.Context, userID string, importID primitive.ObjectID) {
+	status := models.ImportStatusPending
+	if err := is.budgetService.DeleteImportedEntries(ctx, userID, importID); err != nil {
+		log.Printf("Import %s failed to remove the entries of a failed commit: %v", importID.Hex(), err)
+		status = models.ImportStatusCommitted
+	}
+
+	_, err := is.collection.UpdateOne(ctx,
+		bson.M{"_id": importID},
+		bson.M{"$set": bson.M{"status": status}},
+	)
+	if err != nil {
+		log.Printf("Import %s failed to be marked %s after a failed commit: %v", importID.Hex(), status, err)
+	}
+}
+
+// RollbackImport removes every expense and income created by a committed import
+func (is *ImportService) RollbackImport(ctx context.Context, userID, importID string) (*models.Import, error) {
+	imp, err := is.GetImportByID(ctx, userID, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.Status != models.ImportStatusCommitted {
+		return nil, fmt.Errorf("only committed imports can be rolled back")
+	}
+
+	reconciled, err := is.budgetService.HasReconciledImportEntries(ctx, userID, imp.ID)
+	if err != nil {
+		return nil, err
+	}
+	if reconciled {
+		return nil, fmt.Errorf("import has reconciled entries and can't be rolled back")
+	}
+
+	if err := is.budgetService.DeleteImportedEntries(ctx, userID, imp.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"content": 0})
+	result := &models.Import{}
+	err = is.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": imp.ID},
+		bson.M{"$set": bson.M{
+			"status":       models.ImportStatusRolledBack,
+			"rolledBackAt": now,
+		}},
+		opts,
+	).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseImport decodes an import's file into preview rows. Rows the user has already
+// imported (matched by the bank's transaction ID) are skipped.
+func (is *ImportService) parseImport(ctx context.Context, imp *models.Import, mapping *models.ImportMapping) ([]models.ImportRow, error) {
+	var transactions []importers.Transaction
+	var err error
+
+	switch imp.Format {
+	case models.ImportFormatCSV:
+		if mapping == nil {
+			return nil, fmt.Errorf("a column mapping is required for CSV imports")
+		}
+		transactions, err = importers.ParseCSV(imp.Content, *mapping)
+	case models.ImportFormatOFX:
+		transactions, err = importers.ParseOFX(imp.Content)
+	case models.ImportFormatQIF:
+		dateFormat, decimalSeparator := "", ""
+		if mapping != nil {
+			dateFormat, decimalSeparator = mapping.DateFormat, mapping.DecimalSeparator
+		}
+		transactions, err = importers.ParseQIF(imp.Content, dateFormat, decimalSeparator)
+	case models.ImportFormatCAMT053:
+		transactions, err = importers.ParseCAMT053(imp.Content)
+	case models.ImportFormatMT940:
+		transactions, err = importers.ParseMT940(imp.Content)
+	default:
+		return nil, fmt.Errorf("unsupported import format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping != nil && mapping.DateBasis != "" && mapping.DateBasis != models.DateBasisBooking && mapping.DateBasis != models.DateBasisValue {
+		return nil, fmt.Errorf("invalid date basis, must be BOOKING or VALUE")
+	}
+	useValueDate := mapping != nil && mapping.DateBasis == models.DateBasisValue
+
+	externalIDs := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx.ExternalID != "" {
+			externalIDs = append(externalIDs, tx.ExternalID)
+		}
+	}
+
+	// Once committed, an import's own entries must not count as duplicates of itself
+	existing := map[string]bool{}
+	if imp.Status == models.ImportStatusPending {
+		existing, err = is.budgetService.FindExistingExternalIDs(ctx, imp.UserID.Hex(), externalIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	seen := map[string]bool{}
+
+	rows := make([]models.ImportRow, 0, len(transactions))
+	for _, tx := range transactions {
+		row := models.ImportRow{
+			Line:        tx.Line,
+			ExternalID:  tx.ExternalID,
+			Description: tx.Description,
+			Merchant:    tx.Payee,
+			Amount:      tx.Amount,
+			Category:    tx.Category,
+			Error:       tx.Error,
+		}
+
+		if !tx.ValueDate.IsZero() {
+			valueDate := tx.ValueDate
+			row.ValueDate = &valueDate
+		}
+
+		if row.Error == "" {
+			date := tx.Date
+			if useValueDate && row.ValueDate != nil {
+				date = *row.ValueDate
+			}
+			row.Date = &date
+			row.Year = date.Year()
+			row.Month = int(date.Month())
+			row.Amount = math.Abs(tx.Amount)
+
+			row.Kind = models.ImportRowExpense
+			if tx.Amount > 0 {
+				row.Kind = models.ImportRowIncome
+			}
+
+			switch {
+			case tx.Amount == 0:
+				row.Error = "amount is zero"
+			case tx.Pending:
+				row.Skipped = true
+				row.Error = "pending transactions are not imported"
+			case existing[tx.ExternalID]:
+				row.Skipped = true
+				row.Error = "transaction has already been imported"
+			case tx.ExternalID != "" && seen[tx.ExternalID]:
+				row.Skipped = true
+				row.Error = "duplicate transaction in file"
+			}
+		}
+		if tx.ExternalID != "" {
+			seen[tx.ExternalID] = true
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := is.categorizeRows(ctx, imp.UserID.Hex(), rows); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// importableExternalIDs returns the external IDs of the rows a commit would write
+func importableExternalIDs(rows []models.ImportRow) []string {
+	externalIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if !row.Skipped && row.Error == "" && row.ExternalID != "" {
+			externalIDs = append(externalIDs, row.ExternalID)
+		}
+	}
+	return externalIDs
+}
+
+// skipImportedRows skips the rows whose external ID has been imported already
+func skipImportedRows(rows []models.ImportRow, existing map[string]bool) {
+	for i := range rows {
+		if !rows[i].Skipped && rows[i].Error == "" && existing[rows[i].ExternalID] {
+			rows[i].Skipped = true
+			rows[i].Error = "transaction has already been imported"
+		}
+	}
+}
+
+// categorizeRows runs the user's categorization rules over the valid expense rows
+// so previews show the same categories, tags and titles a commit will write
+func (is *ImportService) categorizeRows(ctx context.Context, userID string, rows []models.ImportRow) error {
+	ruleSet, err := is.ruleService.LoadRuleSet(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Skipped || row.Error != "" || row.Kind != models.ImportRowExpense {
+			continue
+		}
+
+		expense := models.Expense{
+			Title:    strings.TrimSpace(row.Description),
+			Amount:   row.Amount,
+			Category: row.Category,
+			Merchant: row.Merchant,
+			Tags:     row.Tags,
+		}
+		if ruleSet.Apply(&expense) {
+			row.Description = expense.Title
+			row.Category = expense.Category
+			row.Tags = expense.Tags
+		}
+	}
+
+	return nil
+}
+
+// buildImportPreview counts valid, invalid and skipped rows
+func buildImportPreview(importID primitive.ObjectID, rows []models.ImportRow) *models.ImportPreviewResponse {
+	preview := &models.ImportPreviewResponse{
+		ImportID: importID.Hex(),
+		Total:    len(rows),
+		Rows:     rows,
+	}
+
+	for _, row := range rows {
+		switch {
+		case row.Skipped:
+			preview.Skipped++
+		case row.Error != "":
+			preview.Invalid++
+		default:
+			preview.Valid++
+		}
+	}
+
+	return preview
+}
+
+// importBatches groups the valid rows of an import into expenses and incomes per month
+func importBatches(importID primitive.ObjectID, accountID *primitive.ObjectID, rows []models.ImportRow) []MonthlyEntries {
+	byMonth := map[int]*MonthlyEntries{}
+	for _, row := range rows {
+		if row.Skipped || row.Error != "" {
+			continue
+		}
+
+		key := monthIndex(row.Year, row.Month)
+		batch, ok := byMonth[key]
+		if !ok {
+			batch = &MonthlyEntries{Year: row.Year, Month: row.Month}
+			byMonth[key] = batch
+		}
+
+		id := importID
+		title := strings.TrimSpace(row.Description)
+		if row.Kind == models.ImportRowIncome {
+			batch.Incomes = append(batch.Incomes, models.Income{
+				ID:         primitive.NewObjectID(),
+				Title:      title,
+				Amount:     row.Amount,
+				Date:       row.Date,
+				AccountID:  accountID,
+				ImportID:   &id,
+				ExternalID: row.ExternalID,
+			})
+			continue
+		}
+
+		batch.Expenses = append(batch.Expenses, models.Expense{
+			ID:         primitive.NewObjectID(),
+			Title:      title,
+			Amount:     row.Amount,
+			Category:   row.Category,
+			Tags:       row.Tags,
+			Merchant:   strings.TrimSpace(row.Merchant),
+			Date:       row.Date,
+			AccountID:  accountID,
+			ImportID:   &id,
+			ExternalID: row.ExternalID,
+		})
+	}
+
+	batches := make([]MonthlyEntries, 0, len(byMonth))
+	for _, batch := range byMonth {
+		batches = append(batches, *batch)
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return monthIndex(batches[i].Year, batches[i].Month) < monthIndex(batches[j].Year, batches[j].Month)
+	})
+
+	return batches
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testOFX = `<OFX>
+<BANKACCTFROM><ACCTID>123</BANKACCTFROM>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260115<TRNAMT>-12.50<FITID>A1<NAME>Coffee</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20260120<TRNAMT>2500.00<FITID>A2<NAME>Salary</STMTTRN>
+</OFX>`
+
+func TestCommitImportConcurrentImportsOfTheSameFile(t *testing.T) {
+	db := testDatabase(t)
+	budgetService := NewBudgetService(db, nil)
+	is := NewImportService(db, budgetService, NewRuleService(db, budgetService), NewAccountService(db, budgetService))
+	userID := primitive.NewObjectID().Hex()
+	ctx := context.Background()
+
+	const imports = 5
+	ids := make([]string, 0, imports)
+	for i := 0; i < imports; i++ {
+		imp, err := is.CreateImport(ctx, userID, models.ImportFormatOFX, "statement.ofx", []byte(testOFX))
+		if err != nil {
+			t.Fatalf("CreateImport: %v", err)
+		}
+		ids = append(ids, imp.ID.Hex())
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			// Losing the race either skips the rows or fails with a retryable error
+			is.CommitImport(ctx, userID, id, models.ImportCommitRequest{})
+		}(id)
+	}
+	wg.Wait()
+
+	budget, err := budgetService.GetBudget(ctx, userID, 2026, 1)
+	if err != nil {
+		t.Fatalf("GetBudget: %v", err)
+	}
+	if len(budget.Expenses) != 1 || len(budget.Incomes) != 1 {
+		t.Errorf("got %d expenses and %d incomes, want 1 of each", len(budget.Expenses), len(budget.Incomes))
+	}
+}
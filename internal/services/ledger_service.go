@@ -0,0 +1,579 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/exporters"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Default journal account names, used until a user configures their own
+const (
+	DefaultLedgerCurrency    = "USD"
+	DefaultLedgerAsset       = "Assets:Checking"
+	DefaultLedgerBaseIncome  = "Income:Salary"
+	DefaultLedgerOtherIncome = "Income:Other"
+	DefaultLedgerExpenseRoot = "Expenses"
+	DefaultLedgerReceivables = "Assets:Receivables"
+	DefaultLedgerPayables    = "Liabilities:Payables"
+	DefaultLedgerFundIncome  = "Income:Funds"
+	DefaultLedgerFundExpense = "Expenses:Funds"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,23}$`)
+
+type LedgerService struct {
+	collection    *mongo.Collection
+	budgetService *BudgetService
+	fundService   *FundService
+}
+
+func NewLedgerService(db *mongo.Database, budgetService *BudgetService, fundService *FundService) *LedgerService {
+	collection := db.Collection("ledger_accounts")
+
+	// Create unique index on userId, each user has one set of account names
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &LedgerService{
+		collection:    collection,
+		budgetService: budgetService,
+		fundService:   fundService,
+	}
+}
+
+// GetAccounts returns the user's journal account names, or the defaults if none are saved
+func (ls *LedgerService) GetAccounts(ctx context.Context, userID string) (*models.LedgerAccounts, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	accounts := &models.LedgerAccounts{}
+	err = ls.collection.FindOne(ctx, bson.M{"userId": objID}).Decode(accounts)
+	if err == mongo.ErrNoDocuments {
+		accounts = &models.LedgerAccounts{UserID: objID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	applyLedgerDefaults(accounts)
+	return accounts, nil
+}
+
+// UpdateAccounts saves the user's journal account names. Empty names fall back to the defaults.
+func (ls *LedgerService) UpdateAccounts(ctx context.Context, userID string, req models.LedgerAccounts) (*models.LedgerAccounts, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	applyLedgerDefaults(&req)
+	if err := validateLedgerAccounts(&req); err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	result := &models.LedgerAccounts{}
+	err = ls.collection.FindOneAndUpdate(ctx,
+		bson.M{"userId": objID},
+		bson.M{"$set": bson.M{
+			"currency":       req.Currency,
+			"asset":          req.Asset,
+			"baseIncome":     req.BaseIncome,
+			"otherIncome":    req.OtherIncome,
+			"expenseRoot":    req.ExpenseRoot,
+			"receivables":    req.Receivables,
+			"payables":       req.Payables,
+			"fundIncome":     req.FundIncome,
+			"fundExpense":    req.FundExpense,
+			"categories":     req.Categories,
+			"counterparties": req.Counterparties,
+			"updatedAt":      time.Now(),
+		}},
+		opts,
+	).Decode(result)
+	if mongo.IsDuplicateKeyError(err) {
+		return ls.UpdateAccounts(ctx, userID, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func applyLedgerDefaults(accounts *models.LedgerAccounts) {
+	defaults := []struct {
+		field *string
+		value string
+	}{
+		{&accounts.Currency, DefaultLedgerCurrency},
+		{&accounts.Asset, DefaultLedgerAsset},
+		{&accounts.BaseIncome, DefaultLedgerBaseIncome},
+		{&accounts.OtherIncome, DefaultLedgerOtherIncome},
+		{&accounts.ExpenseRoot, DefaultLedgerExpenseRoot},
+		{&accounts.Receivables, DefaultLedgerReceivables},
+		{&accounts.Payables, DefaultLedgerPayables},
+		{&accounts.FundIncome, DefaultLedgerFundIncome},
+		{&accounts.FundExpense, DefaultLedgerFundExpense},
+	}
+	for _, d := range defaults {
+		if strings.TrimSpace(*d.field) == "" {
+			*d.field = d.value
+		}
+	}
+	if accounts.Categories == nil {
+		accounts.Categories = []models.AccountMapping{}
+	}
+	if accounts.Counterparties == nil {
+		accounts.Counterparties = []models.AccountMapping{}
+	}
+}
+
+func validateLedgerAccounts(accounts *models.LedgerAccounts) error {
+	if !currencyPattern.MatchString(accounts.Currency) {
+		return fmt.Errorf("currency must be an upper-case commodity code such as USD")
+	}
+
+	named := map[string]string{
+		"asset":       accounts.Asset,
+		"baseIncome":  accounts.BaseIncome,
+		"otherIncome": accounts.OtherIncome,
+		"receivables": accounts.Receivables,
+		"payables":    accounts.Payables,
+		"fundIncome":  accounts.FundIncome,
+		"fundExpense": accounts.FundExpense,
+	}
+	for field, account := range named {
+		if !exporters.ValidAccount(account) {
+			return fmt.Errorf("invalid %s account %q", field, account)
+		}
+	}
+	// The expense root may be just "Expenses"
+	if !exporters.ValidAccount(accounts.ExpenseRoot) && accounts.ExpenseRoot != "Expenses" {
+		return fmt.Errorf("invalid expenseRoot account %q", accounts.ExpenseRoot)
+	}
+
+	for _, mappings := range [][]models.AccountMapping{accounts.Categories, accounts.Counterparties} {
+		for _, mapping := range mappings {
+			if strings.TrimSpace(mapping.Name) == "" {
+				return fmt.Errorf("account mappings need a name")
+			}
+			if !exporters.ValidAccount(mapping.Account) {
+				return fmt.Errorf("invalid account %q for %q", mapping.Account, mapping.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ledgerAccountResolver picks the account for categories and counterparties
+type ledgerAccountResolver struct {
+	accounts       *models.LedgerAccounts
+	categories     map[string]string
+	counterparties map[string]string
+	// writeOffExpenses are the counterparty accounts of the funds whose write-offs
+	// recorded an expense, by expense ID
+	writeOffExpenses map[primitive.ObjectID]string
+}
+
+func newLedgerAccountResolver(accounts *models.LedgerAccounts) *ledgerAccountResolver {
+	r := &ledgerAccountResolver{
+		accounts:         accounts,
+		categories:       map[string]string{},
+		counterparties:   map[string]string{},
+		writeOffExpenses: map[primitive.ObjectID]string{},
+	}
+	for _, m := range accounts.Categories {
+		r.categories[strings.ToLower(strings.TrimSpace(m.Name))] = m.Account
+	}
+	for _, m := range accounts.Counterparties {
+		r.counterparties[strings.ToLower(strings.TrimSpace(m.Name))] = m.Account
+	}
+	return r
+}
+
+func (r *ledgerAccountResolver) category(category string) string {
+	if category == "" {
+		category = UncategorizedCategory
+	}
+	if account, ok := r.categories[strings.ToLower(strings.TrimSpace(category))]; ok {
+		return account
+	}
+	return r.accounts.ExpenseRoot + ":" + exporters.AccountComponent(category)
+}
+
+// counterparty is the account tracking what a person owes or is owed.
+// A configured account is used for both directions so it shows the net position.
+func (r *ledgerAccountResolver) counterparty(person string, fundType models.FundType) string {
+	if account, ok := r.counterparties[strings.ToLower(strings.TrimSpace(person))]; ok {
+		return account
+	}
+	root := r.accounts.Receivables
+	if fundType == models.FundTypeBorrowed {
+		root = r.accounts.Payables
+	}
+	return root + ":" + exporters.AccountComponent(person)
+}
+
+// expenseSource is the account an expense is paid from: the asset account, or the
+// counterparty account for the expense of a write-off, which moves no money
+func (r *ledgerAccountResolver) expenseSource(expenseID primitive.ObjectID) string {
+	if account, ok := r.writeOffExpenses[expenseID]; ok {
+		return account
+	}
+	return r.accounts.Asset
+}
+
+// writeOff is the account written-off amounts go to: bad debt, under the category of
+// write-off expenses, for GIVEN funds and income for forgiven BORROWED funds
+func (r *ledgerAccountResolver) writeOff(fundType models.FundType) string {
+	if fundType == models.FundTypeBorrowed {
+		return r.accounts.FundIncome
+	}
+	return r.category(DefaultWriteOffCategory)
+}
+
+// JournalExtension returns the file extension of a journal format
+func JournalExtension(format models.JournalFormat) (string, error) {
+	switch format {
+	case models.JournalFormatLedger:
+		return "ledger", nil
+	case models.JournalFormatHledger:
+		return "journal", nil
+	case models.JournalFormatBeancount:
+		return "beancount", nil
+	default:
+		return "", fmt.Errorf("unsupported journal format, must be LEDGER, HLEDGER or BEANCOUNT")
+	}
+}
+
+// ExportJournal validates a journal export and returns a function that streams it.
+// With allTime set the whole history is exported; otherwise only the month range,
+// in which case fund balances only cover fund activity inside the range.
+func (ls *LedgerService) ExportJournal(ctx context.Context, userID string, format models.JournalFormat, allTime bool, fromYear, fromMonth, toYear, toMonth int) (func(w io.Writer) error, error) {
+	if _, err := JournalExtension(format); err != nil {
+		return nil, err
+	}
+	if !allTime && monthIndex(fromYear, fromMonth) > monthIndex(toYear, toMonth) {
+		return nil, fmt.Errorf("start month must not be after end month")
+	}
+
+	accounts, err := ls.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w io.Writer) error {
+		resolver := newLedgerAccountResolver(accounts)
+
+		var from, to time.Time
+		title := "Finance tracker export, all time"
+		if !allTime {
+			from = time.Date(fromYear, time.Month(fromMonth), 1, 0, 0, 0, 0, time.UTC)
+			to = time.Date(toYear, time.Month(toMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+			title = fmt.Sprintf("Finance tracker export, %04d-%02d to %04d-%02d", fromYear, fromMonth, toYear, toMonth)
+		}
+
+		fundTransactions, err := ls.fundJournalTransactions(ctx, userID, resolver, from, to)
+		if err != nil {
+			return err
+		}
+
+		journal := exporters.NewJournal(w, format, accounts.Currency, title)
+
+		// Fund activity is merged into the month stream so the journal stays in date order
+		writeFundsBefore := func(before time.Time) error {
+			for len(fundTransactions) > 0 && (before.IsZero() || fundTransactions[0].Date.Before(before)) {
+				if err := journal.Write(fundTransactions[0]); err != nil {
+					return err
+				}
+				fundTransactions = fundTransactions[1:]
+			}
+			return nil
+		}
+
+		writeBudget := func(budget *models.MonthlyBudget) error {
+			if err := writeFundsBefore(time.Date(budget.Year, time.Month(budget.Month), 1, 0, 0, 0, 0, time.UTC)); err != nil {
+				return err
+			}
+			for _, tx := range budgetJournalTransactions(budget, resolver) {
+				if err := journal.Write(tx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if allTime {
+			err = ls.budgetService.IterateBudgets(ctx, userID, writeBudget)
+		} else {
+			err = ls.budgetService.IterateBudgetRange(ctx, userID, fromYear, fromMonth, toYear, toMonth, writeBudget)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := writeFundsBefore(time.Time{}); err != nil {
+			return err
+		}
+
+		return journal.Close()
+	}, nil
+}
+
+// budgetJournalTransactions turns a month into journal transactions in date order
+func budgetJournalTransactions(budget *models.MonthlyBudget, resolver *ledgerAccountResolver) []exporters.JournalTransaction {
+	accounts := resolver.accounts
+	var transactions []exporters.JournalTransaction
+
+	if budget.BaseIncome != nil && *budget.BaseIncome != 0 {
+		amount := *budget.BaseIncome
+		transactions = append(transactions, exporters.JournalTransaction{
+			Date:      time.Date(budget.Year, time.Month(budget.Month), 1, 0, 0, 0, 0, time.UTC),
+			Narration: "Base income",
+			ID:        budget.ID.Hex(),
+			Postings: []exporters.JournalPosting{
+				{Account: accounts.Asset, Amount: &amount},
+				{Account: accounts.BaseIncome},
+			},
+		})
+	}
+
+	for _, income := range budget.Incomes {
+		amount := income.Amount
+		transactions = append(transactions, exporters.JournalTransaction{
+			Date:      entryDate(budget.Year, budget.Month, income.Date, income.CreatedAt),
+			Narration: income.Title,
+			ID:        income.ID.Hex(),
+			Postings: []exporters.JournalPosting{
+				{Account: accounts.Asset, Amount: &amount},
+				{Account: accounts.OtherIncome},
+			},
+		})
+	}
+
+	for _, expense := range budget.Expenses {
+		amount := expense.Amount
+		transactions = append(transactions, exporters.JournalTransaction{
+			Date:      entryDate(budget.Year, budget.Month, expense.Date, expense.CreatedAt),
+			Payee:     expense.Merchant,
+			Narration: expense.Title,
+			Tags:      expense.Tags,
+			ID:        expense.ID.Hex(),
+			Postings: []exporters.JournalPosting{
+				{Account: resolver.category(expense.Category), Amount: &amount},
+				{Account: resolver.expenseSource(expense.ID)},
+			},
+		})
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+	return transactions
+}
+
+// fundJournalTransactions turns the user's funds into journal transactions with
+// fundJournal, each fund's charges worked out up to the day its balance is shown for.
+// Write-offs that recorded an expense are posted as that expense, against the
+// counterparty account, so they are registered with the resolver while the expense
+// exists. A zero from/to leaves that side unbounded.
+func (ls *LedgerService) fundJournalTransactions(ctx context.Context, userID string, resolver *ledgerAccountResolver, from, to time.Time) ([]exporters.JournalTransaction, error) {
+	funds, err := ls.fundService.GetAllFunds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []exporters.JournalTransaction
+	for i := range funds {
+		fund := &funds[i]
+		payments, err := ls.fundService.GetTransactionsByFundID(ctx, fund.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, payment := range payments {
+			if payment.Type != models.TransactionWriteOff || payment.ExpenseID == nil || payment.ReversedAt != nil {
+				continue
+			}
+			_, _, err := ls.budgetService.FindExpense(ctx, userID, payment.ExpenseID.Hex())
+			if err == nil {
+				resolver.writeOffExpenses[*payment.ExpenseID] = resolver.counterparty(fund.PersonName, fund.Type)
+			} else if err.Error() != "expense not found or doesn't belong to user" {
+				return nil, err
+			}
+		}
+		transactions = append(transactions, fundJournal(fund, payments, resolver, from, to, defaultAsOf(payments))...)
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+	return transactions, nil
+}
+
+// fundJournal turns a fund into journal transactions: lending or borrowing the
+// principal and any further disbursements, each repayment, and adjustments and the
+// interest and fees charged, against the fund income account for GIVEN funds and the
+// fund expense account for BORROWED ones. Write-offs go to the write-off account and
+// are put back on the day they were reversed; one whose expense the resolver knows
+// is posted as that expense instead. Charges are worked out with the fund's
+// ledger and posted on the days anything happens on the fund, the days late fees
+// are checked and the last day of the range, up to asOf, so the counterparty account
+// balances to what the fund reports as outstanding as of that day.
+func fundJournal(fund *models.Fund, transactions []models.Transaction, resolver *ledgerAccountResolver, from, to, asOf time.Time) []exporters.JournalTransaction {
+	inRange := func(date time.Time) bool {
+		return (from.IsZero() || !date.Before(from)) && (to.IsZero() || date.Before(to))
+	}
+	asOf = truncateDay(asOf)
+	asset := resolver.accounts.Asset
+	counterparty := resolver.counterparty(fund.PersonName, fund.Type)
+
+	// Amounts are what the fund owes the user: money moves out of the asset account
+	// when lending and into it when borrowing, and charges are income when lending
+	// and an expense when borrowing
+	sign := 1.0
+	charges := resolver.accounts.FundIncome
+	lent := "Lent to " + fund.PersonName
+	if fund.Type == models.FundTypeBorrowed {
+		sign = -1
+		charges = resolver.accounts.FundExpense
+		lent = "Borrowed from " + fund.PersonName
+	}
+	posting := func(date time.Time, narration, id string, amount float64, other string) exporters.JournalTransaction {
+		amount = roundCents(sign * amount)
+		return exporters.JournalTransaction{
+			Date:      date,
+			Payee:     fund.PersonName,
+			Narration: narration,
+			ID:        id,
+			Postings: []exporters.JournalPosting{
+				{Account: counterparty, Amount: &amount},
+				{Account: other},
+			},
+		}
+	}
+
+	writeOffJournal := func(writeOff models.Transaction) []exporters.JournalTransaction {
+		if writeOff.ReversedAt == nil && writeOff.ExpenseID != nil {
+			if _, ok := resolver.writeOffExpenses[*writeOff.ExpenseID]; ok {
+				return nil
+			}
+		}
+
+		narration := "Write-off"
+		if writeOff.Reason != "" {
+			narration = "Write-off: " + writeOff.Reason
+		}
+		var journal []exporters.JournalTransaction
+		if date := truncateDay(writeOff.Date); inRange(date) {
+			journal = append(journal, posting(date, narration, writeOff.ID.Hex(), -writeOff.Amount, resolver.writeOff(fund.Type)))
+		}
+		if writeOff.ReversedAt != nil {
+			if date := truncateDay(*writeOff.ReversedAt); inRange(date) {
+				journal = append(journal, posting(date, narration+" (reversed)", writeOff.ID.Hex(), writeOff.Amount, resolver.writeOff(fund.Type)))
+			}
+		}
+		return journal
+	}
+
+	var journal []exporters.JournalTransaction
+	if start := truncateDay(fund.StartDate); inRange(start) {
+		journal = append(journal, posting(start, lent, fund.ID.Hex(), fund.PrincipalAmount, asset))
+	}
+
+	sorted := make([]models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	// Charges are posted on each of these days before what happened on the day.
+	// The days before the range and its last day collect the charges outside it.
+	var days []time.Time
+	for _, transaction := range sorted {
+		days = append(days, truncateDay(transaction.Date))
+	}
+	for _, check := range lateFeeChecks(fund, asOf) {
+		days = append(days, check.date)
+	}
+	if !from.IsZero() {
+		days = append(days, from.AddDate(0, 0, -1))
+	}
+	if !to.IsZero() {
+		days = append(days, to.AddDate(0, 0, -1))
+	}
+	days = append(days, asOf)
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+
+	var interestPosted, feesPosted float64
+	next := 0
+	for i, date := range days {
+		if date.After(asOf) {
+			break
+		}
+		if i > 0 && date.Equal(days[i-1]) {
+			continue
+		}
+
+		interest, fees := computeFundLedger(fund, transactions, date).charged()
+		interest, fees = roundCents(interest), roundCents(fees)
+		if inRange(date) {
+			if amount := roundCents(interest - interestPosted); amount != 0 {
+				journal = append(journal, posting(date, "Interest", fund.ID.Hex(), amount, charges))
+			}
+			if amount := roundCents(fees - feesPosted); amount != 0 {
+				journal = append(journal, posting(date, "Fees", fund.ID.Hex(), amount, charges))
+			}
+		}
+		interestPosted, feesPosted = interest, fees
+
+		for ; next < len(sorted) && !truncateDay(sorted[next].Date).After(date); next++ {
+			transaction := sorted[next]
+			if transactionType(transaction) == models.TransactionWriteOff {
+				journal = append(journal, writeOffJournal(transaction)...)
+				continue
+			}
+			if !inRange(truncateDay(transaction.Date)) {
+				continue
+			}
+
+			var amount float64
+			var narration, other string
+			switch transactionType(transaction) {
+			case models.TransactionRepayment:
+				amount, narration, other = -transaction.Amount, "Repayment", asset
+			case models.TransactionDisbursement:
+				amount, narration, other = transaction.Amount, lent, asset
+			case models.TransactionAdjustment:
+				amount, narration, other = transaction.Amount, "Adjustment", charges
+			default:
+				// Fees and interest are posted with the charges
+				continue
+			}
+			if transaction.Note != "" {
+				narration = transaction.Note
+			}
+			journal = append(journal, posting(truncateDay(transaction.Date), narration, transaction.ID.Hex(), amount, other))
+		}
+	}
+
+	return journal
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/exporters"
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// journalBalances sums the postings of journal transactions per account, giving the
+// posting without an amount what balances its transaction
+func journalBalances(transactions []exporters.JournalTransaction) map[string]float64 {
+	balances := map[string]float64{}
+	for _, tx := range transactions {
+		total := 0.0
+		implicit := ""
+		for _, posting := range tx.Postings {
+			if posting.Amount == nil {
+				implicit = posting.Account
+				continue
+			}
+			balances[posting.Account] += *posting.Amount
+			total += *posting.Amount
+		}
+		if implicit != "" {
+			balances[implicit] -= total
+		}
+	}
+	for account, balance := range balances {
+		balances[account] = roundCents(balance)
+	}
+	return balances
+}
+
+func testLedgerResolver() *ledgerAccountResolver {
+	accounts := &models.LedgerAccounts{}
+	applyLedgerDefaults(accounts)
+	return newLedgerAccountResolver(accounts)
+}
+
+func TestFundJournalBalancesToOutstanding(t *testing.T) {
+	transaction := func(kind models.TransactionType, amount float64, date time.Time) models.Transaction {
+		return models.Transaction{ID: primitive.NewObjectID(), Type: kind, Amount: amount, Date: date}
+	}
+	dueDate := day(2026, 3, 31)
+	reversed := time.Date(2026, 7, 15, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		fund         models.Fund
+		transactions []models.Transaction
+		asOf         time.Time
+	}{
+		{
+			name: "repaid with interest",
+			fund: models.Fund{PersonName: "Ali", Type: models.FundTypeGiven, PrincipalAmount: 100, StartDate: day(2026, 1, 1),
+				InterestType: models.InterestTypeSimple, InterestRate: 10, DayCount: models.DayCountActual365},
+			transactions: []models.Transaction{transaction(models.TransactionRepayment, 110, day(2027, 1, 1))},
+			asOf:         day(2027, 6, 1),
+		},
+		{
+			name: "lent with interest still accruing",
+			fund: models.Fund{PersonName: "Ali", Type: models.FundTypeGiven, PrincipalAmount: 1000, StartDate: day(2026, 1, 1),
+				InterestType: models.InterestTypeCompound, InterestRate: 12, CompoundingPeriod: models.CompoundingMonthly, DayCount: models.DayCountActual365},
+			transactions: []models.Transaction{
+				transaction(models.TransactionRepayment, 250, day(2026, 3, 15)),
+				transaction(models.TransactionDisbursement, 500, day(2026, 4, 1)),
+				transaction(models.TransactionInterest, 7.5, day(2026, 5, 1)),
+			},
+			asOf: day(2026, 9, 17),
+		},
+		{
+			name: "borrowed with fees, late fees and adjustments",
+			fund: models.Fund{PersonName: "Sara", Type: models.FundTypeBorrowed, PrincipalAmount: 800, StartDate: day(2026, 1, 10),
+				InterestType: models.InterestTypeSimple, InterestRate: 5, DayCount: models.DayCountActual360, DueDate: &dueDate,
+				LateFee: &models.LateFeeRule{Flat: 15, Percent: 1, GraceDays: 5, Frequency: models.LateFeeMonthly}},
+			transactions: []models.Transaction{
+				transaction(models.TransactionFee, 12.34, day(2026, 2, 1)),
+				transaction(models.TransactionAdjustment, -50, day(2026, 2, 20)),
+				transaction(models.TransactionRepayment, 300, day(2026, 4, 20)),
+				transaction(models.TransactionAdjustment, 25, day(2026, 5, 5)),
+			},
+			asOf: day(2026, 8, 1),
+		},
+		{
+			name: "written off after a repayment",
+			fund: models.Fund{PersonName: "Omar", Type: models.FundTypeGiven, PrincipalAmount: 500, StartDate: day(2026, 1, 1),
+				InterestType: models.InterestTypeSimple, InterestRate: 8, DayCount: models.DayCountActual365},
+			transactions: []models.Transaction{
+				transaction(models.TransactionRepayment, 200, day(2026, 3, 1)),
+				{ID: primitive.NewObjectID(), Type: models.TransactionWriteOff, Amount: 150, Date: day(2026, 6, 1), Reason: "Moved away"},
+			},
+			asOf: day(2026, 10, 1),
+		},
+		{
+			name: "write-off reversed",
+			fund: models.Fund{PersonName: "Omar", Type: models.FundTypeGiven, PrincipalAmount: 500, StartDate: day(2026, 1, 1),
+				InterestType: models.InterestTypeSimple, InterestRate: 8, DayCount: models.DayCountActual365},
+			transactions: []models.Transaction{
+				{ID: primitive.NewObjectID(), Type: models.TransactionWriteOff, Amount: 300, Date: day(2026, 2, 1), ReversedAt: &reversed},
+			},
+			asOf: day(2026, 10, 1),
+		},
+		{
+			name: "borrowed and forgiven",
+			fund: models.Fund{PersonName: "Sara", Type: models.FundTypeBorrowed, PrincipalAmount: 400, StartDate: day(2026, 1, 1)},
+			transactions: []models.Transaction{
+				transaction(models.TransactionRepayment, 100, day(2026, 2, 1)),
+				transaction(models.TransactionWriteOff, 300, day(2026, 3, 1)),
+			},
+			asOf: day(2026, 4, 1),
+		},
+	}
+
+	resolver := testLedgerResolver()
+	for _, tt := range tests {
+		counterparty := resolver.counterparty(tt.fund.PersonName, tt.fund.Type)
+		want := fundResponse(&tt.fund, tt.transactions, tt.asOf).Outstanding
+		if tt.fund.Type == models.FundTypeBorrowed {
+			want = -want
+		}
+
+		journal := fundJournal(&tt.fund, tt.transactions, resolver, time.Time{}, time.Time{}, tt.asOf)
+		if got := journalBalances(journal)[counterparty]; got != want {
+			t.Errorf("%s: %s balance = %.2f, want the outstanding %.2f", tt.name, counterparty, got, want)
+		}
+
+		// Exporting month by month posts the same as exporting everything at once
+		var months []exporters.JournalTransaction
+		for from := day(2026, 1, 1); from.Before(day(2028, 1, 1)); from = from.AddDate(0, 1, 0) {
+			months = append(months, fundJournal(&tt.fund, tt.transactions, resolver, from, from.AddDate(0, 1, 0), tt.asOf)...)
+		}
+		all, monthly := journalBalances(journal), journalBalances(months)
+		for account, balance := range all {
+			if monthly[account] != balance {
+				t.Errorf("%s: %s balance = %.2f month by month, want %.2f", tt.name, account, monthly[account], balance)
+			}
+		}
+	}
+}
+
+func TestFundJournalPostsChargesToFundAccounts(t *testing.T) {
+	fund := models.Fund{PersonName: "Ali", Type: models.FundTypeGiven, PrincipalAmount: 100, StartDate: day(2026, 1, 1),
+		InterestType: models.InterestTypeSimple, InterestRate: 10, DayCount: models.DayCountActual365}
+	transactions := []models.Transaction{
+		{ID: primitive.NewObjectID(), Type: models.TransactionFee, Amount: 5, Date: day(2026, 6, 1)},
+		{ID: primitive.NewObjectID(), Type: models.TransactionRepayment, Amount: 115, Date: day(2027, 1, 1), Note: "Paid in cash"},
+	}
+
+	resolver := testLedgerResolver()
+	balances := journalBalances(fundJournal(&fund, transactions, resolver, time.Time{}, time.Time{}, day(2027, 1, 1)))
+	want := map[string]float64{
+		"Assets:Receivables:Ali": 0,
+		DefaultLedgerAsset:       15,
+		DefaultLedgerFundIncome:  -15,
+	}
+	for account, balance := range want {
+		if balances[account] != balance {
+			t.Errorf("%s balance = %.2f, want %.2f", account, balances[account], balance)
+		}
+	}
+
+	fund.Type = models.FundTypeBorrowed
+	balances = journalBalances(fundJournal(&fund, transactions, resolver, time.Time{}, time.Time{}, day(2027, 1, 1)))
+	if balances[DefaultLedgerFundExpense] != 15 || balances[DefaultLedgerAsset] != -15 {
+		t.Errorf("borrowed fund charges = %.2f and asset = %.2f, want 15 and -15",
+			balances[DefaultLedgerFundExpense], balances[DefaultLedgerAsset])
+	}
+}
+
+func TestFundJournalWriteOffs(t *testing.T) {
+	expenseID := primitive.NewObjectID()
+	reversed := time.Date(2026, 5, 2, 18, 0, 0, 0, time.UTC)
+	fund := models.Fund{ID: primitive.NewObjectID(), PersonName: "Ali", Type: models.FundTypeGiven, PrincipalAmount: 100, StartDate: day(2026, 1, 1)}
+	writeOff := models.Transaction{ID: primitive.NewObjectID(), Type: models.TransactionWriteOff, Amount: 100, Date: day(2026, 3, 1), ExpenseID: &expenseID}
+	budget := &models.MonthlyBudget{Year: 2026, Month: 3, Expenses: []models.Expense{
+		{ID: expenseID, Title: "Write-off: Ali", Amount: 100, Category: "Bad Debt", Date: &writeOff.Date},
+	}}
+	counterparty := "Assets:Receivables:Ali"
+	badDebt := "Expenses:Bad-Debt"
+
+	tests := []struct {
+		name     string
+		writeOff models.Transaction
+		recorded bool
+		want     map[string]float64
+	}{
+		{
+			name:     "posted as its recorded expense",
+			writeOff: writeOff,
+			recorded: true,
+			want:     map[string]float64{counterparty: 0, DefaultLedgerAsset: -100, badDebt: 100},
+		},
+		{
+			name:     "posted itself once its expense is deleted",
+			writeOff: writeOff,
+			want:     map[string]float64{counterparty: 0, DefaultLedgerAsset: -100, badDebt: 100},
+		},
+		{
+			name: "reversed",
+			writeOff: models.Transaction{ID: writeOff.ID, Type: models.TransactionWriteOff, Amount: 100, Date: day(2026, 3, 1),
+				ExpenseID: &expenseID, ReversedAt: &reversed},
+			want: map[string]float64{counterparty: 100, DefaultLedgerAsset: -100, badDebt: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		resolver := testLedgerResolver()
+		var journal []exporters.JournalTransaction
+		if tt.recorded {
+			resolver.writeOffExpenses[expenseID] = counterparty
+			journal = append(journal, budgetJournalTransactions(budget, resolver)...)
+		}
+		journal = append(journal, fundJournal(&fund, []models.Transaction{tt.writeOff}, resolver, time.Time{}, time.Time{}, day(2026, 6, 1))...)
+
+		balances := journalBalances(journal)
+		for account, balance := range tt.want {
+			if balances[account] != balance {
+				t.Errorf("%s: %s balance = %.2f, want %.2f", tt.name, account, balances[account], balance)
+			}
+		}
+	}
+
+	// The reversal is posted on the day it happened, which may be in another range
+	march := fundJournal(&fund, []models.Transaction{tests[2].writeOff}, testLedgerResolver(), day(2026, 3, 1), day(2026, 4, 1), day(2026, 6, 1))
+	if balances := journalBalances(march); balances[counterparty] != -100 || balances[badDebt] != 100 {
+		t.Errorf("March: %s = %.2f and %s = %.2f, want -100 and 100", counterparty, balances[counterparty], badDebt, balances[badDebt])
+	}
+}
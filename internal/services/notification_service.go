@@ -0,0 +1,554 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/notifiers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultInboxLimit is how many notifications the inbox lists by default
+	DefaultInboxLimit = 50
+	// MaxInboxLimit caps how many notifications the inbox lists
+	MaxInboxLimit = 200
+	// MaxDeliveryAttempts is how often an email or webhook delivery is tried before it fails
+	MaxDeliveryAttempts = 6
+
+	deliveryBatchSize = 100
+	deliveryLease     = 5 * time.Minute
+	maxRetryDelay     = time.Hour
+)
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, userID string, event models.NotificationEvent) error
+}
+
+// defaultNotificationChannels are used for types the user hasn't configured
+var defaultNotificationChannels = []models.NotificationChannel{
+	models.NotificationChannelInApp,
+	models.NotificationChannelEmail,
+}
+
+type NotificationService struct {
+	notificationCollection *mongo.Collection
+	preferenceCollection   *mongo.Collection
+	deliveryCollection     *mongo.Collection
+	userService            *UserService
+	senders                map[models.NotificationChannel]notifiers.Sender
+	wake                   chan struct{}
+}
+
+// NewNotificationService creates the notification service. senders holds the email and
+// webhook senders; a channel without a sender is not delivered on.
+func NewNotificationService(db *mongo.Database, userService *UserService, senders map[models.NotificationChannel]notifiers.Sender) *NotificationService {
+	notificationCollection := db.Collection("notifications")
+	preferenceCollection := db.Collection("notification_preferences")
+	deliveryCollection := db.Collection("notification_deliveries")
+
+	// Create indexes for the inbox and for deduplicating events
+	notificationIndexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "inbox", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "dedupKey", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"dedupKey": bson.M{"$exists": true}}),
+		},
+	}
+	notificationCollection.Indexes().CreateMany(context.Background(), notificationIndexModels)
+
+	// Create unique index on userId for preferences
+	preferenceIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	preferenceCollection.Indexes().CreateOne(context.Background(), preferenceIndexModel)
+
+	// Create index for picking up due deliveries
+	deliveryIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+	}
+	deliveryCollection.Indexes().CreateOne(context.Background(), deliveryIndexModel)
+
+	return &NotificationService{
+		notificationCollection: notificationCollection,
+		preferenceCollection:   preferenceCollection,
+		deliveryCollection:     deliveryCollection,
+		userService:            userService,
+		senders:                senders,
+		wake:                   make(chan struct{}, 1),
+	}
+}
+
+// Wake is signalled whenever deliveries are queued, so the delivery worker doesn't
+// have to wait for its next tick
+func (ns *NotificationService) Wake() <-chan struct{} {
+	return ns.wake
+}
+
+// Notify renders an event, stores it for the inbox and queues its email and webhook
+// deliveries according to the user's preferences. Events whose dedup key was already
+// notified are ignored. On error nothing is kept, so the event can be notified again.
+func (ns *NotificationService) Notify(ctx context.Context, userID string, event models.NotificationEvent) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID")
+	}
+
+	title, body, err := notifiers.Render(event.Type, event.Data)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := ns.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	channels := prefs.Channels[event.Type]
+
+	notification := &models.Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    objID,
+		Type:      event.Type,
+		Title:     title,
+		Body:      body,
+		Data:      event.Data,
+		DedupKey:  event.DedupKey,
+		Inbox:     hasChannel(channels, models.NotificationChannelInApp),
+		CreatedAt: time.Now(),
+	}
+	if _, err := ns.notificationCollection.InsertOne(ctx, notification); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+
+	// The notification holds the dedup key, so it is removed again when its
+	// deliveries can't be queued; otherwise the caller's retry would be ignored as
+	// a duplicate and the email or webhook never sent
+	deliveries, err := ns.deliveries(ctx, prefs, notification, channels)
+	if err == nil && len(deliveries) > 0 {
+		_, err = ns.deliveryCollection.InsertMany(ctx, deliveries)
+	}
+	if err != nil {
+		ns.deliveryCollection.DeleteMany(ctx, bson.M{"notificationId": notification.ID})
+		ns.notificationCollection.DeleteOne(ctx, bson.M{"_id": notification.ID})
+		return err
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	select {
+	case ns.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// deliveries builds the queued email and webhook deliveries of a notification
+func (ns *NotificationService) deliveries(ctx context.Context, prefs *models.NotificationPreferences, notification *models.Notification, channels []models.NotificationChannel) ([]interface{}, error) {
+	now := time.Now()
+	var deliveries []interface{}
+	for _, channel := range channels {
+		delivery := models.NotificationDelivery{
+			ID:             primitive.NewObjectID(),
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        channel,
+			Subject:        notification.Title,
+			Body:           notification.Body,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+
+		switch channel {
+		case models.NotificationChannelEmail:
+			if ns.senders[channel] == nil {
+				continue
+			}
+			delivery.Target = prefs.Email
+			if delivery.Target == "" {
+				user, err := ns.userService.GetUserByID(ctx, notification.UserID.Hex())
+				if err != nil {
+					return nil, err
+				}
+				delivery.Target = user.Email
+			}
+		case models.NotificationChannelWebhook:
+			if ns.senders[channel] == nil || prefs.WebhookURL == "" {
+				continue
+			}
+			payload, err := json.Marshal(webhookPayload(notification))
+			if err != nil {
+				return nil, err
+			}
+			delivery.Target = prefs.WebhookURL
+			delivery.Secret = prefs.WebhookSecret
+			delivery.Payload = payload
+		default:
+			continue
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// webhookPayload is the JSON body posted to webhooks
+func webhookPayload(notification *models.Notification) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        notification.ID.Hex(),
+		"type":      notification.Type,
+		"title":     notification.Title,
+		"body":      notification.Body,
+		"data":      notification.Data,
+		"createdAt": notification.CreatedAt,
+	}
+}
+
+// DeliverPending sends the queued deliveries that are due. Each delivery is claimed
+// before sending, so several workers don't send it twice; failures are retried with
+// exponential backoff until MaxDeliveryAttempts.
+func (ns *NotificationService) DeliverPending(ctx context.Context) error {
+	for i := 0; i < deliveryBatchSize; i++ {
+		now := time.Now()
+		delivery := &models.NotificationDelivery{}
+		err := ns.deliveryCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"status":        models.DeliveryPending,
+				"nextAttemptAt": bson.M{"$lte": now},
+			},
+			bson.M{
+				"$set": bson.M{"nextAttemptAt": now.Add(deliveryLease)},
+				"$inc": bson.M{"attempts": 1},
+			},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(delivery)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil
+			}
+			return err
+		}
+
+		sendErr := fmt.Errorf("channel %s is not configured", delivery.Channel)
+		if sender := ns.senders[delivery.Channel]; sender != nil {
+			sendErr = sender.Send(ctx, notifiers.Message{
+				To:      delivery.Target,
+				Subject: delivery.Subject,
+				Body:    delivery.Body,
+				Payload: delivery.Payload,
+				Secret:  delivery.Secret,
+			})
+		}
+
+		set := bson.M{}
+		switch {
+		case sendErr == nil:
+			set["status"] = models.DeliverySent
+			set["sentAt"] = time.Now()
+		case delivery.Attempts >= MaxDeliveryAttempts:
+			set["status"] = models.DeliveryFailed
+			set["lastError"] = sendErr.Error()
+			log.Printf("Notification delivery %s failed for good: %v", delivery.ID.Hex(), sendErr)
+		default:
+			set["nextAttemptAt"] = time.Now().Add(retryDelay(delivery.Attempts))
+			set["lastError"] = sendErr.Error()
+		}
+
+		if _, err := ns.deliveryCollection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retryDelay doubles the wait after every failed attempt: 1m, 2m, 4m... up to an hour
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// GetInbox lists the user's in-app notifications, newest first
+func (ns *NotificationService) GetInbox(ctx context.Context, userID string, unreadOnly bool, limit int) (*models.NotificationInboxResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	if limit <= 0 || limit > MaxInboxLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxInboxLimit)
+	}
+
+	filter := bson.M{"userId": objID, "inbox": true}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := ns.notificationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	response := &models.NotificationInboxResponse{Notifications: []models.Notification{}}
+	if err := cursor.All(ctx, &response.Notifications); err != nil {
+		return nil, err
+	}
+
+	response.Unread, err = ns.notificationCollection.CountDocuments(ctx, bson.M{
+		"userId": objID,
+		"inbox":  true,
+		"read":   false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// MarkRead marks one in-app notification as read
+func (ns *NotificationService) MarkRead(ctx context.Context, userID, notificationID string) (*models.Notification, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	notificationObjID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification ID")
+	}
+
+	notification := &models.Notification{}
+	err = ns.notificationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": notificationObjID, "userId": objID, "inbox": true},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(notification)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("notification not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+// MarkAllRead marks every unread in-app notification as read
+func (ns *NotificationService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID")
+	}
+
+	result, err := ns.notificationCollection.UpdateMany(ctx,
+		bson.M{"userId": objID, "inbox": true, "read": false},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// GetPreferences retrieves the user's notification preferences, with the default
+// channels filled in for types the user hasn't configured
+func (ns *NotificationService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	prefs := &models.NotificationPreferences{}
+	err = ns.preferenceCollection.FindOne(ctx, bson.M{"userId": objID}).Decode(prefs)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		prefs = &models.NotificationPreferences{UserID: objID}
+	}
+
+	if prefs.Channels == nil {
+		prefs.Channels = map[models.NotificationType][]models.NotificationChannel{}
+	}
+	for _, notificationType := range notificationTypes {
+		if _, ok := prefs.Channels[notificationType]; !ok {
+			prefs.Channels[notificationType] = defaultNotificationChannels
+		}
+	}
+
+	return prefs, nil
+}
+
+// notificationTypes lists every notification type users can configure
+var notificationTypes = []models.NotificationType{
+	models.NotificationBudgetAlert,
+	models.NotificationBillDue,
+	models.NotificationFundDue,
+	models.NotificationSecurityNewLogin,
+}
+
+func validateNotificationPreferences(ctx context.Context, req models.NotificationPreferencesRequest) error {
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			return fmt.Errorf("invalid email address")
+		}
+	}
+	if req.WebhookURL != "" {
+		if err := notifiers.CheckWebhookURL(ctx, req.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	for notificationType, channels := range req.Channels {
+		if !notifiers.Known(notificationType) {
+			return fmt.Errorf("unknown notification type %q", notificationType)
+		}
+		for _, channel := range channels {
+			switch channel {
+			case models.NotificationChannelInApp, models.NotificationChannelEmail:
+			case models.NotificationChannelWebhook:
+				if req.WebhookURL == "" {
+					return fmt.Errorf("webhook URL is required for the WEBHOOK channel")
+				}
+			default:
+				return fmt.Errorf("invalid channel %q, must be IN_APP, EMAIL or WEBHOOK", channel)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UpdatePreferences replaces the user's notification preferences
+func (ns *NotificationService) UpdatePreferences(ctx context.Context, userID string, req models.NotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	if err := validateNotificationPreferences(ctx, req); err != nil {
+		return nil, err
+	}
+
+	channels := req.Channels
+	if channels == nil {
+		channels = map[models.NotificationType][]models.NotificationChannel{}
+	}
+
+	set := bson.M{
+		"email":      req.Email,
+		"webhookUrl": req.WebhookURL,
+		"channels":   channels,
+		"updatedAt":  time.Now(),
+	}
+	if req.WebhookSecret != nil {
+		set["webhookSecret"] = *req.WebhookSecret
+	}
+
+	_, err = ns.preferenceCollection.UpdateOne(ctx,
+		bson.M{"userId": objID},
+		bson.M{"$set": set},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.GetPreferences(ctx, userID)
+}
+
+// RemindBill notifies the user that a bill is coming due
+func (ns *NotificationService) RemindBill(ctx context.Context, bill models.Bill) error {
+	dueDate := bill.DueDate.Format("2006-01-02")
+	amount := fmt.Sprintf("%.2f", bill.Amount)
+	if bill.Estimated {
+		amount = "about " + amount
+	}
+
+	return ns.Notify(ctx, bill.UserID.Hex(), models.NotificationEvent{
+		Type: models.NotificationBillDue,
+		Data: map[string]string{
+			"billId":  bill.ID.Hex(),
+			"payee":   bill.Payee,
+			"amount":  amount,
+			"dueDate": dueDate,
+			"autopay": fmt.Sprintf("%t", bill.Autopay),
+		},
+		DedupKey: "bill-due:" + bill.ID.Hex() + ":" + dueDate,
+	})
+}
+
+// RemindFund notifies the user that a fund, or one of its instalments, is coming due
+func (ns *NotificationService) RemindFund(ctx context.Context, fund models.Fund, dueDate time.Time, amount float64) error {
+	day := dueDate.Format("2006-01-02")
+
+	return ns.Notify(ctx, fund.UserID.Hex(), models.NotificationEvent{
+		Type: models.NotificationFundDue,
+		Data: map[string]string{
+			"fundId":     fund.ID.Hex(),
+			"personName": fund.PersonName,
+			"type":       string(fund.Type),
+			"amount":     fmt.Sprintf("%.2f", amount),
+			"dueDate":    day,
+		},
+		DedupKey: "fund-due:" + fund.ID.Hex() + ":" + day,
+	})
+}
+
+// NotifyAlert notifies the user of a budget alert that fired
+func (ns *NotificationService) NotifyAlert(ctx context.Context, userID string, trigger models.BudgetAlertTrigger) error {
+	month := time.Date(trigger.Year, time.Month(trigger.Month), 1, 0, 0, 0, 0, time.UTC)
+	return ns.Notify(ctx, userID, models.NotificationEvent{
+		Type: models.NotificationBudgetAlert,
+		Data: map[string]string{
+			"alertType": string(trigger.Type),
+			"year":      fmt.Sprintf("%d", trigger.Year),
+			"month":     month.Format("January 2006"),
+			"threshold": fmt.Sprintf("%.2f", trigger.Threshold),
+			"value":     fmt.Sprintf("%.2f", trigger.Value),
+			"category":  trigger.Category,
+			"message":   trigger.Message,
+		},
+		DedupKey: fmt.Sprintf("budget-alert:%s:%d-%02d", trigger.AlertKey, trigger.Year, trigger.Month),
+	})
+}
+
+func hasChannel(channels []models.NotificationChannel, channel models.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"github.com/huxxnainali/finance-app/internal/notifiers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type nopSender struct{}
+
+func (nopSender) Send(context.Context, notifiers.Message) error { return nil }
+
+func TestNotifyRetriesAfterQueueingFails(t *testing.T) {
+	db := testDatabase(t)
+	userService := NewUserService(db)
+	ns := NewNotificationService(db, userService, map[models.NotificationChannel]notifiers.Sender{
+		models.NotificationChannelEmail: nopSender{},
+	})
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID()
+	event := models.NotificationEvent{
+		Type:     models.NotificationBudgetAlert,
+		Data:     map[string]string{"month": "2026-01", "message": "Over budget"},
+		DedupKey: "test-alert",
+	}
+
+	// Without the user the email address can't be looked up, so queueing fails
+	if err := ns.Notify(ctx, userID.Hex(), event); err == nil {
+		t.Fatal("Notify succeeded without a user to email")
+	}
+	count, err := ns.notificationCollection.CountDocuments(ctx, bson.M{"userId": userID})
+	if err != nil {
+		t.Fatalf("count notifications: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("%d notifications kept after queueing failed, want 0", count)
+	}
+
+	if _, err := userService.collection.InsertOne(ctx, models.User{ID: userID, Email: "ali@example.com", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if err := ns.Notify(ctx, userID.Hex(), event); err != nil {
+		t.Fatalf("retried Notify: %v", err)
+	}
+	queued, err := ns.deliveryCollection.CountDocuments(ctx, bson.M{"userId": userID, "target": "ali@example.com"})
+	if err != nil {
+		t.Fatalf("count deliveries: %v", err)
+	}
+	if queued != 1 {
+		t.Errorf("%d email deliveries queued by the retry, want 1", queued)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{8, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
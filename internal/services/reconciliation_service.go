@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reconciliationTolerance is how far the cleared balance may be from the statement when completing
+const reconciliationTolerance = 0.005
+
+type ReconciliationService struct {
+	collection     *mongo.Collection
+	accountService *AccountService
+	budgetService  *BudgetService
+}
+
+func NewReconciliationService(db *mongo.Database, accountService *AccountService, budgetService *BudgetService) *ReconciliationService {
+	collection := db.Collection("reconciliations")
+
+	// Create index on userId and accountId
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "accountId", Value: 1},
+		},
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &ReconciliationService{
+		collection:     collection,
+		accountService: accountService,
+		budgetService:  budgetService,
+	}
+}
+
+// StartReconciliation opens a reconciliation of an account against a statement.
+// An account can only have one reconciliation in progress, and statements must
+// be reconciled in date order.
+func (rs *ReconciliationService) StartReconciliation(ctx context.Context, userID, accountID string, req models.ReconciliationRequest) (*models.ReconciliationResponse, error) {
+	account, err := rs.accountService.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if req.StatementDate.IsZero() {
+		return nil, fmt.Errorf("statement date is required")
+	}
+
+	inProgress, err := rs.collection.CountDocuments(ctx, bson.M{
+		"userId":    account.UserID,
+		"accountId": account.ID,
+		"status":    models.ReconciliationInProgress,
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return nil, err
+	}
+	if inProgress > 0 {
+		return nil, fmt.Errorf("account already has a reconciliation in progress")
+	}
+
+	latest := &models.Reconciliation{}
+	err = rs.collection.FindOne(ctx, bson.M{
+		"userId":    account.UserID,
+		"accountId": account.ID,
+		"status":    models.ReconciliationCompleted,
+	}, options.FindOne().SetSort(bson.D{{Key: "statementDate", Value: -1}})).Decode(latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil && !req.StatementDate.After(latest.StatementDate) {
+		return nil, fmt.Errorf("statement date must be after the last reconciled statement")
+	}
+
+	reconciliation := &models.Reconciliation{
+		ID:             primitive.NewObjectID(),
+		UserID:         account.UserID,
+		AccountID:      account.ID,
+		StatementDate:  req.StatementDate,
+		ClosingBalance: req.ClosingBalance,
+		Status:         models.ReconciliationInProgress,
+		CreatedAt:      time.Now(),
+	}
+
+	if _, err := rs.collection.InsertOne(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+
+	return rs.reconciliationResponse(ctx, userID, account, reconciliation)
+}
+
+// GetReconciliations lists an account's reconciliations, newest statement first
+func (rs *ReconciliationService) GetReconciliations(ctx context.Context, userID, accountID string) ([]models.Reconciliation, error) {
+	account, err := rs.accountService.getAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "statementDate", Value: -1}})
+	cursor, err := rs.collection.Find(ctx, bson.M{
+		"userId":    account.UserID,
+		"accountId": account.ID,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reconciliations := []models.Reconciliation{}
+	if err := cursor.All(ctx, &reconciliations); err != nil {
+		return nil, err
+	}
+
+	return reconciliations, nil
+}
+
+// getReconciliation retrieves a reconciliation together with its account
+func (rs *ReconciliationService) getReconciliation(ctx context.Context, userID, reconciliationID string) (*models.Reconciliation, *models.Account, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid user ID")
+	}
+
+	reconciliationObjID, err := primitive.ObjectIDFromHex(reconciliationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid reconciliation ID")
+	}
+
+	reconciliation := &models.Reconciliation{}
+	err = rs.collection.FindOne(ctx, bson.M{
+		"_id":    reconciliationObjID,
+		"userId": userObjID,
+	}).Decode(reconciliation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, fmt.Errorf("reconciliation not found or doesn't belong to user")
+		}
+		return nil, nil, err
+	}
+
+	account, err := rs.accountService.getAccount(ctx, userID, reconciliation.AccountID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reconciliation, account, nil
+}
+
+// GetReconciliation retrieves a reconciliation with its cleared balance and difference
+func (rs *ReconciliationService) GetReconciliation(ctx context.Context, userID, reconciliationID string) (*models.ReconciliationResponse, error) {
+	reconciliation, account, err := rs.getReconciliation(ctx, userID, reconciliationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.reconciliationResponse(ctx, userID, account, reconciliation)
+}
+
+// ClearEntries marks expenses, incomes and transfers of the account as cleared or
+// uncleared. Only entries up to the statement date can be cleared, and entries
+// locked by an earlier reconciliation are left alone.
+func (rs *ReconciliationService) ClearEntries(ctx context.Context, userID, reconciliationID string, req models.ClearEntriesRequest) (*models.ReconciliationResponse, error) {
+	reconciliation, account, err := rs.getReconciliation(ctx, userID, reconciliationID)
+	if err != nil {
+		return nil, err
+	}
+	if reconciliation.Status != models.ReconciliationInProgress {
+		return nil, fmt.Errorf("reconciliation is already completed")
+	}
+	if len(req.EntryIDs) == 0 {
+		return nil, fmt.Errorf("at least one entry ID is required")
+	}
+
+	entries, err := rs.accountService.accountEntries(ctx, userID, account)
+	if err != nil {
+		return nil, err
+	}
+	entriesByID := make(map[string]models.AccountEntry, len(entries))
+	for _, entry := range entries {
+		entriesByID[entry.ID] = entry
+	}
+
+	statementEnd := endOfDay(reconciliation.StatementDate)
+	var entryIDs, transferIDs []primitive.ObjectID
+	for _, id := range req.EntryIDs {
+		entry, ok := entriesByID[id]
+		if !ok {
+			return nil, fmt.Errorf("entry %s not found on account", id)
+		}
+		if entry.Reconciled {
+			return nil, fmt.Errorf("entry %s is reconciled and locked", id)
+		}
+		if req.Cleared && entry.Date.After(statementEnd) {
+			return nil, fmt.Errorf("entry %s is dated after the statement date", id)
+		}
+
+		objID, _ := primitive.ObjectIDFromHex(id)
+		switch entry.Kind {
+		case models.AccountEntryTransferIn, models.AccountEntryTransferOut:
+			transferIDs = append(transferIDs, objID)
+		default:
+			entryIDs = append(entryIDs, objID)
+		}
+	}
+
+	if len(entryIDs) > 0 {
+		if _, err := rs.budgetService.SetEntriesCleared(ctx, userID, account.ID, entryIDs, req.Cleared); err != nil {
+			return nil, err
+		}
+	}
+	if len(transferIDs) > 0 {
+		if err := rs.accountService.SetTransfersCleared(ctx, account, transferIDs, req.Cleared); err != nil {
+			return nil, err
+		}
+	}
+
+	return rs.reconciliationResponse(ctx, userID, account, reconciliation)
+}
+
+// CompleteReconciliation locks the cleared entries of the account up to the statement
+// date once the cleared balance matches the statement's closing balance
+func (rs *ReconciliationService) CompleteReconciliation(ctx context.Context, userID, reconciliationID string) (*models.ReconciliationResponse, error) {
+	reconciliation, account, err := rs.getReconciliation(ctx, userID, reconciliationID)
+	if err != nil {
+		return nil, err
+	}
+	if reconciliation.Status != models.ReconciliationInProgress {
+		return nil, fmt.Errorf("reconciliation is already completed")
+	}
+
+	response, err := rs.reconciliationResponse(ctx, userID, account, reconciliation)
+	if err != nil {
+		return nil, err
+	}
+	if math.Abs(response.Difference) >= reconciliationTolerance {
+		return nil, fmt.Errorf("cleared balance differs from the statement by %.2f", response.Difference)
+	}
+
+	// Lock exactly the entries counted in the cleared balance
+	var entryIDs, transferIDs []primitive.ObjectID
+	for _, entry := range response.ClearedEntries {
+		if entry.Reconciled {
+			continue
+		}
+		objID, _ := primitive.ObjectIDFromHex(entry.ID)
+		switch entry.Kind {
+		case models.AccountEntryTransferIn, models.AccountEntryTransferOut:
+			transferIDs = append(transferIDs, objID)
+		default:
+			entryIDs = append(entryIDs, objID)
+		}
+	}
+	if len(entryIDs) > 0 {
+		if err := rs.budgetService.LockClearedEntries(ctx, userID, account.ID, reconciliation.ID, entryIDs); err != nil {
+			return nil, err
+		}
+	}
+	if len(transferIDs) > 0 {
+		if err := rs.accountService.LockClearedTransfers(ctx, account, reconciliation.ID, transferIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	err = rs.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": reconciliation.ID, "status": models.ReconciliationInProgress},
+		bson.M{"$set": bson.M{"status": models.ReconciliationCompleted, "completedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(reconciliation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("reconciliation is already completed")
+		}
+		return nil, err
+	}
+
+	return rs.reconciliationResponse(ctx, userID, account, reconciliation)
+}
+
+// DeleteReconciliation abandons a reconciliation that is still in progress.
+// Entries keep their cleared flags.
+func (rs *ReconciliationService) DeleteReconciliation(ctx context.Context, userID, reconciliationID string) error {
+	reconciliation, _, err := rs.getReconciliation(ctx, userID, reconciliationID)
+	if err != nil {
+		return err
+	}
+
+	result, err := rs.collection.DeleteOne(ctx, bson.M{
+		"_id":    reconciliation.ID,
+		"status": models.ReconciliationInProgress,
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("reconciliation is already completed")
+	}
+
+	return nil
+}
+
+// reconciliationResponse computes the cleared balance of the account up to the
+// statement date and splits the entries up to then into cleared and uncleared.
+// Entries dated later don't count, even when cleared.
+func (rs *ReconciliationService) reconciliationResponse(ctx context.Context, userID string, account *models.Account, reconciliation *models.Reconciliation) (*models.ReconciliationResponse, error) {
+	entries, err := rs.accountService.accountEntries(ctx, userID, account)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ReconciliationResponse{
+		Reconciliation:   *reconciliation,
+		ClearedEntries:   []models.AccountEntry{},
+		UnclearedEntries: []models.AccountEntry{},
+	}
+
+	statementEnd := endOfDay(reconciliation.StatementDate)
+	cleared := account.OpeningBalance
+	for _, entry := range entries {
+		if entry.Date.After(statementEnd) {
+			continue
+		}
+		if entry.Cleared {
+			cleared += entry.Amount
+			entry.Balance = cleared
+			response.ClearedEntries = append(response.ClearedEntries, entry)
+			continue
+		}
+		response.UnclearedEntries = append(response.UnclearedEntries, entry)
+	}
+
+	response.ClearedBalance = math.Round(cleared*100) / 100
+	response.Difference = math.Round((reconciliation.ClosingBalance-cleared)*100) / 100
+
+	return response, nil
+}
+
+// endOfDay returns the last instant of the day t falls on
+func endOfDay(t time.Time) time.Time {
+	return truncateDay(t).Add(24*time.Hour - time.Nanosecond)
+}
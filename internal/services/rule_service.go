@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RuleService struct {
+	collection    *mongo.Collection
+	budgetService *BudgetService
+}
+
+func NewRuleService(db *mongo.Database, budgetService *BudgetService) *RuleService {
+	collection := db.Collection("category_rules")
+
+	// Create index on userId and priority for rules
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "userId", Value: 1},
+			{Key: "priority", Value: 1},
+		},
+	}
+	collection.Indexes().CreateOne(context.Background(), indexModel)
+
+	return &RuleService{
+		collection:    collection,
+		budgetService: budgetService,
+	}
+}
+
+// compiledRule is a rule with its patterns compiled
+type compiledRule struct {
+	rule     models.CategoryRule
+	title    *regexp.Regexp
+	merchant *regexp.Regexp
+}
+
+// RuleSet is a user's enabled rules, compiled and ordered by priority
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// compileRule validates a rule and compiles its patterns
+func compileRule(rule models.CategoryRule) (*compiledRule, error) {
+	compiled := &compiledRule{rule: rule}
+
+	var err error
+	if rule.Conditions.TitlePattern != "" {
+		compiled.title, err = regexp.Compile("(?i)" + rule.Conditions.TitlePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title pattern: %v", err)
+		}
+	}
+	if rule.Conditions.MerchantPattern != "" {
+		compiled.merchant, err = regexp.Compile("(?i)" + rule.Conditions.MerchantPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merchant pattern: %v", err)
+		}
+	}
+
+	return compiled, nil
+}
+
+// matches reports whether an expense passes every condition of the rule
+func (cr *compiledRule) matches(expense *models.Expense) bool {
+	c := cr.rule.Conditions
+	if cr.title != nil && !cr.title.MatchString(expense.Title) {
+		return false
+	}
+	if cr.merchant != nil && !cr.merchant.MatchString(expense.Merchant) {
+		return false
+	}
+	if c.MinAmount != nil && expense.Amount < *c.MinAmount {
+		return false
+	}
+	if c.MaxAmount != nil && expense.Amount > *c.MaxAmount {
+		return false
+	}
+	if c.PaymentMethod != "" && !strings.EqualFold(c.PaymentMethod, expense.PaymentMethod) {
+		return false
+	}
+	return true
+}
+
+// applyActions makes a rule's changes to an expense. An existing category is
+// only replaced when overwrite is set; tags are added to the existing ones.
+func applyActions(expense *models.Expense, a models.RuleActions, overwrite bool) {
+	if a.SetCategory != "" && (expense.Category == "" || overwrite) {
+		expense.Category = a.SetCategory
+	}
+	if a.RenameTo != "" {
+		expense.Title = a.RenameTo
+	}
+	for _, tag := range a.AddTags {
+		if !containsTag(expense.Tags, tag) {
+			expense.Tags = append(expense.Tags, tag)
+		}
+	}
+}
+
+// Apply runs every rule against an expense in priority order and reports whether it changed.
+// The first rule to set a category or title wins; later rules can still add tags.
+func (set *RuleSet) Apply(expense *models.Expense) bool {
+	if set == nil || len(set.rules) == 0 {
+		return false
+	}
+
+	before := *expense
+	before.Tags = append([]string(nil), expense.Tags...)
+
+	categorized, renamed := false, false
+	for i := range set.rules {
+		rule := &set.rules[i]
+		if !rule.matches(expense) {
+			continue
+		}
+
+		actions := rule.rule.Actions
+		if categorized {
+			actions.SetCategory = ""
+		}
+		if renamed {
+			actions.RenameTo = ""
+		}
+		applyActions(expense, actions, false)
+
+		categorized = categorized || expense.Category != ""
+		renamed = renamed || actions.RenameTo != ""
+	}
+
+	return expenseRuleFieldsChanged(&before, expense)
+}
+
+// LoadRuleSet compiles a user's enabled rules
+func (rs *RuleService) LoadRuleSet(ctx context.Context, userID string) (*RuleSet, error) {
+	rules, err := rs.GetRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &RuleSet{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		compiled, err := compileRule(rule)
+		if err != nil {
+			// Rules are validated on save; skip any that no longer compile
+			continue
+		}
+		set.rules = append(set.rules, *compiled)
+	}
+
+	return set, nil
+}
+
+// Categorize applies the user's rules to a new expense
+func (rs *RuleService) Categorize(ctx context.Context, userID string, expense *models.Expense) error {
+	set, err := rs.LoadRuleSet(ctx, userID)
+	if err != nil {
+		return err
+	}
+	set.Apply(expense)
+	return nil
+}
+
+// GetRules retrieves all rules for a user in priority order
+func (rs *RuleService) GetRules(ctx context.Context, userID string) ([]models.CategoryRule, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := rs.collection.Find(ctx, bson.M{"userId": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []models.CategoryRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetRuleByID retrieves a rule by ID
+func (rs *RuleService) GetRuleByID(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	ruleObjID, err := primitive.ObjectIDFromHex(ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule ID")
+	}
+
+	rule := &models.CategoryRule{}
+	err = rs.collection.FindOne(ctx, bson.M{
+		"_id":    ruleObjID,
+		"userId": userObjID,
+	}).Decode(rule)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("rule not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// validateRuleRequest checks a rule request and returns the rule it describes
+func validateRuleRequest(req models.CategoryRuleRequest) (*models.CategoryRule, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("rule name is required")
+	}
+
+	c := req.Conditions
+	if c.TitlePattern == "" && c.MerchantPattern == "" && c.MinAmount == nil && c.MaxAmount == nil && c.PaymentMethod == "" {
+		return nil, fmt.Errorf("rule needs at least one condition")
+	}
+	if c.MinAmount != nil && c.MaxAmount != nil && *c.MinAmount > *c.MaxAmount {
+		return nil, fmt.Errorf("minimum amount cannot be greater than maximum amount")
+	}
+
+	a := req.Actions
+	if a.SetCategory == "" && a.RenameTo == "" && len(a.AddTags) == 0 {
+		return nil, fmt.Errorf("rule needs at least one action")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	rule := &models.CategoryRule{
+		Name:       strings.TrimSpace(req.Name),
+		Priority:   req.Priority,
+		Enabled:    enabled,
+		Conditions: c,
+		Actions:    a,
+	}
+	if _, err := compileRule(*rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// CreateRule creates a new rule
+func (rs *RuleService) CreateRule(ctx context.Context, userID string, req models.CategoryRuleRequest) (*models.CategoryRule, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	rule, err := validateRuleRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rule.ID = primitive.NewObjectID()
+	rule.UserID = objID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	_, err = rs.collection.InsertOne(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateRule updates an existing rule
+func (rs *RuleService) UpdateRule(ctx context.Context, userID, ruleID string, req models.CategoryRuleRequest) (*models.CategoryRule, error) {
+	existing, err := rs.GetRuleByID(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := validateRuleRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	result := &models.CategoryRule{}
+	err = rs.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":    existing.ID,
+			"userId": existing.UserID,
+		},
+		bson.M{
+			"$set": bson.M{
+				"name":       rule.Name,
+				"priority":   rule.Priority,
+				"enabled":    rule.Enabled,
+				"conditions": rule.Conditions,
+				"actions":    rule.Actions,
+				"updatedAt":  time.Now(),
+			},
+		},
+		opts,
+	).Decode(result)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("rule not found or doesn't belong to user")
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteRule deletes a rule
+func (rs *RuleService) DeleteRule(ctx context.Context, userID, ruleID string) error {
+	rule, err := rs.GetRuleByID(ctx, userID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	_, err = rs.collection.DeleteOne(ctx, bson.M{
+		"_id":    rule.ID,
+		"userId": rule.UserID,
+	})
+	return err
+}
+
+// DryRunRule lists the existing expenses a rule would change without changing them
+func (rs *RuleService) DryRunRule(ctx context.Context, userID string, req models.CategoryRuleRequest, overwrite bool) (*models.RuleDryRunResponse, error) {
+	rule, err := validateRuleRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.runRuleOnHistory(ctx, userID, *rule, overwrite, false)
+}
+
+// DryRunSavedRule lists the existing expenses a saved rule would change without changing them
+func (rs *RuleService) DryRunSavedRule(ctx context.Context, userID, ruleID string, overwrite bool) (*models.RuleDryRunResponse, error) {
+	rule, err := rs.GetRuleByID(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.runRuleOnHistory(ctx, userID, *rule, overwrite, false)
+}
+
+// ApplyRuleToHistory applies a saved rule to all of the user's existing expenses
+func (rs *RuleService) ApplyRuleToHistory(ctx context.Context, userID, ruleID string, overwrite bool) (*models.RuleDryRunResponse, error) {
+	rule, err := rs.GetRuleByID(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rs.runRuleOnHistory(ctx, userID, *rule, overwrite, true)
+}
+
+// runRuleOnHistory evaluates a single rule against every expense of the user
+// and, when apply is set, writes the changes in one bulk update
+func (rs *RuleService) runRuleOnHistory(ctx context.Context, userID string, rule models.CategoryRule, overwrite, apply bool) (*models.RuleDryRunResponse, error) {
+	compiled, err := compileRule(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RuleDryRunResponse{Changes: []models.RuleChange{}}
+	var updates []models.Expense
+
+	err = rs.budgetService.IterateBudgets(ctx, userID, func(budget *models.MonthlyBudget) error {
+		for _, expense := range budget.Expenses {
+			if !compiled.matches(&expense) {
+				continue
+			}
+			result.Matched++
+
+			after := expense
+			after.Tags = append([]string(nil), expense.Tags...)
+			applyActions(&after, compiled.rule.Actions, overwrite)
+			if !expenseRuleFieldsChanged(&expense, &after) {
+				continue
+			}
+			if expense.ReconciliationID != nil {
+				result.Skipped++
+				continue
+			}
+
+			result.Changes = append(result.Changes, models.RuleChange{
+				ExpenseID: expense.ID.Hex(),
+				Year:      budget.Year,
+				Month:     budget.Month,
+				Amount:    expense.Amount,
+				Before:    expense,
+				After:     after,
+			})
+			updates = append(updates, after)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Changed = len(result.Changes)
+
+	if apply && len(updates) > 0 {
+		updated, err := rs.budgetService.UpdateExpenseLabelsBulk(ctx, userID, updates)
+		if err != nil {
+			return nil, err
+		}
+		// Expenses reconciled since they were read are left out of the update
+		result.Skipped += len(updates) - updated
+		result.Applied = true
+	}
+
+	return result, nil
+}
+
+// expenseRuleFieldsChanged compares the fields rules can change
+func expenseRuleFieldsChanged(before, after *models.Expense) bool {
+	if before.Title != after.Title || before.Category != after.Category || len(before.Tags) != len(after.Tags) {
+		return true
+	}
+	for i := range before.Tags {
+		if before.Tags[i] != after.Tags[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
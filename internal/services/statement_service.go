@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/huxxnainali/finance-app/internal/models"
+)
+
+type StatementService struct {
+	budgetService *BudgetService
+	fundService   *FundService
+}
+
+func NewStatementService(budgetService *BudgetService, fundService *FundService) *StatementService {
+	return &StatementService{
+		budgetService: budgetService,
+		fundService:   fundService,
+	}
+}
+
+// GetMonthlyStatement collects a month's budget, the previous month for comparison
+// and the user's open funds
+func (ss *StatementService) GetMonthlyStatement(ctx context.Context, userID string, year, month int) (*models.MonthlyStatement, error) {
+	if year <= 0 || month < 1 || month > 12 {
+		return nil, fmt.Errorf("valid year and month are required")
+	}
+
+	budget, err := ss.budgetService.GetBudget(ctx, userID, year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	prevYear, prevMonth := year, month-1
+	if prevMonth == 0 {
+		prevYear, prevMonth = year-1, 12
+	}
+	previous, err := ss.budgetService.GetBudget(ctx, userID, prevYear, prevMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	openFunds, err := ss.openFunds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MonthlyStatement{
+		Budget:      NewBudgetResponse(budget),
+		Previous:    NewBudgetResponse(previous),
+		Categories:  statementCategories(budget.Expenses, previous.Expenses),
+		OpenFunds:   openFunds,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// openFunds lists the user's funds that still have an outstanding balance
+func (ss *StatementService) openFunds(ctx context.Context, userID string) ([]models.StatementFund, error) {
+	funds, _, err := ss.fundService.ListFunds(ctx, userID, FundListOptions{Page: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	open := []models.StatementFund{}
+	for _, fund := range funds {
+		if fund.Outstanding <= 0 {
+			continue
+		}
+
+		open = append(open, models.StatementFund{
+			PersonName:      fund.PersonName,
+			Type:            fund.Type,
+			PrincipalAmount: fund.PrincipalAmount,
+			TotalPaid:       fund.TotalPaid,
+			Outstanding:     fund.Outstanding,
+			StartDate:       fund.StartDate,
+		})
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].Type != open[j].Type {
+			return open[i].Type < open[j].Type
+		}
+		return open[i].Outstanding > open[j].Outstanding
+	})
+
+	return open, nil
+}
+
+// statementCategories groups expenses by category, largest first, with last month's
+// total per category for comparison
+func statementCategories(expenses, previous []models.Expense) []models.StatementCategory {
+	byCategory := map[string]*models.StatementCategory{}
+	category := func(name string) *models.StatementCategory {
+		if name == "" {
+			name = UncategorizedCategory
+		}
+		c, ok := byCategory[name]
+		if !ok {
+			c = &models.StatementCategory{Category: name, Expenses: []models.Expense{}}
+			byCategory[name] = c
+		}
+		return c
+	}
+
+	for _, expense := range expenses {
+		c := category(expense.Category)
+		c.Total += expense.Amount
+		c.Expenses = append(c.Expenses, expense)
+	}
+	for _, expense := range previous {
+		category(expense.Category).PreviousTotal += expense.Amount
+	}
+
+	categories := make([]models.StatementCategory, 0, len(byCategory))
+	for _, c := range byCategory {
+		categories = append(categories, *c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Total != categories[j].Total {
+			return categories[i].Total > categories[j].Total
+		}
+		return categories[i].Category < categories[j].Category
+	})
+
+	return categories
+}